@@ -1,91 +1,245 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"io/fs"
 	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
 	"github.com/immxrtalbeast/plandstu/internal/config"
 	"github.com/immxrtalbeast/plandstu/internal/controller"
 	"github.com/immxrtalbeast/plandstu/internal/domain"
+	"github.com/immxrtalbeast/plandstu/internal/health"
+	"github.com/immxrtalbeast/plandstu/internal/lib"
+	"github.com/immxrtalbeast/plandstu/internal/llmclient"
+	"github.com/immxrtalbeast/plandstu/internal/logging"
+	"github.com/immxrtalbeast/plandstu/internal/metrics"
 	"github.com/immxrtalbeast/plandstu/internal/middleware"
+	"github.com/immxrtalbeast/plandstu/internal/notify"
+	"github.com/immxrtalbeast/plandstu/internal/parser"
+	"github.com/immxrtalbeast/plandstu/internal/problem"
+	"github.com/immxrtalbeast/plandstu/internal/ratelimit"
 	"github.com/immxrtalbeast/plandstu/internal/task"
+	"github.com/immxrtalbeast/plandstu/internal/tracing"
+	"github.com/immxrtalbeast/plandstu/internal/usecase/access"
+	"github.com/immxrtalbeast/plandstu/internal/usecase/catalog"
+	"github.com/immxrtalbeast/plandstu/internal/usecase/group"
 	"github.com/immxrtalbeast/plandstu/internal/usecase/llm"
+	"github.com/immxrtalbeast/plandstu/internal/usecase/password"
+	"github.com/immxrtalbeast/plandstu/internal/usecase/profile"
 	"github.com/immxrtalbeast/plandstu/internal/usecase/report"
 	"github.com/immxrtalbeast/plandstu/internal/usecase/roadmap"
+	"github.com/immxrtalbeast/plandstu/internal/usecase/servicekey"
 	teachertest "github.com/immxrtalbeast/plandstu/internal/usecase/teacher_test"
 	"github.com/immxrtalbeast/plandstu/internal/usecase/tests"
 	"github.com/immxrtalbeast/plandstu/internal/usecase/user"
 	"github.com/immxrtalbeast/plandstu/internal/worker"
+	"github.com/immxrtalbeast/plandstu/storage/migrations"
 	"github.com/immxrtalbeast/plandstu/storage/psql"
 	"github.com/joho/godotenv"
-	"gorm.io/driver/postgres"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 	"gorm.io/gorm"
 )
 
 // go run .\cmd\main.go --config=./config/local.yaml
+// go run .\cmd\main.go --config=./config/local.yaml migrate up|down [steps]|status
+// go run .\cmd\main.go --config=./config/local.yaml service-key create <service> <scope>...
 func main() {
+	// .env необязателен: переменные окружения могут быть заданы напрямую
+	if err := godotenv.Load(".env"); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		panic(err)
+	}
 	cfg := config.MustLoad()
-	log := setupLogger()
+	log := setupLogger(cfg.Env)
+	slog.SetDefault(log)
 	log.Info("starting application", slog.Any("config", cfg))
-	if err := godotenv.Load(".env"); err != nil {
-		panic(err)
+
+	shutdownTracing, err := tracing.Init(context.Background(), cfg.Tracing)
+	if err != nil {
+		panic("failed to init tracing: " + err.Error())
 	}
 
-	dsn := fmt.Sprintf("postgresql://postgres.rcrqslgziljieocjppwc:%s@aws-0-eu-central-1.pooler.supabase.com:6543/postgres", os.Getenv("DB_PASS"))
-	db, err := gorm.Open(postgres.New(postgres.Config{
-		DSN:                  dsn,
-		PreferSimpleProtocol: true,
-	}), &gorm.Config{})
+	db, err := psql.Open(cfg.DB)
 	if err != nil {
-		panic("failed to connect database")
+		panic("failed to connect database: " + err.Error())
 	}
 	log.Info("db connected")
-	db.AutoMigrate(&domain.User{}, &domain.History{}, &domain.RoadmapHistory{}, &domain.RoadmapTest{}, &domain.Report{}, &domain.TeacherTest{})
+
+	migrator, err := migrations.New(db)
+	if err != nil {
+		panic("failed to load migrations: " + err.Error())
+	}
+	if args := flag.Args(); len(args) > 0 && args[0] == "migrate" {
+		runMigrate(migrator, log, args[1:])
+		return
+	}
+	pending, err := migrator.Pending(context.Background())
+	if err != nil {
+		panic("failed to check schema version: " + err.Error())
+	}
+	if len(pending) > 0 {
+		panic(fmt.Sprintf("database schema is behind by %d migration(s), run the migrate command first", len(pending)))
+	}
 	if err := db.Exec("DEALLOCATE ALL").Error; err != nil {
 		panic(err)
 	}
-	authMiddleware := middleware.AuthMiddleware(os.Getenv("APP_SECRET"))
-	teacherMiddleware := middleware.TeacherMiddleware(os.Getenv("APP_SECRET"))
+	auditRepo := psql.NewAuditRepository(db)
+	serviceKeyINT := servicekey.NewServiceKeyInteractor(psql.NewServiceKeyRepository(db), auditRepo, mustLoadSecretBox(cfg.ServiceAuth), cfg.ServiceAuth.MaxSkew)
+	resealed, err := serviceKeyINT.ResealSecrets(context.Background())
+	if err != nil {
+		panic("failed to reseal service key secrets: " + err.Error())
+	}
+	if resealed > 0 {
+		log.Info("service key secrets resealed", slog.Int("count", resealed))
+	}
+	if args := flag.Args(); len(args) > 0 && args[0] == "service-key" {
+		runServiceKey(serviceKeyINT, args[1:])
+		return
+	}
+	keys := mustLoadKeys(cfg, log)
 
+	groupRepo := psql.NewGroupRepository(db)
+	accessRepo := psql.NewAccessRepository(db)
+	if err := accessRepo.SeedRoles(context.Background(), domain.DefaultRolePermissions); err != nil {
+		panic(err)
+	}
 	usrRepo := psql.NewUserRepository(db)
-	userINT := user.NewUserInteractor(usrRepo, cfg.TokenTTL, os.Getenv("APP_SECRET"))
-	userController := controller.NewUserController(userINT, cfg.TokenTTL, os.Getenv("APP_SECRET"))
+	sessionRepo := psql.NewSessionRepository(db)
+	loginRepo := psql.NewLoginRepository(db)
+	loginPolicy := user.LoginPolicy{
+		FreeAttempts:     cfg.Login.FreeAttempts,
+		IPFreeAttempts:   cfg.Login.IPFreeAttempts,
+		BaseDelay:        cfg.Login.BaseDelay,
+		MaxDelay:         cfg.Login.MaxDelay,
+		FailureWindow:    cfg.Login.FailureWindow,
+		LockoutThreshold: cfg.Login.LockoutThreshold,
+		LockoutDuration:  cfg.Login.LockoutDuration,
+	}
+	userINT := user.NewUserInteractor(usrRepo, sessionRepo, accessRepo, auditRepo, groupRepo, loginRepo, cfg.TokenTTL, cfg.RefreshTokenTTL, loginPolicy, keys)
+	passwordINT := password.NewPasswordInteractor(usrRepo, sessionRepo, loginRepo, psql.NewPasswordResetRepository(db), newNotifier(cfg.Notify), cfg.PasswordReset.TokenTTL, cfg.PasswordReset.URL)
+	cookies := controller.CookieSettings{
+		Domain:   cfg.Cookie.Domain,
+		Secure:   cfg.Cookie.Secure,
+		HTTPOnly: cfg.Cookie.HTTPOnly,
+		SameSite: cfg.Cookie.SameSiteMode(),
+	}
+	userController := controller.NewUserController(userINT, passwordINT, cookies, cfg.TokenTTL, cfg.RefreshTokenTTL)
+	jwksController := controller.NewJWKSController(keys)
+
+	disciplineRepo := psql.NewDisciplineRepository(db)
+	accessINT := access.NewAccessInteractor(accessRepo, groupRepo, disciplineRepo)
+	accessController := controller.NewAccessController(accessINT)
+	adminController := controller.NewAdminController(userINT)
+	groupINT := group.NewGroupInteractor(groupRepo, usrRepo)
+	groupController := controller.NewGroupController(groupINT)
+
+	authMiddleware := middleware.AuthMiddleware(keys, userINT)
+	requirePermission := func(permissions ...string) gin.HandlerFunc {
+		return middleware.RequirePermission(accessINT, permissions...)
+	}
+	rateLimit, ipRateLimit := newRateLimit(cfg.RateLimit, cfg.Services.RedisAddr)
+	loginLimit := ipRateLimit(ratelimit.Rule{Name: "login", Limit: cfg.RateLimit.LoginPerMinute, Window: time.Minute})
+	forgotLimit := ipRateLimit(ratelimit.Rule{Name: "password_forgot", Limit: cfg.RateLimit.PasswordPerHour, Window: time.Hour})
+	resetLimit := ipRateLimit(ratelimit.Rule{Name: "password_reset", Limit: cfg.RateLimit.PasswordPerHour, Window: time.Hour})
+	chatLimits := rateLimit(
+		ratelimit.Rule{Name: "chat", Limit: cfg.RateLimit.ChatPerMinute, Window: time.Minute},
+		ratelimit.Rule{Name: "chat_daily", Limit: cfg.RateLimit.ChatPerDay, Window: 24 * time.Hour, Quota: true},
+	)
+	// Пробный и полный тест делят одни лимиты: оба занимают LLM
+	testLimits := rateLimit(
+		ratelimit.Rule{Name: "tests", Limit: cfg.RateLimit.TestsPerMinute, Window: time.Minute},
+		ratelimit.Rule{Name: "tests_daily", Limit: cfg.RateLimit.TestsPerDay, Window: 24 * time.Hour, Quota: true},
+	)
 	LLMRepo := psql.NewLLMRepository(db)
-	LLMINT := llm.NewLLMInteractor(LLMRepo)
-	LLMController := controller.NewLLMController(os.Getenv("LLM_URL"), LLMINT)
+	llmClient := llmclient.New(cfg.Services.LLMURL, llmclient.DefaultOptions())
+	LLMINT := llm.NewLLMInteractor(LLMRepo, disciplineRepo, llmClient)
+	LLMController := controller.NewLLMController(llmClient, LLMINT)
 	RoadmapRepo := psql.NewRoadmapRepository(db)
 	TeacherTestRepo := psql.NewTeacherTestRepository(db)
-	TeacherTestINT := teachertest.NewTeacherTestInteractor(TeacherTestRepo)
-	TeacherTestController := controller.NewTeacherTestController(TeacherTestINT)
+	TeacherTestINT := teachertest.NewTeacherTestInteractor(TeacherTestRepo, disciplineRepo)
+	TeacherTestController := controller.NewTeacherTestController(TeacherTestINT, accessINT)
 	TestRepository := psql.NewTestRepository(db)
 
-	RoadmapINT := roadmap.NewRoadmapInteractor(RoadmapRepo, TestRepository)
+	RoadmapINT := roadmap.NewRoadmapInteractor(RoadmapRepo, TestRepository, disciplineRepo)
 	RoadmapController := controller.NewRoadmapController(RoadmapINT)
 
-	TestINT := tests.NewTestInteractor(TestRepository, os.Getenv("LLM_URL"), RoadmapRepo)
-	TestsController := controller.NewTestsController(os.Getenv("LLM_URL"), RoadmapINT, TestINT, os.Getenv("REDIS_URL"), TeacherTestINT)
-	parserController := controller.NewParserController(os.Getenv("PARSER_URL"))
+	TestINT := tests.NewTestInteractor(TestRepository, llmClient, RoadmapRepo)
+	TestsController := controller.NewTestsController(llmClient, RoadmapINT, TestINT, cfg.Services.RedisAddr, TeacherTestINT)
 
 	ReportRepo := psql.NewReportRepository(db)
-	ReportINT := report.NewReportInteractor(ReportRepo)
-	ReportController := controller.NewReportController(ReportINT, RoadmapINT, userINT)
+	ReportINT := report.NewReportInteractor(ReportRepo, disciplineRepo)
+	catalogRepo := psql.NewCatalogRepository(db)
+	catalogINT := catalog.NewCatalogInteractor(parser.NewClient(cfg.Services.ParserURL), catalogRepo, disciplineRepo, cfg.Catalog.TTL, cfg.Catalog.CacheSize)
+	parserController := controller.NewParserController(catalogINT)
+	disciplineController := controller.NewDisciplineController(catalogINT)
+	profileINT := profile.NewProfileInteractor(usrRepo, groupRepo, catalogINT)
+	profileController := controller.NewProfileController(profileINT)
+	ReportController := controller.NewReportController(ReportINT, RoadmapINT, userINT, accessINT)
 
-	task.Init(os.Getenv("REDIS_URL"))
-	worker := worker.NewWorker(os.Getenv("REDIS_URL"), 10, TestINT)
-	go func() {
-		if err := worker.Start(); err != nil {
-			panic("worker failed")
+	task.Init(cfg.Services.RedisAddr)
+	worker := worker.NewWorker(cfg.Services.RedisAddr, cfg.Worker.Concurrency, cfg.HTTP.ShutdownTimeout-cfg.HTTP.DrainDelay, log, TestINT, llmClient)
+	if err := worker.Start(); err != nil {
+		panic("failed to start worker: " + err.Error())
+	}
+	background, stopBackground := context.WithCancel(logging.WithLogger(context.Background(), log.With(slog.String("component", "catalog"))))
+	defer stopBackground()
+	go catalogINT.RunRefresh(background, cfg.Catalog.RefreshInterval)
+	go serviceKeyINT.RunPurge(logging.WithLogger(background, log.With(slog.String("component", "service_auth"))), cfg.ServiceAuth.PurgeInterval)
+	go userINT.RunPurge(logging.WithLogger(background, log.With(slog.String("component", "auth"))), cfg.TokenPurgeInterval)
+	serviceKeyController := controller.NewServiceKeyController(serviceKeyINT)
+	checker := health.New(cfg.HTTP.HealthTimeout)
+	checker.Register("postgres", func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
 		}
-	}()
-	router := gin.Default()
+		return sqlDB.PingContext(ctx)
+	})
+	checker.Register("redis", func(context.Context) error { return task.Ping() })
+	checkClient := &http.Client{Timeout: cfg.HTTP.HealthTimeout}
+	// Справочники парсера отдаются из кэша, без парсера сервис остаётся готовым
+	checker.RegisterOptional("parser", health.HTTPCheck(checkClient, cfg.Services.ParserURL))
+	checker.Register("llm", health.HTTPCheck(checkClient, cfg.Services.LLMURL))
+	healthController := controller.NewHealthController(checker)
 
-	config := cors.DefaultConfig()
-	config.AllowOrigins = []string{
-		"http://localhost:3000",
+	inspector := asynq.NewInspector(asynq.RedisClientOpt{Addr: cfg.Services.RedisAddr})
+	defer inspector.Close()
+	prometheus.MustRegister(metrics.NewQueueCollector(inspector, "default"))
+
+	router := gin.New()
+	// Контекст запроса со спаном должен быть виден через gin.Context в интеракторах и репозиториях
+	router.ContextWithFallback = true
+	// Без списка gin верит X-Forwarded-For от любого клиента, и IP в лимитах входа подделывается
+	if err := router.SetTrustedProxies(cfg.HTTP.TrustedProxies); err != nil {
+		panic("invalid trusted proxies: " + err.Error())
 	}
+	router.Use(
+		gin.CustomRecovery(func(c *gin.Context, recovered any) {
+			problem.Abort(c, fmt.Errorf("panic: %v", recovered))
+		}),
+		middleware.RequestID(log),
+		middleware.AccessLog(),
+		otelgin.Middleware(cfg.Tracing.ServiceName),
+		metrics.Middleware(),
+	)
+
+	config := cors.DefaultConfig()
+	config.AllowOrigins = cfg.CORS.AllowOrigins
 	config.AllowCredentials = true
 	config.AllowHeaders = []string{
 		"Authorization",
@@ -94,15 +248,36 @@ func main() {
 		"Accept",
 	}
 	config.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"}
-	config.ExposeHeaders = []string{"Set-Cookie"}
+	config.AllowHeaders = append(config.AllowHeaders, logging.RequestIDHeader, middleware.CSRFHeader)
+	config.ExposeHeaders = []string{"Set-Cookie", logging.RequestIDHeader, controller.ThreadIDHeader, middleware.CSRFHeader}
 	router.Use(cors.New(config))
+	router.NoRoute(func(c *gin.Context) {
+		problem.Abort(c, domain.NotFound("route_not_found", "route not found"))
+	})
+	router.GET("/healthz", healthController.Live)
+	router.GET("/metrics", metrics.Handler())
+	router.GET("/readyz", healthController.Ready)
+	router.GET("/.well-known/jwks.json", jwksController.JWKS)
+	// Проверка CSRF для запросов, авторизованных кукой. Вход, регистрация и
+	// сброс пароля сессию не используют и проверку не проходят
+	csrf := middleware.CSRF(middleware.AccessCookie, controller.RefreshCookie)
 	api := router.Group("/api/v1")
 	{
 		api.POST("/register", userController.Register)
-		api.POST("/login", userController.Login)
+		api.POST("/login", loginLimit, userController.Login)
+		api.POST("/refresh", csrf, userController.Refresh)
+		api.POST("/logout", authMiddleware, csrf, userController.Logout)
+		api.GET("/me", authMiddleware, profileController.Me)
+		api.PATCH("/me", authMiddleware, csrf, profileController.UpdateMe)
+		api.GET("/me/logins", authMiddleware, userController.LoginEvents)
+		api.POST("/me/password", authMiddleware, csrf, userController.ChangePassword)
+		api.POST("/password/forgot", forgotLimit, userController.ForgotPassword)
+		api.POST("/password/reset", resetLimit, userController.ResetPassword)
+		api.GET("/groups", groupController.Groups)
+		api.GET("/groups/:id", groupController.Group)
 	}
 	parser := api.Group("/parser")
-	parser.Use(authMiddleware)
+	parser.Use(authMiddleware, csrf)
 	{
 		parser.GET("/faculties", parserController.Faculties)
 		parser.GET("/faculties/:id", parserController.FacultyByID)
@@ -110,53 +285,289 @@ func main() {
 		parser.GET("/roadmap/:discipline/:link", parserController.Roadmap)
 
 	}
+	disciplines := api.Group("/disciplines")
+	disciplines.Use(authMiddleware, csrf)
+	{
+		disciplines.GET("", disciplineController.Disciplines)
+		disciplines.GET("/:id", disciplineController.Discipline)
+	}
 	llm := api.Group("/llm")
-	llm.Use(authMiddleware)
+	llm.Use(authMiddleware, csrf)
 	{
-		llm.GET("/chat", LLMController.Chat)
+		llm.POST("/chat", chatLimits, LLMController.Chat)
+		// Устаревший GET не проходит проверку CSRF. Фронтенду нужно перейти на
+		// POST /llm/chat с телом {"message": ..., "thread_id": ...} и заголовком X-CSRF-Token
+		llm.GET("/chat", middleware.Deprecated("/api/v1/llm/chat"), chatLimits, LLMController.Chat)
 		llm.GET("/history", LLMController.History)
-		llm.GET("/clear_history", LLMController.ClearHistory)
+		llm.GET("/history/export", LLMController.ExportHistory)
+		llm.DELETE("/history", LLMController.ClearHistory)
+		// Устаревший алиас DELETE /llm/history: его ещё вызывает текущий образ фронтенда
+		llm.GET("/clear_history", middleware.Deprecated("/api/v1/llm/history"), LLMController.ClearHistory)
+		llm.GET("/threads", LLMController.Threads)
+		llm.POST("/threads", LLMController.CreateThread)
+		llm.GET("/threads/:id", LLMController.Thread)
+		llm.PATCH("/threads/:id", LLMController.UpdateThread)
+		llm.DELETE("/threads/:id", LLMController.DeleteThread)
 	}
-	api.POST("/llm/save-history", LLMController.SaveHistory)
+	// Историю пишет только LLM-сервис, запрос подписывается его ключом
+	api.POST("/llm/save-history", middleware.ServiceAuth(serviceKeyINT, domain.ScopeChatHistoryWrite), LLMController.SaveHistory)
 
 	// api.GET("/roadmap/history/:link", RoadmapController.History).Use(authMiddleware)
 	// api.POST("/roadmap/send-report").Use(authMiddleware)
 	tests := api.Group("/tests")
-	tests.Use(authMiddleware)
+	tests.Use(authMiddleware, csrf)
 	{
 		tests.GET("/history", RoadmapController.History)
-		tests.POST("/first-test", TestsController.FirstTest)
+		tests.POST("/first-test", testLimits, TestsController.FirstTest)
 		tests.POST("/answers", TestsController.Answers)
-		tests.POST("/default-test", TestsController.CreateTest)
+		tests.POST("/default-test", testLimits, TestsController.CreateTest)
 		tests.GET("/my-history", TestsController.MyHistory)
 		tests.GET("/status", TestsController.GetTaskStatus)
 	}
 	report := api.Group("/report")
-	report.Use(authMiddleware)
+	report.Use(authMiddleware, csrf)
 	{
 		report.POST("/create", ReportController.CreateReport)
 		report.GET("/", ReportController.Report)
 	}
 	teacher := api.Group("/teacher")
-	teacher.Use(authMiddleware, teacherMiddleware)
+	teacher.Use(authMiddleware, csrf)
+	{
+		teacher.GET("/reports/disciplines", requirePermission(domain.PermReportsRead), ReportController.ReportsDisciplines)
+		teacher.GET("/reports/groups", requirePermission(domain.PermReportsRead), ReportController.ReportsGroup)
+		teacher.GET("/reports/stats", requirePermission(domain.PermReportsRead), ReportController.ReportsByGroupAndDiscipline)
+		teacher.GET("/test", requirePermission(domain.PermTeacherTestsManage), TeacherTestController.TeacherTest)
+		teacher.POST("/test/create", requirePermission(domain.PermTeacherTestsManage), TeacherTestController.CreateTeacherTest)
+		teacher.GET("/test/random", requirePermission(domain.PermTeacherTestsManage), TeacherTestController.RandomTestTest)
+		teacher.PUT("/test", requirePermission(domain.PermTeacherTestsManage), TeacherTestController.UpdateTeacherTest)
+		teacher.DELETE("/test", requirePermission(domain.PermTeacherTestsManage), TeacherTestController.DeleteTeacherTest)
+
+	}
+	admin := api.Group("/admin")
+	admin.Use(authMiddleware, csrf)
 	{
-		teacher.GET("/reports/disciplines", ReportController.ReportsDisciplines)
-		teacher.GET("/reports/groups", ReportController.ReportsGroup)
-		teacher.GET("/reports/stats", ReportController.ReportsByGroupAndDiscipline)
-		teacher.GET("/test", TeacherTestController.TeacherTest)
-		teacher.POST("/test/create", TeacherTestController.CreateTeacherTest)
-		teacher.GET("/test/random", TeacherTestController.RandomTestTest)
-		teacher.PUT("/test", TeacherTestController.UpdateTeacherTest)
-		teacher.DELETE("/test", TeacherTestController.DeleteTeacherTest)
+		admin.GET("/users/:id/grants", requirePermission(domain.PermGrantsManage), accessController.Grants)
+		admin.POST("/users/:id/grants", requirePermission(domain.PermGrantsManage), accessController.CreateGrant)
+		admin.DELETE("/grants/:grant_id", requirePermission(domain.PermGrantsManage), accessController.DeleteGrant)
+
+		admin.GET("/users", requirePermission(domain.PermUsersManage), adminController.Users)
+		admin.POST("/users", requirePermission(domain.PermUsersManage), adminController.CreateUser)
+		admin.GET("/users/:id", requirePermission(domain.PermUsersManage), adminController.User)
+		admin.DELETE("/users/:id", requirePermission(domain.PermUsersManage), adminController.DeleteUser)
+		admin.PATCH("/users/:id/role", requirePermission(domain.PermUsersManage), adminController.ChangeRole)
+		admin.POST("/users/:id/disable", requirePermission(domain.PermUsersManage), adminController.DisableUser)
+		admin.POST("/users/:id/enable", requirePermission(domain.PermUsersManage), adminController.EnableUser)
+		admin.POST("/users/:id/password", requirePermission(domain.PermUsersManage), adminController.ResetPassword)
+		admin.POST("/users/:id/unlock", requirePermission(domain.PermUsersManage), adminController.UnlockUser)
+		admin.GET("/audit", requirePermission(domain.PermUsersManage), adminController.AuditLogs)
+		admin.GET("/status", requirePermission(domain.PermSystemStatus), healthController.Status)
+		admin.POST("/catalog/resync", requirePermission(domain.PermCatalogSync), parserController.Resync)
+		admin.GET("/service-keys", requirePermission(domain.PermServiceKeysManage), serviceKeyController.ServiceKeys)
+		admin.POST("/service-keys", requirePermission(domain.PermServiceKeysManage), serviceKeyController.CreateServiceKey)
+		admin.DELETE("/service-keys/:id", requirePermission(domain.PermServiceKeysManage), serviceKeyController.RevokeServiceKey)
 
+		admin.POST("/groups", requirePermission(domain.PermGroupsManage), groupController.CreateGroup)
+		admin.PUT("/groups/:id", requirePermission(domain.PermGroupsManage), groupController.UpdateGroup)
+		admin.DELETE("/groups/:id", requirePermission(domain.PermGroupsManage), groupController.DeleteGroup)
+	}
+
+	server := &http.Server{Addr: cfg.HTTP.Address, Handler: router}
+	serverErr := make(chan error, 1)
+	go func() {
+		log.Info("http server started", slog.String("address", cfg.HTTP.Address))
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+
+	stop, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+	select {
+	case <-stop.Done():
+		log.Info("shutdown signal received")
+	case err := <-serverErr:
+		log.Error("http server failed", slog.String("error", err.Error()))
+	}
+	stopBackground()
+	shutdown(log, cfg.HTTP, healthController, server, worker, db)
+	ctx, cancelFlush := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFlush()
+	if err := shutdownTracing(ctx); err != nil {
+		log.Error("tracing shutdown", slog.String("error", err.Error()))
 	}
-	router.Run(":8080")
 }
-func setupLogger() *slog.Logger {
+
+// shutdown останавливает приём трафика и дожидается текущих запросов и задач.
+func shutdown(log *slog.Logger, cfg config.HTTPConfig, health *controller.HealthController, server *http.Server, w *worker.Worker, db *gorm.DB) {
+	health.SetDraining()
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if cfg.DrainDelay > 0 {
+		log.Info("draining", slog.Duration("delay", cfg.DrainDelay))
+		time.Sleep(cfg.DrainDelay)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		if err := server.Shutdown(ctx); err != nil {
+			log.Error("http server shutdown", slog.String("error", err.Error()))
+			server.Close()
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		w.Shutdown()
+	}()
+	wg.Wait()
+
+	if err := task.Close(); err != nil {
+		log.Error("task client close", slog.String("error", err.Error()))
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		if err := sqlDB.Close(); err != nil {
+			log.Error("database close", slog.String("error", err.Error()))
+		}
+	}
+	log.Info("application stopped")
+}
+func runMigrate(migrator *migrations.Migrator, log *slog.Logger, args []string) {
+	ctx := context.Background()
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+	switch command {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			log.Info("migration applied", slog.Int64("version", m.Version), slog.String("name", m.Name))
+		}
+		if err != nil {
+			panic(err)
+		}
+		log.Info("schema is up to date", slog.Int("applied", len(applied)))
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				panic("migrate down: steps must be a positive number")
+			}
+			steps = n
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		for _, m := range reverted {
+			log.Info("migration reverted", slog.Int64("version", m.Version), slog.String("name", m.Name))
+		}
+		if err != nil {
+			panic(err)
+		}
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			panic(err)
+		}
+		for _, s := range statuses {
+			log.Info("migration", slog.Int64("version", s.Version), slog.String("name", s.Name), slog.Bool("applied", s.AppliedAt != nil))
+		}
+	default:
+		panic("unknown migrate command: " + command)
+	}
+}
+
+// newNotifier выбирает способ доставки писем.
+func newNotifier(cfg config.NotifyConfig) password.Notifier {
+	if cfg.Backend == "smtp" {
+		return notify.NewSMTP(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUser, string(cfg.SMTPPassword), cfg.From)
+	}
+	return notify.NewLog(cfg.LogFile)
+}
+
+// newRateLimit возвращает конструктор middleware лимитов. При выключенных лимитах
+// middleware пропускает все запросы.
+func newRateLimit(cfg config.RateLimitConfig, redisAddr string) (byUser func(rules ...ratelimit.Rule) gin.HandlerFunc, byIP func(rules ...ratelimit.Rule) gin.HandlerFunc) {
+	if !cfg.Enabled {
+		pass := func(...ratelimit.Rule) gin.HandlerFunc {
+			return func(c *gin.Context) { c.Next() }
+		}
+		return pass, pass
+	}
+	var store ratelimit.Store = ratelimit.NewMemoryStore()
+	if cfg.Backend == "redis" {
+		store = ratelimit.NewRedisStore(redis.NewClient(&redis.Options{Addr: redisAddr}))
+	}
+	limiter := ratelimit.New(store, "ratelimit")
+	multipliers := map[string]int{
+		domain.RoleTeacher: cfg.StaffMultiplier,
+		domain.RoleAdmin:   cfg.StaffMultiplier,
+	}
+	byUser = func(rules ...ratelimit.Rule) gin.HandlerFunc {
+		return middleware.RateLimit(limiter, multipliers, rules...)
+	}
+	byIP = func(rules ...ratelimit.Rule) gin.HandlerFunc {
+		return middleware.RateLimitByIP(limiter, rules...)
+	}
+	return byUser, byIP
+}
+
+// runServiceKey создаёт ключ сервиса из командной строки, например для первого запуска LLM-сервиса.
+func runServiceKey(keyINT *servicekey.ServiceKeyInteractor, args []string) {
+	if len(args) < 3 || args[0] != "create" {
+		panic("usage: service-key create <service> <scope>...")
+	}
+	key, secret, err := keyINT.CreateServiceKey(context.Background(), uuid.Nil, args[1], args[2:])
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("key_id=%s\nsecret=%s\n", key.ID, secret)
+}
+
+func mustLoadSecretBox(cfg config.ServiceAuthConfig) *lib.SecretBox {
+	old := make([]string, 0, len(cfg.OldSecretKeys))
+	for _, key := range cfg.OldSecretKeys {
+		old = append(old, string(key))
+	}
+	secrets, err := lib.NewSecretBox(string(cfg.SecretKey), old...)
+	if err != nil {
+		panic("failed to load service key secret keys: " + err.Error())
+	}
+	return secrets
+}
+
+func mustLoadKeys(cfg *config.Config, log *slog.Logger) *lib.KeySet {
+	if cfg.JWT.KeysDir == "" {
+		if cfg.Env != "local" {
+			panic("jwt keys_dir is required outside of local env")
+		}
+		log.Warn("jwt keys_dir is not set, using ephemeral signing key")
+		keys, err := lib.NewEphemeralKeySet()
+		if err != nil {
+			panic(err)
+		}
+		return keys
+	}
+	keys, err := lib.LoadKeySet(cfg.JWT.KeysDir, cfg.JWT.ActiveKID)
+	if err != nil {
+		panic("failed to load jwt keys: " + err.Error())
+	}
+	return keys
+}
+
+// setupLogger: локально читаемый текст с debug, в остальных окружениях JSON для сборщика логов.
+func setupLogger(env string) *slog.Logger {
 	var log *slog.Logger
 
-	log = slog.New(
-		slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}),
-	)
+	switch env {
+	case "local":
+		log = slog.New(
+			slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+		)
+	default:
+		log = slog.New(
+			slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}),
+		)
+	}
 	return log
 }
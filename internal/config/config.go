@@ -1,16 +1,247 @@
 package config
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 )
 
 type Config struct {
-	Env      string        `yaml:"env" env-default:"local"`
-	TokenTTL time.Duration `yaml:"token_ttl" env-default:"1h"`
+	Env             string        `yaml:"env" env-default:"local"`
+	TokenTTL        time.Duration `yaml:"token_ttl" env-default:"1h"`
+	RefreshTokenTTL time.Duration `yaml:"refresh_token_ttl" env-default:"720h"`
+	// TokenPurgeInterval - как часто удаляются истёкшие записи об отозванных токенах.
+	TokenPurgeInterval time.Duration       `yaml:"token_purge_interval" env:"TOKEN_PURGE_INTERVAL" env-default:"1h"`
+	JWT                JWTConfig           `yaml:"jwt"`
+	HTTP               HTTPConfig          `yaml:"http"`
+	Worker             WorkerConfig        `yaml:"worker"`
+	DB                 DBConfig            `yaml:"db"`
+	Services           ServicesConfig      `yaml:"services"`
+	CORS               CORSConfig          `yaml:"cors"`
+	Cookie             CookieConfig        `yaml:"cookie"`
+	Tracing            TracingConfig       `yaml:"tracing"`
+	Catalog            CatalogConfig       `yaml:"catalog"`
+	ServiceAuth        ServiceAuthConfig   `yaml:"service_auth"`
+	RateLimit          RateLimitConfig     `yaml:"rate_limit"`
+	Login              LoginConfig         `yaml:"login"`
+	Notify             NotifyConfig        `yaml:"notify"`
+	PasswordReset      PasswordResetConfig `yaml:"password_reset"`
+}
+
+// JWTConfig описывает ключи подписи токенов. В KeysDir лежат файлы <kid>.pem,
+// ActiveKID выбирает ключ, которым подписываются новые токены.
+type JWTConfig struct {
+	KeysDir   string `yaml:"keys_dir" env:"JWT_KEYS_DIR"`
+	ActiveKID string `yaml:"active_kid" env:"JWT_ACTIVE_KID"`
+}
+
+// HTTPConfig - параметры HTTP сервера. При остановке readiness в течение DrainDelay
+// отвечает "draining", затем сервер дожидается текущих запросов не дольше ShutdownTimeout.
+type HTTPConfig struct {
+	Address         string        `yaml:"address" env:"HTTP_ADDRESS" env-default:":8080"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" env-default:"30s"`
+	DrainDelay      time.Duration `yaml:"drain_delay" env:"DRAIN_DELAY" env-default:"0s"`
+	// HealthTimeout ограничивает проверку каждой зависимости в /readyz.
+	HealthTimeout time.Duration `yaml:"health_timeout" env:"HEALTH_TIMEOUT" env-default:"2s"`
+	// TrustedProxies - адреса или подсети прокси, которым верим X-Forwarded-For.
+	// По умолчанию пусто: IP клиента берётся из соединения и не подделывается заголовком.
+	TrustedProxies []string `yaml:"trusted_proxies" env:"TRUSTED_PROXIES" env-separator:","`
+}
+
+type WorkerConfig struct {
+	Concurrency int `yaml:"concurrency" env:"WORKER_CONCURRENCY" env-default:"10"`
+}
+
+// DBConfig - подключение к Postgres и настройки пула. По умолчанию - локальная
+// база, адрес прода задаётся только явно, чтобы запуск без переменных не попал в него.
+type DBConfig struct {
+	Host     string `yaml:"host" env:"DB_HOST" env-default:"localhost"`
+	Port     int    `yaml:"port" env:"DB_PORT" env-default:"5432"`
+	User     string `yaml:"user" env:"DB_USER" env-default:"postgres"`
+	Password Secret `yaml:"password" env:"DB_PASS"`
+	Name     string `yaml:"name" env:"DB_NAME" env-default:"postgres"`
+	SSLMode  string `yaml:"ssl_mode" env:"DB_SSL_MODE" env-default:"prefer"`
+	// QueryExecMode - режим кэширования выражений pgx. За пулером в режиме
+	// transaction (прод на Supabase) подготовленные выражения не работают,
+	// поэтому по умолчанию simple_protocol.
+	QueryExecMode   string        `yaml:"query_exec_mode" env:"DB_QUERY_EXEC_MODE" env-default:"simple_protocol"`
+	MaxOpenConns    int           `yaml:"max_open_conns" env:"DB_MAX_OPEN_CONNS" env-default:"10"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" env:"DB_MAX_IDLE_CONNS" env-default:"5"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME" env-default:"30m"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" env:"DB_CONN_MAX_IDLE_TIME" env-default:"5m"`
+	// SlowQueryThreshold - запросы дольше этого пишутся в лог как медленные.
+	SlowQueryThreshold time.Duration `yaml:"slow_query_threshold" env:"DB_SLOW_QUERY_THRESHOLD" env-default:"200ms"`
+}
+
+// ServicesConfig - адреса внешних сервисов. HTTP адреса приводятся к виду с завершающим "/".
+type ServicesConfig struct {
+	LLMURL    string `yaml:"llm_url" env:"LLM_URL"`
+	ParserURL string `yaml:"parser_url" env:"PARSER_URL"`
+	RedisAddr string `yaml:"redis_addr" env:"REDIS_URL" env-default:"localhost:6379"`
+}
+
+type CORSConfig struct {
+	AllowOrigins []string `yaml:"allow_origins" env:"CORS_ALLOW_ORIGINS" env-separator:"," env-default:"http://localhost:3000"`
+}
+
+// CookieConfig - атрибуты кук авторизации. SameSite: lax, strict или none,
+// none браузеры принимают только вместе с Secure. Domain пуст - кука только
+// для текущего хоста.
+type CookieConfig struct {
+	Domain   string `yaml:"domain" env:"COOKIE_DOMAIN"`
+	Secure   bool   `yaml:"secure" env:"COOKIE_SECURE" env-default:"false"`
+	HTTPOnly bool   `yaml:"http_only" env:"COOKIE_HTTP_ONLY" env-default:"true"`
+	SameSite string `yaml:"same_site" env:"COOKIE_SAME_SITE" env-default:"lax"`
+}
+
+var sameSiteModes = map[string]http.SameSite{
+	"lax":    http.SameSiteLaxMode,
+	"strict": http.SameSiteStrictMode,
+	"none":   http.SameSiteNoneMode,
+}
+
+// SameSiteMode возвращает значение SameSite для net/http.
+func (c CookieConfig) SameSiteMode() http.SameSite {
+	return sameSiteModes[c.SameSite]
+}
+
+// TracingConfig - экспорт спанов OpenTelemetry по OTLP/HTTP. Endpoint указывается как host:port.
+type TracingConfig struct {
+	Enabled     bool    `yaml:"enabled" env:"TRACING_ENABLED" env-default:"false"`
+	Endpoint    string  `yaml:"endpoint" env:"TRACING_ENDPOINT" env-default:"localhost:4318"`
+	Insecure    bool    `yaml:"insecure" env:"TRACING_INSECURE" env-default:"true"`
+	ServiceName string  `yaml:"service_name" env:"TRACING_SERVICE_NAME" env-default:"plandstu-go"`
+	SampleRatio float64 `yaml:"sample_ratio" env:"TRACING_SAMPLE_RATIO" env-default:"1"`
+}
+
+// ServiceAuthConfig - проверка подписанных запросов внутренних сервисов.
+// MaxSkew - допустимое расхождение часов, столько же хранятся использованные nonce.
+//
+// SecretKey (32 случайных байта в base64, например openssl rand -base64 32)
+// шифрует секреты ключей сервисов в базе. OldSecretKeys только расшифровывают.
+// Ротация: новый ключ сначала добавляется в old_secret_keys всех экземпляров,
+// затем становится secret_key, а прежний переносится в old_secret_keys. При
+// старте сервер перешифровывает секреты активным ключом, после перезапуска
+// всех экземпляров прежний ключ можно убрать.
+type ServiceAuthConfig struct {
+	MaxSkew       time.Duration `yaml:"max_skew" env:"SERVICE_AUTH_MAX_SKEW" env-default:"5m"`
+	PurgeInterval time.Duration `yaml:"purge_interval" env:"SERVICE_AUTH_PURGE_INTERVAL" env-default:"10m"`
+	SecretKey     Secret        `yaml:"secret_key" env:"SERVICE_AUTH_SECRET_KEY"`
+	OldSecretKeys []Secret      `yaml:"old_secret_keys" env:"SERVICE_AUTH_OLD_SECRET_KEYS" env-separator:","`
+}
+
+// RateLimitConfig - лимиты на дорогие запросы к LLM. Лимиты в минуту сглаживают
+// всплески, суточные квоты сбрасываются в полночь UTC. Преподавателям и
+// администраторам лимиты умножаются на StaffMultiplier. Вход и сброс пароля
+// ограничиваются по IP: LoginPerMinute и PasswordPerHour на каждый эндпоинт.
+type RateLimitConfig struct {
+	Enabled         bool   `yaml:"enabled" env:"RATE_LIMIT_ENABLED" env-default:"true"`
+	Backend         string `yaml:"backend" env:"RATE_LIMIT_BACKEND" env-default:"redis"`
+	ChatPerMinute   int    `yaml:"chat_per_minute" env:"RATE_LIMIT_CHAT_PER_MINUTE" env-default:"10"`
+	ChatPerDay      int    `yaml:"chat_per_day" env:"RATE_LIMIT_CHAT_PER_DAY" env-default:"200"`
+	TestsPerMinute  int    `yaml:"tests_per_minute" env:"RATE_LIMIT_TESTS_PER_MINUTE" env-default:"3"`
+	TestsPerDay     int    `yaml:"tests_per_day" env:"RATE_LIMIT_TESTS_PER_DAY" env-default:"20"`
+	StaffMultiplier int    `yaml:"staff_multiplier" env:"RATE_LIMIT_STAFF_MULTIPLIER" env-default:"5"`
+	LoginPerMinute  int    `yaml:"login_per_minute" env:"RATE_LIMIT_LOGIN_PER_MINUTE" env-default:"20"`
+	PasswordPerHour int    `yaml:"password_per_hour" env:"RATE_LIMIT_PASSWORD_PER_HOUR" env-default:"10"`
+}
+
+// LoginConfig - защита входа от перебора. После FreeAttempts ошибок подряд
+// (IPFreeAttempts для одного IP) вход откладывается, начиная с BaseDelay и
+// удваивая задержку до MaxDelay. Ошибки старше FailureWindow не учитываются.
+// После LockoutThreshold ошибок по логину учётная запись блокируется на LockoutDuration.
+type LoginConfig struct {
+	FreeAttempts     int           `yaml:"free_attempts" env:"LOGIN_FREE_ATTEMPTS" env-default:"3"`
+	IPFreeAttempts   int           `yaml:"ip_free_attempts" env:"LOGIN_IP_FREE_ATTEMPTS" env-default:"20"`
+	BaseDelay        time.Duration `yaml:"base_delay" env:"LOGIN_BASE_DELAY" env-default:"1s"`
+	MaxDelay         time.Duration `yaml:"max_delay" env:"LOGIN_MAX_DELAY" env-default:"15m"`
+	FailureWindow    time.Duration `yaml:"failure_window" env:"LOGIN_FAILURE_WINDOW" env-default:"1h"`
+	LockoutThreshold int           `yaml:"lockout_threshold" env:"LOGIN_LOCKOUT_THRESHOLD" env-default:"20"`
+	LockoutDuration  time.Duration `yaml:"lockout_duration" env:"LOGIN_LOCKOUT_DURATION" env-default:"30m"`
+}
+
+// NotifyConfig - доставка писем пользователям. Backend log пишет письма
+// в LogFile или в лог приложения и подходит только для разработки.
+type NotifyConfig struct {
+	Backend      string `yaml:"backend" env:"NOTIFY_BACKEND" env-default:"log"`
+	LogFile      string `yaml:"log_file" env:"NOTIFY_LOG_FILE"`
+	SMTPHost     string `yaml:"smtp_host" env:"SMTP_HOST"`
+	SMTPPort     int    `yaml:"smtp_port" env:"SMTP_PORT" env-default:"587"`
+	SMTPUser     string `yaml:"smtp_user" env:"SMTP_USER"`
+	SMTPPassword Secret `yaml:"smtp_password" env:"SMTP_PASSWORD"`
+	From         string `yaml:"from" env:"NOTIFY_FROM"`
+}
+
+// PasswordResetConfig - сброс пароля по письму. URL - страница фронтенда,
+// к ней добавляется параметр token.
+type PasswordResetConfig struct {
+	TokenTTL time.Duration `yaml:"token_ttl" env:"PASSWORD_RESET_TOKEN_TTL" env-default:"1h"`
+	URL      string        `yaml:"url" env:"PASSWORD_RESET_URL" env-default:"http://localhost:3000/reset-password"`
+}
+
+// CatalogConfig - кэш справочников парсера. Записи старше TTL отдаются,
+// но обновляются в фоне; раз в RefreshInterval устаревшие записи обновляются фоновой задачей.
+type CatalogConfig struct {
+	TTL             time.Duration `yaml:"ttl" env:"CATALOG_TTL" env-default:"6h"`
+	RefreshInterval time.Duration `yaml:"refresh_interval" env:"CATALOG_REFRESH_INTERVAL" env-default:"30m"`
+	CacheSize       int           `yaml:"cache_size" env:"CATALOG_CACHE_SIZE" env-default:"512"`
+}
+
+// Secret - строка, которая не попадает в логи при выводе конфига.
+type Secret string
+
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return "***"
+}
+
+// LogValue скрывает значение в slog: JSON-обработчик не вызывает String.
+func (s Secret) LogValue() slog.Value {
+	return slog.StringValue(s.String())
+}
+
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+var (
+	sslModes       = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+	queryExecModes = []string{"cache_statement", "cache_describe", "describe_exec", "exec", "simple_protocol"}
+)
+
+// DSN собирает строку подключения в формате URL.
+func (c DBConfig) DSN() string {
+	query := url.Values{}
+	query.Set("sslmode", c.SSLMode)
+	query.Set("default_query_exec_mode", c.QueryExecMode)
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(c.User, string(c.Password)),
+		Host:     net.JoinHostPort(c.Host, strconv.Itoa(c.Port)),
+		Path:     "/" + c.Name,
+		RawQuery: query.Encode(),
+	}
+	return dsn.String()
+}
+
+// SimpleProtocol сообщает, что запросы нужно выполнять без подготовленных выражений.
+func (c DBConfig) SimpleProtocol() bool {
+	return c.QueryExecMode == "simple_protocol"
 }
 
 func MustLoad() *Config {
@@ -30,10 +261,218 @@ func MustLoadByPath(configPath string) *Config {
 	if err := cleanenv.ReadConfig(configPath, &cfg); err != nil {
 		panic("cannot read the config: " + err.Error())
 	}
+	if err := cfg.Validate(); err != nil {
+		panic("invalid config: " + err.Error())
+	}
 
 	return &cfg
 }
 
+// Validate проверяет конфиг и нормализует адреса сервисов.
+func (c *Config) Validate() error {
+	var errs []error
+	if c.TokenTTL <= 0 {
+		errs = append(errs, errors.New("token_ttl must be positive"))
+	}
+	if c.RefreshTokenTTL <= 0 {
+		errs = append(errs, errors.New("refresh_token_ttl must be positive"))
+	}
+	if c.TokenPurgeInterval <= 0 {
+		errs = append(errs, errors.New("token_purge_interval must be positive"))
+	}
+	if c.HTTP.Address == "" {
+		errs = append(errs, errors.New("http.address is required"))
+	}
+	if c.HTTP.ShutdownTimeout <= 0 {
+		errs = append(errs, errors.New("http.shutdown_timeout must be positive"))
+	}
+	if c.HTTP.DrainDelay < 0 || c.HTTP.DrainDelay >= c.HTTP.ShutdownTimeout {
+		errs = append(errs, errors.New("http.drain_delay must be between 0 and http.shutdown_timeout"))
+	}
+	if c.HTTP.HealthTimeout <= 0 {
+		errs = append(errs, errors.New("http.health_timeout must be positive"))
+	}
+	for i, proxy := range c.HTTP.TrustedProxies {
+		proxy = strings.TrimSpace(proxy)
+		c.HTTP.TrustedProxies[i] = proxy
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			errs = append(errs, fmt.Errorf("http.trusted_proxies: invalid address %q", proxy))
+		}
+	}
+	if c.Worker.Concurrency < 1 {
+		errs = append(errs, errors.New("worker.concurrency must be positive"))
+	}
+	errs = append(errs, c.DB.validate()...)
+
+	var err error
+	if c.Services.LLMURL, err = normalizeBaseURL(c.Services.LLMURL); err != nil {
+		errs = append(errs, fmt.Errorf("services.llm_url: %w", err))
+	}
+	if c.Services.ParserURL, err = normalizeBaseURL(c.Services.ParserURL); err != nil {
+		errs = append(errs, fmt.Errorf("services.parser_url: %w", err))
+	}
+	if _, _, err := net.SplitHostPort(c.Services.RedisAddr); err != nil {
+		errs = append(errs, fmt.Errorf("services.redis_addr: %w", err))
+	}
+
+	if c.Catalog.TTL <= 0 || c.Catalog.RefreshInterval <= 0 {
+		errs = append(errs, errors.New("catalog.ttl and catalog.refresh_interval must be positive"))
+	}
+	if c.Catalog.CacheSize < 1 {
+		errs = append(errs, errors.New("catalog.cache_size must be positive"))
+	}
+
+	if c.ServiceAuth.MaxSkew <= 0 || c.ServiceAuth.PurgeInterval <= 0 {
+		errs = append(errs, errors.New("service_auth.max_skew and service_auth.purge_interval must be positive"))
+	}
+	if !validSecretKey(c.ServiceAuth.SecretKey) {
+		errs = append(errs, errors.New("service_auth.secret_key must be 32 bytes in base64"))
+	}
+	for i, key := range c.ServiceAuth.OldSecretKeys {
+		if !validSecretKey(key) {
+			errs = append(errs, fmt.Errorf("service_auth.old_secret_keys[%d] must be 32 bytes in base64", i))
+		}
+	}
+
+	if c.RateLimit.Enabled {
+		if c.RateLimit.Backend != "redis" && c.RateLimit.Backend != "memory" {
+			errs = append(errs, fmt.Errorf("rate_limit.backend must be redis or memory, got %q", c.RateLimit.Backend))
+		}
+		if c.RateLimit.ChatPerMinute < 1 || c.RateLimit.ChatPerDay < 1 || c.RateLimit.TestsPerMinute < 1 || c.RateLimit.TestsPerDay < 1 ||
+			c.RateLimit.LoginPerMinute < 1 || c.RateLimit.PasswordPerHour < 1 {
+			errs = append(errs, errors.New("rate_limit limits must be positive"))
+		}
+		if c.RateLimit.StaffMultiplier < 1 {
+			errs = append(errs, errors.New("rate_limit.staff_multiplier must be at least 1"))
+		}
+	}
+
+	if c.Login.FreeAttempts < 1 || c.Login.IPFreeAttempts < 1 {
+		errs = append(errs, errors.New("login.free_attempts and login.ip_free_attempts must be positive"))
+	}
+	if c.Login.BaseDelay <= 0 || c.Login.MaxDelay < c.Login.BaseDelay {
+		errs = append(errs, errors.New("login.base_delay must be positive and not exceed login.max_delay"))
+	}
+	if c.Login.FailureWindow <= 0 || c.Login.LockoutDuration <= 0 {
+		errs = append(errs, errors.New("login.failure_window and login.lockout_duration must be positive"))
+	}
+	if c.Login.LockoutThreshold <= c.Login.FreeAttempts {
+		errs = append(errs, errors.New("login.lockout_threshold must exceed login.free_attempts"))
+	}
+
+	switch c.Notify.Backend {
+	case "log":
+	case "smtp":
+		if c.Notify.SMTPHost == "" || c.Notify.From == "" {
+			errs = append(errs, errors.New("notify.smtp_host and notify.from are required for smtp backend"))
+		}
+		if c.Notify.SMTPPort < 1 || c.Notify.SMTPPort > 65535 {
+			errs = append(errs, fmt.Errorf("notify.smtp_port %d is out of range", c.Notify.SMTPPort))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("notify.backend must be smtp or log, got %q", c.Notify.Backend))
+	}
+	if c.PasswordReset.TokenTTL <= 0 {
+		errs = append(errs, errors.New("password_reset.token_ttl must be positive"))
+	}
+	if u, err := url.Parse(c.PasswordReset.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs = append(errs, fmt.Errorf("password_reset.url: invalid url %q", c.PasswordReset.URL))
+	}
+
+	if c.Tracing.Enabled {
+		if c.Tracing.Endpoint == "" {
+			errs = append(errs, errors.New("tracing.endpoint is required when tracing is enabled"))
+		}
+		if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+			errs = append(errs, errors.New("tracing.sample_ratio must be between 0 and 1"))
+		}
+	}
+
+	c.Cookie.SameSite = strings.ToLower(c.Cookie.SameSite)
+	if _, ok := sameSiteModes[c.Cookie.SameSite]; !ok {
+		errs = append(errs, fmt.Errorf("cookie.same_site must be lax, strict or none, got %q", c.Cookie.SameSite))
+	}
+	if c.Cookie.SameSite == "none" && !c.Cookie.Secure {
+		errs = append(errs, errors.New("cookie.same_site none requires cookie.secure"))
+	}
+	// В проде токены не должны уходить по HTTP
+	if c.Env == "prod" && !c.Cookie.Secure {
+		errs = append(errs, errors.New("cookie.secure must be enabled in prod"))
+	}
+
+	if len(c.CORS.AllowOrigins) == 0 {
+		errs = append(errs, errors.New("cors.allow_origins must not be empty"))
+	}
+	for i, origin := range c.CORS.AllowOrigins {
+		origin = strings.TrimSpace(origin)
+		c.CORS.AllowOrigins[i] = origin
+		// Куки передаются с credentials, а с ними браузер не принимает "*"
+		if origin == "*" {
+			errs = append(errs, errors.New("cors.allow_origins: wildcard is not allowed with credentials"))
+			continue
+		}
+		u, err := url.Parse(origin)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || (u.Path != "" && u.Path != "/") {
+			errs = append(errs, fmt.Errorf("cors.allow_origins: invalid origin %q", origin))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (c DBConfig) validate() []error {
+	var errs []error
+	if c.Host == "" {
+		errs = append(errs, errors.New("db.host is required"))
+	}
+	if c.Port < 1 || c.Port > 65535 {
+		errs = append(errs, fmt.Errorf("db.port %d is out of range", c.Port))
+	}
+	if c.User == "" {
+		errs = append(errs, errors.New("db.user is required"))
+	}
+	if c.Name == "" {
+		errs = append(errs, errors.New("db.name is required"))
+	}
+	if !slices.Contains(sslModes, c.SSLMode) {
+		errs = append(errs, fmt.Errorf("db.ssl_mode must be one of %s", strings.Join(sslModes, ", ")))
+	}
+	if !slices.Contains(queryExecModes, c.QueryExecMode) {
+		errs = append(errs, fmt.Errorf("db.query_exec_mode must be one of %s", strings.Join(queryExecModes, ", ")))
+	}
+	if c.MaxOpenConns < 0 || c.MaxIdleConns < 0 {
+		errs = append(errs, errors.New("db pool sizes must not be negative"))
+	}
+	if c.MaxOpenConns > 0 && c.MaxIdleConns > c.MaxOpenConns {
+		errs = append(errs, errors.New("db.max_idle_conns must not exceed db.max_open_conns"))
+	}
+	if c.ConnMaxLifetime < 0 || c.ConnMaxIdleTime < 0 {
+		errs = append(errs, errors.New("db connection lifetimes must not be negative"))
+	}
+	return errs
+}
+
+func validSecretKey(key Secret) bool {
+	raw, err := base64.StdEncoding.DecodeString(string(key))
+	return err == nil && len(raw) == 32
+}
+
+func normalizeBaseURL(raw string) (string, error) {
+	if raw == "" {
+		return "", errors.New("is required")
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("%q must be an absolute http(s) URL", raw)
+	}
+	if !strings.HasSuffix(raw, "/") {
+		raw += "/"
+	}
+	return raw, nil
+}
+
 func fetchConfigPath() string {
 	var res string
 
@@ -0,0 +1,143 @@
+package config
+
+import (
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+// validConfig - конфиг, который проходит Validate, со значениями по умолчанию.
+func validConfig() Config {
+	return Config{
+		Env:                "local",
+		TokenTTL:           time.Hour,
+		RefreshTokenTTL:    720 * time.Hour,
+		TokenPurgeInterval: time.Hour,
+		HTTP: HTTPConfig{
+			Address:         ":8080",
+			ShutdownTimeout: 30 * time.Second,
+			HealthTimeout:   2 * time.Second,
+		},
+		Worker: WorkerConfig{Concurrency: 10},
+		DB: DBConfig{
+			Host:          "localhost",
+			Port:          5432,
+			User:          "postgres",
+			Name:          "postgres",
+			SSLMode:       "prefer",
+			QueryExecMode: "simple_protocol",
+			MaxOpenConns:  10,
+			MaxIdleConns:  5,
+		},
+		Services: ServicesConfig{
+			LLMURL:    "http://llm:8085",
+			ParserURL: "http://parser:8082/",
+			RedisAddr: "localhost:6379",
+		},
+		CORS:    CORSConfig{AllowOrigins: []string{"http://localhost:3000"}},
+		Cookie:  CookieConfig{HTTPOnly: true, SameSite: "lax"},
+		Catalog: CatalogConfig{TTL: 6 * time.Hour, RefreshInterval: 30 * time.Minute, CacheSize: 512},
+		ServiceAuth: ServiceAuthConfig{
+			MaxSkew:       5 * time.Minute,
+			PurgeInterval: 10 * time.Minute,
+			SecretKey:     Secret(base64.StdEncoding.EncodeToString(make([]byte, 32))),
+		},
+		RateLimit: RateLimitConfig{
+			Enabled: true, Backend: "redis",
+			ChatPerMinute: 10, ChatPerDay: 200, TestsPerMinute: 3, TestsPerDay: 20,
+			StaffMultiplier: 5, LoginPerMinute: 20, PasswordPerHour: 10,
+		},
+		Login: LoginConfig{
+			FreeAttempts: 3, IPFreeAttempts: 20,
+			BaseDelay: time.Second, MaxDelay: 15 * time.Minute,
+			FailureWindow: time.Hour, LockoutThreshold: 20, LockoutDuration: 30 * time.Minute,
+		},
+		Notify:        NotifyConfig{Backend: "log", SMTPPort: 587},
+		PasswordReset: PasswordResetConfig{TokenTTL: time.Hour, URL: "http://localhost:3000/reset-password"},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(c *Config)
+		wantErr string // пусто - конфиг валиден
+	}{
+		{name: "defaults", change: func(c *Config) {}},
+		{name: "zero token ttl", change: func(c *Config) { c.TokenTTL = 0 }, wantErr: "token_ttl must be positive"},
+		{name: "drain delay not shorter than shutdown", change: func(c *Config) { c.HTTP.DrainDelay = c.HTTP.ShutdownTimeout }, wantErr: "http.drain_delay"},
+		{name: "trusted proxy cidr", change: func(c *Config) { c.HTTP.TrustedProxies = []string{" 10.0.0.0/8", "127.0.0.1"} }},
+		{name: "invalid trusted proxy", change: func(c *Config) { c.HTTP.TrustedProxies = []string{"proxy.local"} }, wantErr: `invalid address "proxy.local"`},
+		{name: "db port out of range", change: func(c *Config) { c.DB.Port = 70000 }, wantErr: "db.port 70000 is out of range"},
+		{name: "unknown ssl mode", change: func(c *Config) { c.DB.SSLMode = "on" }, wantErr: "db.ssl_mode"},
+		{name: "idle conns above open conns", change: func(c *Config) { c.DB.MaxIdleConns = 20 }, wantErr: "db.max_idle_conns"},
+		{name: "missing llm url", change: func(c *Config) { c.Services.LLMURL = "" }, wantErr: "services.llm_url: is required"},
+		{name: "relative parser url", change: func(c *Config) { c.Services.ParserURL = "parser:8082" }, wantErr: "services.parser_url"},
+		{name: "redis without port", change: func(c *Config) { c.Services.RedisAddr = "redis" }, wantErr: "services.redis_addr"},
+		{name: "missing service secret key", change: func(c *Config) { c.ServiceAuth.SecretKey = "" }, wantErr: "service_auth.secret_key"},
+		{name: "short old service secret key", change: func(c *Config) {
+			c.ServiceAuth.OldSecretKeys = []Secret{Secret(base64.StdEncoding.EncodeToString(make([]byte, 16)))}
+		}, wantErr: "service_auth.old_secret_keys[0]"},
+		{name: "unknown rate limit backend", change: func(c *Config) { c.RateLimit.Backend = "memcached" }, wantErr: "rate_limit.backend"},
+		{name: "disabled rate limit is not checked", change: func(c *Config) { c.RateLimit = RateLimitConfig{} }},
+		{name: "lockout below free attempts", change: func(c *Config) { c.Login.LockoutThreshold = 3 }, wantErr: "login.lockout_threshold"},
+		{name: "smtp without host", change: func(c *Config) { c.Notify.Backend = "smtp" }, wantErr: "notify.smtp_host"},
+		{name: "unknown notify backend", change: func(c *Config) { c.Notify.Backend = "sms" }, wantErr: "notify.backend"},
+		{name: "reset url without scheme", change: func(c *Config) { c.PasswordReset.URL = "localhost:3000/reset" }, wantErr: "password_reset.url"},
+		{name: "same site is case insensitive", change: func(c *Config) { c.Cookie.SameSite = "Strict" }},
+		{name: "same site none without secure", change: func(c *Config) { c.Cookie.SameSite = "none" }, wantErr: "cookie.same_site none requires cookie.secure"},
+		{name: "prod without secure cookie", change: func(c *Config) { c.Env = "prod" }, wantErr: "cookie.secure must be enabled in prod"},
+		{name: "wildcard origin", change: func(c *Config) { c.CORS.AllowOrigins = []string{"*"} }, wantErr: "wildcard is not allowed"},
+		{name: "origin with path", change: func(c *Config) { c.CORS.AllowOrigins = []string{"http://localhost:3000/app"} }, wantErr: "invalid origin"},
+		{name: "tracing sample ratio", change: func(c *Config) { c.Tracing = TracingConfig{Enabled: true, Endpoint: "otel:4318", SampleRatio: 2} }, wantErr: "tracing.sample_ratio"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			tt.change(&cfg)
+			err := cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Validate() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateNormalizes(t *testing.T) {
+	cfg := validConfig()
+	cfg.CORS.AllowOrigins = []string{" http://localhost:3000 "}
+	cfg.Cookie.SameSite = "Lax"
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Services.LLMURL != "http://llm:8085/" || cfg.Services.ParserURL != "http://parser:8082/" {
+		t.Errorf("service urls = %q, %q, want a trailing slash", cfg.Services.LLMURL, cfg.Services.ParserURL)
+	}
+	if cfg.CORS.AllowOrigins[0] != "http://localhost:3000" {
+		t.Errorf("origin = %q, want trimmed", cfg.CORS.AllowOrigins[0])
+	}
+	if cfg.Cookie.SameSite != "lax" {
+		t.Errorf("same_site = %q, want lax", cfg.Cookie.SameSite)
+	}
+}
+
+func TestSecretIsMasked(t *testing.T) {
+	var buf strings.Builder
+	log := slog.New(slog.NewJSONHandler(&buf, nil))
+	log.Info("config", slog.Any("db", DBConfig{User: "postgres", Password: "hunter2"}))
+	if strings.Contains(buf.String(), "hunter2") {
+		t.Fatalf("secret leaked to log: %s", buf.String())
+	}
+	if got := fmt.Sprint(Secret("hunter2")); got != "***" {
+		t.Fatalf("Secret printed as %q", got)
+	}
+}
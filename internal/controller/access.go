@@ -0,0 +1,60 @@
+package controller
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/immxrtalbeast/plandstu/internal/domain"
+	"github.com/immxrtalbeast/plandstu/internal/problem"
+)
+
+// accessScope - дисциплины и группы (по ID), к которым у текущего пользователя есть гранты.
+type accessScope struct {
+	all         bool
+	disciplines []string
+	groups      []string
+}
+
+func (s *accessScope) discipline(id int) bool {
+	return s.all || slices.Contains(s.disciplines, strconv.Itoa(id))
+}
+
+func (s *accessScope) group(id uuid.UUID) bool {
+	return s.all || slices.Contains(s.groups, id.String())
+}
+
+func loadAccessScope(ctx *gin.Context, accessINT domain.AccessInteractor) (*accessScope, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	role, _ := ctx.Keys["role"].(string)
+	disciplines, all, err := accessINT.ScopeValues(ctx, userID, role, domain.ScopeDiscipline)
+	if err != nil {
+		return nil, err
+	}
+	if all {
+		return &accessScope{all: true}, nil
+	}
+	groups, _, err := accessINT.ScopeValues(ctx, userID, role, domain.ScopeGroup)
+	if err != nil {
+		return nil, err
+	}
+	return &accessScope{disciplines: disciplines, groups: groups}, nil
+}
+
+// userIDFromContext достаёт ID пользователя, положенный AuthMiddleware.
+func userIDFromContext(ctx *gin.Context) (uuid.UUID, error) {
+	userIDStr, ok := ctx.Keys["userID"].(string)
+	if !ok {
+		return uuid.Nil, fmt.Errorf("userID is missing in context")
+	}
+	return uuid.Parse(userIDStr)
+}
+
+func abortForbidden(ctx *gin.Context, reason string) {
+	problem.Abort(ctx, domain.Forbidden("forbidden", reason))
+}
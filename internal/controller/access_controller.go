@@ -0,0 +1,83 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/immxrtalbeast/plandstu/internal/domain"
+	"github.com/immxrtalbeast/plandstu/internal/problem"
+	"gorm.io/gorm"
+)
+
+var (
+	errGrantExists   = domain.Conflict("grant_exists", "grant already exists")
+	errGrantNotFound = domain.NotFound("grant_not_found", "grant not found")
+)
+
+type AccessController struct {
+	accessINT domain.AccessInteractor
+}
+
+func NewAccessController(accessINT domain.AccessInteractor) *AccessController {
+	return &AccessController{accessINT: accessINT}
+}
+
+func (c *AccessController) Grants(ctx *gin.Context) {
+	userID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		problem.Abort(ctx, problem.InvalidParam("id", err))
+		return
+	}
+	grants, err := c.accessINT.Grants(ctx, userID)
+	if err != nil {
+		problem.Abort(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"grants": grants})
+}
+
+func (c *AccessController) CreateGrant(ctx *gin.Context) {
+	type CreateGrantRequest struct {
+		Scope string `json:"scope" binding:"required"`
+		Value string `json:"value" binding:"required"`
+	}
+	userID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		problem.Abort(ctx, problem.InvalidParam("id", err))
+		return
+	}
+	var request CreateGrantRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		problem.Abort(ctx, problem.InvalidBody(err))
+		return
+	}
+	grant, err := c.accessINT.CreateGrant(ctx, userID, request.Scope, request.Value)
+	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			problem.Abort(ctx, errGrantExists)
+			return
+		}
+		problem.Abort(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, gin.H{"grant": grant})
+}
+
+func (c *AccessController) DeleteGrant(ctx *gin.Context) {
+	grantID, err := uuid.Parse(ctx.Param("grant_id"))
+	if err != nil {
+		problem.Abort(ctx, problem.InvalidParam("grant_id", err))
+		return
+	}
+	if err := c.accessINT.DeleteGrant(ctx, grantID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			problem.Abort(ctx, errGrantNotFound)
+			return
+		}
+		problem.Abort(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{})
+}
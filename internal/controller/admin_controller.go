@@ -0,0 +1,273 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/immxrtalbeast/plandstu/internal/domain"
+	"github.com/immxrtalbeast/plandstu/internal/problem"
+)
+
+type AdminController struct {
+	userINT domain.UserInteractor
+}
+
+func NewAdminController(userINT domain.UserInteractor) *AdminController {
+	return &AdminController{userINT: userINT}
+}
+
+type userResponse struct {
+	ID          uuid.UUID  `json:"id"`
+	Login       string     `json:"login"`
+	Email       *string    `json:"email"`
+	Role        string     `json:"role"`
+	Faculty     string     `json:"faculty"`
+	Direction   string     `json:"direction"`
+	Group       string     `json:"group"`
+	GroupID     *uuid.UUID `json:"group_id"`
+	Disabled    bool       `json:"disabled"`
+	LockedUntil *time.Time `json:"locked_until"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+func newUserResponse(u *domain.User) userResponse {
+	return userResponse{
+		ID:          u.ID,
+		Login:       u.Login,
+		Email:       u.Email,
+		Role:        u.Role,
+		Faculty:     u.Faculty,
+		Direction:   u.Direction,
+		Group:       u.Group,
+		GroupID:     u.GroupID,
+		Disabled:    u.Disabled,
+		LockedUntil: u.LockedUntil,
+		CreatedAt:   u.CreatedAt,
+	}
+}
+
+func (c *AdminController) Users(ctx *gin.Context) {
+	limit, _ := strconv.Atoi(ctx.Query("limit"))
+	offset, _ := strconv.Atoi(ctx.Query("offset"))
+	filter := domain.UserFilter{
+		Query:  ctx.Query("q"),
+		Role:   ctx.Query("role"),
+		Limit:  limit,
+		Offset: offset,
+	}
+	users, total, err := c.userINT.Users(ctx, filter)
+	if err != nil {
+		problem.Abort(ctx, err)
+		return
+	}
+	response := make([]userResponse, 0, len(users))
+	for _, u := range users {
+		response = append(response, newUserResponse(u))
+	}
+	ctx.JSON(http.StatusOK, gin.H{"users": response, "total": total})
+}
+
+func (c *AdminController) User(ctx *gin.Context) {
+	userID, ok := parseUserIDParam(ctx)
+	if !ok {
+		return
+	}
+	u, err := c.userINT.User(ctx, userID)
+	if err != nil {
+		problem.Abort(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"user": newUserResponse(u)})
+}
+
+func (c *AdminController) CreateUser(ctx *gin.Context) {
+	type CreateUserRequest struct {
+		Login   string     `json:"login" binding:"required,min=3,max=50"`
+		Pass    string     `json:"password" binding:"required,min=8,max=50"`
+		Role    string     `json:"role"`
+		GroupID *uuid.UUID `json:"group_id"`
+	}
+	actorID, ok := actorIDFromContext(ctx)
+	if !ok {
+		return
+	}
+	var req CreateUserRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		problem.Abort(ctx, problem.InvalidBody(err))
+		return
+	}
+	if !passwordRegex.MatchString(req.Pass) {
+		problem.Abort(ctx, errPasswordCharacters)
+		return
+	}
+	if req.Role == "" {
+		req.Role = domain.RoleUser
+	}
+	id, err := c.userINT.AdminCreateUser(ctx, actorID, req.Login, req.Pass, req.Role, req.GroupID)
+	if err != nil {
+		problem.Abort(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, gin.H{"id": id})
+}
+
+func (c *AdminController) ChangeRole(ctx *gin.Context) {
+	type ChangeRoleRequest struct {
+		Role string `json:"role" binding:"required"`
+	}
+	actorID, ok := actorIDFromContext(ctx)
+	if !ok {
+		return
+	}
+	userID, ok := parseUserIDParam(ctx)
+	if !ok {
+		return
+	}
+	var req ChangeRoleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		problem.Abort(ctx, problem.InvalidBody(err))
+		return
+	}
+	if err := c.userINT.ChangeRole(ctx, actorID, userID, req.Role); err != nil {
+		problem.Abort(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{})
+}
+
+func (c *AdminController) DisableUser(ctx *gin.Context) {
+	c.setDisabled(ctx, true)
+}
+
+func (c *AdminController) EnableUser(ctx *gin.Context) {
+	c.setDisabled(ctx, false)
+}
+
+func (c *AdminController) setDisabled(ctx *gin.Context, disabled bool) {
+	actorID, ok := actorIDFromContext(ctx)
+	if !ok {
+		return
+	}
+	userID, ok := parseUserIDParam(ctx)
+	if !ok {
+		return
+	}
+	if err := c.userINT.SetDisabled(ctx, actorID, userID, disabled); err != nil {
+		problem.Abort(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{})
+}
+
+// UnlockUser снимает блокировку, выставленную после неудачных входов.
+func (c *AdminController) UnlockUser(ctx *gin.Context) {
+	actorID, ok := actorIDFromContext(ctx)
+	if !ok {
+		return
+	}
+	userID, ok := parseUserIDParam(ctx)
+	if !ok {
+		return
+	}
+	if err := c.userINT.UnlockUser(ctx, actorID, userID); err != nil {
+		problem.Abort(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{})
+}
+
+func (c *AdminController) DeleteUser(ctx *gin.Context) {
+	actorID, ok := actorIDFromContext(ctx)
+	if !ok {
+		return
+	}
+	userID, ok := parseUserIDParam(ctx)
+	if !ok {
+		return
+	}
+	if err := c.userINT.DeleteUser(ctx, actorID, userID); err != nil {
+		problem.Abort(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{})
+}
+
+// ResetPassword задаёт пароль из запроса или генерирует временный, если поле пустое.
+func (c *AdminController) ResetPassword(ctx *gin.Context) {
+	type ResetPasswordRequest struct {
+		Pass string `json:"password" binding:"omitempty,min=8,max=50"`
+	}
+	actorID, ok := actorIDFromContext(ctx)
+	if !ok {
+		return
+	}
+	userID, ok := parseUserIDParam(ctx)
+	if !ok {
+		return
+	}
+	var req ResetPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		problem.Abort(ctx, problem.InvalidBody(err))
+		return
+	}
+	if req.Pass != "" && !passwordRegex.MatchString(req.Pass) {
+		problem.Abort(ctx, errPasswordCharacters)
+		return
+	}
+	tempPass, err := c.userINT.ResetPassword(ctx, actorID, userID, req.Pass)
+	if err != nil {
+		problem.Abort(ctx, err)
+		return
+	}
+	if tempPass != "" {
+		ctx.JSON(http.StatusOK, gin.H{"temporary_password": tempPass})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{})
+}
+
+func (c *AdminController) AuditLogs(ctx *gin.Context) {
+	limit, _ := strconv.Atoi(ctx.Query("limit"))
+	offset, _ := strconv.Atoi(ctx.Query("offset"))
+	filter := domain.AuditFilter{
+		TargetID: ctx.Query("target_id"),
+		Action:   ctx.Query("action"),
+		Limit:    limit,
+		Offset:   offset,
+	}
+	if actor := ctx.Query("actor_id"); actor != "" {
+		actorID, err := uuid.Parse(actor)
+		if err != nil {
+			problem.Abort(ctx, problem.InvalidParam("actor_id", err))
+			return
+		}
+		filter.ActorID = actorID
+	}
+	logs, total, err := c.userINT.AuditLogs(ctx, filter)
+	if err != nil {
+		problem.Abort(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"audit": logs, "total": total})
+}
+
+func parseUserIDParam(ctx *gin.Context) (uuid.UUID, bool) {
+	userID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		problem.Abort(ctx, problem.InvalidParam("id", err))
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+func actorIDFromContext(ctx *gin.Context) (uuid.UUID, bool) {
+	actorID, err := userIDFromContext(ctx)
+	if err != nil {
+		problem.Abort(ctx, err)
+		return uuid.Nil, false
+	}
+	return actorID, true
+}
@@ -0,0 +1,46 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/immxrtalbeast/plandstu/internal/domain"
+	"github.com/immxrtalbeast/plandstu/internal/problem"
+)
+
+type DisciplineController struct {
+	disciplineINT domain.DisciplineInteractor
+}
+
+func NewDisciplineController(disciplineINT domain.DisciplineInteractor) *DisciplineController {
+	return &DisciplineController{disciplineINT: disciplineINT}
+}
+
+func (c *DisciplineController) Disciplines(ctx *gin.Context) {
+	filter := domain.DisciplineFilter{
+		FacultyID:   ctx.Query("faculty_id"),
+		DirectionID: ctx.Query("direction_id"),
+		Query:       ctx.Query("q"),
+	}
+	disciplines, err := c.disciplineINT.Disciplines(ctx, filter)
+	if err != nil {
+		problem.Abort(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"disciplines": disciplines})
+}
+
+func (c *DisciplineController) Discipline(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		problem.Abort(ctx, problem.InvalidParam("id", err))
+		return
+	}
+	discipline, err := c.disciplineINT.Discipline(ctx, id)
+	if err != nil {
+		problem.Abort(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"discipline": discipline})
+}
@@ -1,159 +1,62 @@
 package controller
 
 import (
-	"io"
+	"context"
+	"encoding/json"
 	"net/http"
-	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/immxrtalbeast/plandstu/internal/domain"
+	"github.com/immxrtalbeast/plandstu/internal/parser"
+	"github.com/immxrtalbeast/plandstu/internal/problem"
 )
 
+// Catalog - справочники парсера с кэшем.
+type Catalog interface {
+	Resource(ctx context.Context, r parser.Resource) (json.RawMessage, error)
+	Resync(ctx context.Context) (*domain.CatalogSyncResult, error)
+}
+
 type ParserController struct {
-	parserURL string
+	catalog Catalog
 }
 
-func NewParserController(parserURL string) *ParserController {
-	return &ParserController{parserURL: parserURL}
+func NewParserController(catalog Catalog) *ParserController {
+	return &ParserController{catalog: catalog}
 }
 
 func (c *ParserController) Faculties(ctx *gin.Context) {
-	client := &http.Client{
-		Timeout: 10 * time.Second,
-	}
-
-	req, err := http.NewRequest("GET", c.parserURL+"api/faculties/", nil)
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Failed to create request", "details": err.Error()})
-		return
-
-	}
-
-	req.Header.Set("User-Agent", "Parser/1.0")
-
-	resp, err := client.Do(req)
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Request failed", "details": err.Error()})
-		return
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Unknown status code"})
-		return
-	}
-
-	data, err := io.ReadAll(resp.Body)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to read response body",
-			"details": err.Error(),
-		})
-		return
-	}
-	ctx.Data(resp.StatusCode, "application/json", data)
+	c.resource(ctx, parser.FacultiesResource())
 }
-func (c *ParserController) FacultyByID(ctx *gin.Context) {
-	id := ctx.Param("id")
-	client := &http.Client{
-		Timeout: 10 * time.Second,
-	}
-
-	req, err := http.NewRequest("GET", c.parserURL+"api/faculties/"+id, nil)
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Failed to create request", "details": err.Error()})
-		return
-
-	}
-	req.Header.Set("User-Agent", "Parser/1.0")
-	resp, err := client.Do(req)
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Request failed", "details": err.Error()})
-		return
-	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Unknown status code"})
-		return
-	}
-	data, err := io.ReadAll(resp.Body)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to read response body",
-			"details": err.Error(),
-		})
-		return
-	}
-	ctx.Data(resp.StatusCode, "application/json", data)
+func (c *ParserController) FacultyByID(ctx *gin.Context) {
+	c.resource(ctx, parser.FacultyResource(ctx.Param("id")))
 }
 
 func (c *ParserController) Disciplines(ctx *gin.Context) {
-	direction := ctx.Param("direction")
-	client := &http.Client{
-		Timeout: 20 * time.Second,
-	}
-
-	req, err := http.NewRequest("GET", c.parserURL+"api/get_disciplines/"+direction, nil)
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Failed to create request", "details": err.Error()})
-		return
-
-	}
-	req.Header.Set("User-Agent", "Parser/1.0")
-	resp, err := client.Do(req)
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Request failed", "details": err.Error()})
-		return
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Unknown status code"})
-		return
-	}
-	data, err := io.ReadAll(resp.Body)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to read response body",
-			"details": err.Error(),
-		})
-		return
-	}
-	ctx.Data(resp.StatusCode, "application/json", data)
+	c.resource(ctx, parser.DisciplinesResource(ctx.Param("direction")))
 }
 
 func (c *ParserController) Roadmap(ctx *gin.Context) {
-	discipline := ctx.Param("discipline")
-	link := ctx.Param("link")
-	client := &http.Client{
-		Timeout: 40 * time.Second,
-	}
-
-	req, err := http.NewRequest("GET", c.parserURL+"api/roadmaps/"+discipline+"/"+link, nil)
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Failed to create request", "details": err.Error()})
-		return
+	c.resource(ctx, parser.RoadmapResource(ctx.Param("discipline"), ctx.Param("link")))
+}
 
-	}
-	req.Header.Set("User-Agent", "Parser/1.0")
-	resp, err := client.Do(req)
+// Resync принудительно обновляет кэш справочников.
+func (c *ParserController) Resync(ctx *gin.Context) {
+	result, err := c.catalog.Resync(ctx)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Request failed", "details": err.Error()})
+		problem.Abort(ctx, err)
 		return
 	}
-	defer resp.Body.Close()
+	ctx.JSON(http.StatusOK, result)
+}
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Unknown status code"})
-		return
-	}
-	data, err := io.ReadAll(resp.Body)
+// resource отдаёт ответ парсера в исходном формате.
+func (c *ParserController) resource(ctx *gin.Context, r parser.Resource) {
+	data, err := c.catalog.Resource(ctx, r)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to read response body",
-			"details": err.Error(),
-		})
+		problem.Abort(ctx, err)
 		return
 	}
-	ctx.Data(resp.StatusCode, "application/json", data)
+	ctx.Data(http.StatusOK, "application/json", data)
 }
@@ -0,0 +1,121 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/immxrtalbeast/plandstu/internal/domain"
+	"github.com/immxrtalbeast/plandstu/internal/problem"
+)
+
+type GroupController struct {
+	groupINT domain.GroupInteractor
+}
+
+func NewGroupController(groupINT domain.GroupInteractor) *GroupController {
+	return &GroupController{groupINT: groupINT}
+}
+
+type groupRequest struct {
+	Name      string     `json:"name" binding:"required,max=50"`
+	Faculty   string     `json:"faculty"`
+	Direction string     `json:"direction"`
+	Year      int        `json:"year"`
+	CuratorID *uuid.UUID `json:"curator_id"`
+}
+
+func (r groupRequest) toDomain() domain.Group {
+	return domain.Group{
+		Name:      r.Name,
+		Faculty:   r.Faculty,
+		Direction: r.Direction,
+		Year:      r.Year,
+		CuratorID: r.CuratorID,
+	}
+}
+
+// Groups отдаёт справочник групп, в том числе для выбора группы при регистрации.
+func (c *GroupController) Groups(ctx *gin.Context) {
+	year, _ := strconv.Atoi(ctx.Query("year"))
+	filter := domain.GroupFilter{
+		Faculty:   ctx.Query("faculty"),
+		Direction: ctx.Query("direction"),
+		Year:      year,
+	}
+	groups, err := c.groupINT.Groups(ctx, filter)
+	if err != nil {
+		problem.Abort(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"groups": groups})
+}
+
+func (c *GroupController) Group(ctx *gin.Context) {
+	groupID, ok := parseGroupIDParam(ctx)
+	if !ok {
+		return
+	}
+	g, err := c.groupINT.Group(ctx, groupID)
+	if err != nil {
+		problem.Abort(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"group": g})
+}
+
+func (c *GroupController) CreateGroup(ctx *gin.Context) {
+	var req groupRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		problem.Abort(ctx, problem.InvalidBody(err))
+		return
+	}
+	g, err := c.groupINT.CreateGroup(ctx, req.toDomain())
+	if err != nil {
+		problem.Abort(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, gin.H{"group": g})
+}
+
+func (c *GroupController) UpdateGroup(ctx *gin.Context) {
+	groupID, ok := parseGroupIDParam(ctx)
+	if !ok {
+		return
+	}
+	var req groupRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		problem.Abort(ctx, problem.InvalidBody(err))
+		return
+	}
+	update := req.toDomain()
+	update.ID = groupID
+	g, err := c.groupINT.UpdateGroup(ctx, update)
+	if err != nil {
+		problem.Abort(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"group": g})
+}
+
+func (c *GroupController) DeleteGroup(ctx *gin.Context) {
+	groupID, ok := parseGroupIDParam(ctx)
+	if !ok {
+		return
+	}
+	if err := c.groupINT.DeleteGroup(ctx, groupID); err != nil {
+		problem.Abort(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{})
+}
+
+func parseGroupIDParam(ctx *gin.Context) (uuid.UUID, bool) {
+	groupID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		problem.Abort(ctx, problem.InvalidParam("id", err))
+		return uuid.Nil, false
+	}
+	return groupID, true
+}
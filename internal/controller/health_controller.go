@@ -0,0 +1,56 @@
+package controller
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+	"github.com/immxrtalbeast/plandstu/internal/health"
+)
+
+type HealthController struct {
+	checker  *health.Checker
+	draining atomic.Bool
+}
+
+func NewHealthController(checker *health.Checker) *HealthController {
+	return &HealthController{checker: checker}
+}
+
+// SetDraining переводит readiness в состояние остановки, чтобы балансировщик
+// перестал присылать новые запросы.
+func (c *HealthController) SetDraining() {
+	c.draining.Store(true)
+}
+
+// Live отвечает, что процесс жив. Зависимости не проверяются,
+// чтобы падение базы не приводило к перезапуску контейнера.
+func (c *HealthController) Live(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+func (c *HealthController) Ready(ctx *gin.Context) {
+	if c.draining.Load() {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"status": "draining"})
+		return
+	}
+	statuses := c.checker.Check(ctx)
+	if !health.Healthy(statuses) {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"status": "unavailable", "dependencies": statuses})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"status": "ready", "dependencies": statuses})
+}
+
+// Status - страница для админов: задержка и последняя ошибка каждой зависимости.
+func (c *HealthController) Status(ctx *gin.Context) {
+	status := "ok"
+	statuses := c.checker.Check(ctx)
+	switch {
+	case c.draining.Load():
+		status = "draining"
+	case !health.Healthy(statuses):
+		status = "degraded"
+	}
+	ctx.JSON(http.StatusOK, gin.H{"status": status, "dependencies": statuses})
+}
@@ -0,0 +1,22 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/immxrtalbeast/plandstu/internal/lib"
+)
+
+type JWKSController struct {
+	keys *lib.KeySet
+}
+
+func NewJWKSController(keys *lib.KeySet) *JWKSController {
+	return &JWKSController{keys: keys}
+}
+
+// JWKS отдаёт публичные ключи, по которым другие сервисы проверяют токены пользователей.
+func (c *JWKSController) JWKS(ctx *gin.Context) {
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, c.keys.JWKS())
+}
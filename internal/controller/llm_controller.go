@@ -1,65 +1,71 @@
 package controller
 
 import (
-	"bytes"
-	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
+	"strconv"
 	"time"
+	"unicode/utf8"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/immxrtalbeast/plandstu/internal/domain"
+	"github.com/immxrtalbeast/plandstu/internal/llmclient"
+	"github.com/immxrtalbeast/plandstu/internal/logging"
+	"github.com/immxrtalbeast/plandstu/internal/problem"
 )
 
+// ThreadIDHeader - тред, в который ушло сообщение чата.
+const ThreadIDHeader = "X-Thread-ID"
+
 type LLMController struct {
-	llmURL     string
+	llm        llmclient.Client
 	interactor domain.LLMInteractor
 }
 
-func NewLLMController(llmURL string, LLMINT domain.LLMInteractor) *LLMController {
-	return &LLMController{llmURL: llmURL, interactor: LLMINT}
+func NewLLMController(llm llmclient.Client, LLMINT domain.LLMInteractor) *LLMController {
+	return &LLMController{llm: llm, interactor: LLMINT}
 }
 
+// maxChatMessage - предельная длина сообщения чата в символах.
+const maxChatMessage = 8000
+
+var errChatMessage = errors.New("message is required and must be at most 8000 characters")
+
+// Chat отправляет сообщение в тред и потоком отдаёт ответ модели.
+// Основной вариант - POST с JSON-телом: запрос тратит квоту и пишет историю,
+// поэтому должен проходить проверку CSRF. GET с query оставлен для старого фронтенда.
 func (c *LLMController) Chat(ctx *gin.Context) {
-	type ChatRequest struct {
-		UserID string `json:"user_id"`
-		Prompt string `json:"prompt"`
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		problem.Abort(ctx, err)
+		return
 	}
-	message := ctx.Query("message")
-	userIDStr, ok := ctx.Keys["userID"].(string)
+	message, threadID, ok := chatParams(ctx)
 	if !ok {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error parsing userID"})
 		return
 	}
-
-	fastAPIReq := ChatRequest{
-		UserID: userIDStr,
-		Prompt: message,
-	}
-
-	requestBody, err := json.Marshal(fastAPIReq)
+	thread, err := c.interactor.ChatThread(ctx, userID, threadID)
 	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error creating request"})
+		problem.Abort(ctx, err)
 		return
 	}
-	// Создаем запрос к FastAPI
-	req, err := http.NewRequest("GET", c.llmURL+"chat-stream/", bytes.NewBuffer(requestBody))
+	stream, err := c.llm.ChatStream(ctx.Request.Context(), llmclient.ChatRequest{
+		UserID:       userID,
+		ThreadID:     thread.ID,
+		Prompt:       message,
+		DisciplineID: thread.DisciplineID,
+		RoadmapBlock: thread.RoadmapBlock,
+	})
 	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		problem.Abort(ctx, err)
 		return
 	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
+	defer stream.Close()
 
-	// Выполняем запрос
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusBadGateway, gin.H{"error": err.Error()})
-		return
-	}
-	defer resp.Body.Close()
+	// Без thread_id сообщение уходит в тред по умолчанию, клиенту нужен его ID
+	ctx.Header(ThreadIDHeader, thread.ID.String())
 
 	// Настраиваем заголовки SSE
 	ctx.Header("Content-Type", "text/event-stream")
@@ -69,7 +75,7 @@ func (c *LLMController) Chat(ctx *gin.Context) {
 	// Потоково копируем данные
 	buf := make([]byte, 1024)
 	for {
-		n, err := resp.Body.Read(buf)
+		n, err := stream.Read(buf)
 		if n > 0 {
 			ctx.Writer.Write(buf[:n])
 			ctx.Writer.Flush()
@@ -78,132 +84,259 @@ func (c *LLMController) Chat(ctx *gin.Context) {
 			break
 		}
 		if err != nil {
-			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			// Заголовки уже отправлены, ответить ошибкой нельзя.
+			logging.FromContext(ctx.Request.Context()).Warn("llm stream interrupted", logging.Err(err))
+			ctx.Abort()
 			return
 		}
 	}
 }
 
+// chatParams читает сообщение и тред: из JSON-тела для POST, из query для устаревшего GET.
+func chatParams(ctx *gin.Context) (string, uuid.UUID, bool) {
+	if ctx.Request.Method != http.MethodGet {
+		var req struct {
+			Message  string     `json:"message" binding:"required,max=8000"`
+			ThreadID *uuid.UUID `json:"thread_id"`
+		}
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			problem.Abort(ctx, problem.InvalidBody(err))
+			return "", uuid.Nil, false
+		}
+		if req.ThreadID == nil {
+			return req.Message, uuid.Nil, true
+		}
+		return req.Message, *req.ThreadID, true
+	}
+	message := ctx.Query("message")
+	if message == "" || utf8.RuneCountInString(message) > maxChatMessage {
+		problem.Abort(ctx, problem.InvalidParam("message", errChatMessage))
+		return "", uuid.Nil, false
+	}
+	threadID, ok := uuidQuery(ctx, "thread_id")
+	return message, threadID, ok
+}
+
 func (c *LLMController) SaveHistory(ctx *gin.Context) {
 	var req domain.SaveHistoryRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(400, gin.H{"error": "Invalid request"})
+		problem.Abort(ctx, problem.InvalidBody(err))
 		return
 	}
 
 	userID, err := uuid.Parse(req.UserID)
 	if err != nil {
-		ctx.JSON(400, gin.H{"error": "Error while parsing userID", "details": err.Error()})
+		problem.Abort(ctx, problem.InvalidParam("user_id", err))
 		return
 	}
+	var threadID uuid.UUID
+	if req.ThreadID != "" {
+		threadID, err = uuid.Parse(req.ThreadID)
+		if err != nil {
+			problem.Abort(ctx, problem.InvalidParam("thread_id", err))
+			return
+		}
+	}
 
-	id, err := c.interactor.SaveHistory(ctx, req, userID)
+	saved, err := c.interactor.SaveHistory(ctx, req, userID, threadID)
 	if err != nil {
-		ctx.JSON(400, gin.H{"error": "Error while saving history", "details": err.Error()})
+		problem.Abort(ctx, err)
 		return
 	}
-	ctx.JSON(http.StatusOK, gin.H{
-		"HistoryID": id,
-	})
-
+	ctx.JSON(http.StatusOK, gin.H{"saved": saved})
 }
 
+// History отдаёт историю чата из базы, от новых сообщений к старым.
+// Без thread_id поиск идёт по всем тредам.
 func (c *LLMController) History(ctx *gin.Context) {
-	type HistoryRequest struct {
-		UserID string `json:"user_id"`
-	}
-	client := &http.Client{
-		Timeout: 20 * time.Second,
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		problem.Abort(ctx, err)
+		return
 	}
-	userIDStr, ok := ctx.Keys["userID"].(string)
+	threadID, ok := uuidQuery(ctx, "thread_id")
 	if !ok {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error parsing userID"})
 		return
 	}
-	historyReq := HistoryRequest{
-		UserID: userIDStr,
+	limit, _ := strconv.Atoi(ctx.Query("limit"))
+	offset, _ := strconv.Atoi(ctx.Query("offset"))
+	filter := domain.ChatFilter{
+		ThreadID: threadID,
+		Query:    ctx.Query("q"),
+		Role:     ctx.Query("role"),
+		Limit:    limit,
+		Offset:   offset,
 	}
-	requestBody, err := json.Marshal(historyReq)
+	messages, total, err := c.interactor.ChatHistory(ctx, userID, filter)
 	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error creating request"})
+		problem.Abort(ctx, err)
 		return
 	}
-	req, err := http.NewRequest("GET", c.llmURL+"get_history/", bytes.NewBuffer(requestBody))
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Failed to create request", "details": err.Error()})
-		return
+	ctx.JSON(http.StatusOK, gin.H{"messages": messages, "total": total})
+}
 
-	}
-	req.Header.Set("User-Agent", "Parser/1.0")
-	resp, err := client.Do(req)
+// ExportHistory отдаёт всю историю чата файлом в хронологическом порядке.
+func (c *LLMController) ExportHistory(ctx *gin.Context) {
+	userID, err := userIDFromContext(ctx)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Request failed", "details": err.Error()})
+		problem.Abort(ctx, err)
 		return
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		errorBody, _ := io.ReadAll(resp.Body)
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Unknown status code", "details": string(errorBody)})
+	threadID, ok := uuidQuery(ctx, "thread_id")
+	if !ok {
 		return
 	}
-	data, err := io.ReadAll(resp.Body)
+	messages, err := c.interactor.ExportHistory(ctx, userID, threadID)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to read response body",
-			"details": err.Error(),
-		})
+		problem.Abort(ctx, err)
 		return
 	}
-	ctx.Data(resp.StatusCode, "application/json", data)
+	ctx.Header("Content-Disposition", `attachment; filename="chat-history.json"`)
+	ctx.JSON(http.StatusOK, gin.H{"messages": messages, "exported_at": time.Now().UTC()})
 }
 
 func (c *LLMController) ClearHistory(ctx *gin.Context) {
-	type HistoryRequest struct {
-		UserID string `json:"user_id"`
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		problem.Abort(ctx, err)
+		return
 	}
-	client := &http.Client{
-		Timeout: 20 * time.Second,
+	threadID, ok := uuidQuery(ctx, "thread_id")
+	if !ok {
+		return
 	}
-	userIDStr, ok := ctx.Keys["userID"].(string)
+	if err := c.interactor.ClearHistory(ctx, userID, threadID); err != nil {
+		problem.Abort(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{})
+}
+
+func (c *LLMController) Threads(ctx *gin.Context) {
+	userID, ok := actorIDFromContext(ctx)
 	if !ok {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error parsing userID"})
 		return
 	}
-	historyReq := HistoryRequest{
-		UserID: userIDStr,
+	limit, _ := strconv.Atoi(ctx.Query("limit"))
+	offset, _ := strconv.Atoi(ctx.Query("offset"))
+	filter := domain.ChatThreadFilter{
+		Archived: ctx.Query("archived") == "true",
+		Limit:    limit,
+		Offset:   offset,
 	}
-	requestBody, err := json.Marshal(historyReq)
+	threads, total, err := c.interactor.Threads(ctx, userID, filter)
 	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error creating request"})
+		problem.Abort(ctx, err)
 		return
 	}
-	req, err := http.NewRequest("GET", c.llmURL+"clear_history/", bytes.NewBuffer(requestBody))
+	ctx.JSON(http.StatusOK, gin.H{"threads": threads, "total": total})
+}
+
+func (c *LLMController) Thread(ctx *gin.Context) {
+	userID, ok := actorIDFromContext(ctx)
+	if !ok {
+		return
+	}
+	threadID, ok := parseThreadIDParam(ctx)
+	if !ok {
+		return
+	}
+	thread, err := c.interactor.Thread(ctx, userID, threadID)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Failed to create request", "details": err.Error()})
+		problem.Abort(ctx, err)
 		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"thread": thread})
+}
 
+func (c *LLMController) CreateThread(ctx *gin.Context) {
+	type CreateThreadRequest struct {
+		Title        string `json:"title" binding:"max=200"`
+		DisciplineID *int   `json:"discipline_id"`
+		RoadmapBlock string `json:"roadmap_block" binding:"max=200"`
+	}
+	userID, ok := actorIDFromContext(ctx)
+	if !ok {
+		return
+	}
+	var req CreateThreadRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		problem.Abort(ctx, problem.InvalidBody(err))
+		return
 	}
-	req.Header.Set("User-Agent", "Parser/1.0")
-	resp, err := client.Do(req)
+	thread, err := c.interactor.CreateThread(ctx, userID, req.Title, req.DisciplineID, req.RoadmapBlock)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Request failed", "details": err.Error()})
+		problem.Abort(ctx, err)
 		return
 	}
-	defer resp.Body.Close()
+	ctx.JSON(http.StatusCreated, gin.H{"thread": thread})
+}
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		errorBody, _ := io.ReadAll(resp.Body)
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Unknown status code", "details": string(errorBody)})
+// UpdateThread переименовывает тред и переносит его в архив или обратно.
+func (c *LLMController) UpdateThread(ctx *gin.Context) {
+	type UpdateThreadRequest struct {
+		Title    *string `json:"title" binding:"omitempty,max=200"`
+		Archived *bool   `json:"archived"`
+	}
+	userID, ok := actorIDFromContext(ctx)
+	if !ok {
+		return
+	}
+	threadID, ok := parseThreadIDParam(ctx)
+	if !ok {
+		return
+	}
+	var req UpdateThreadRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		problem.Abort(ctx, problem.InvalidBody(err))
 		return
 	}
-	data, err := io.ReadAll(resp.Body)
+	thread, err := c.interactor.UpdateThread(ctx, userID, threadID, domain.ChatThreadUpdate{
+		Title:    req.Title,
+		Archived: req.Archived,
+	})
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to read response body",
-			"details": err.Error(),
-		})
+		problem.Abort(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"thread": thread})
+}
+
+func (c *LLMController) DeleteThread(ctx *gin.Context) {
+	userID, ok := actorIDFromContext(ctx)
+	if !ok {
+		return
+	}
+	threadID, ok := parseThreadIDParam(ctx)
+	if !ok {
 		return
 	}
-	ctx.Data(resp.StatusCode, "application/json", data)
+	if err := c.interactor.DeleteThread(ctx, userID, threadID); err != nil {
+		problem.Abort(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{})
+}
+
+func parseThreadIDParam(ctx *gin.Context) (uuid.UUID, bool) {
+	threadID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		problem.Abort(ctx, problem.InvalidParam("id", err))
+		return uuid.Nil, false
+	}
+	return threadID, true
+}
+
+// uuidQuery разбирает необязательный UUID из query, пустое значение - uuid.Nil.
+func uuidQuery(ctx *gin.Context, name string) (uuid.UUID, bool) {
+	value := ctx.Query(name)
+	if value == "" {
+		return uuid.Nil, true
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		problem.Abort(ctx, problem.InvalidParam(name, err))
+		return uuid.Nil, false
+	}
+	return id, true
 }
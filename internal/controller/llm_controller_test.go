@@ -0,0 +1,153 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/immxrtalbeast/plandstu/internal/domain"
+	"github.com/immxrtalbeast/plandstu/internal/llmclient"
+)
+
+// stubLLMInteractor отдаёт тред для чата, остальные методы не нужны.
+type stubLLMInteractor struct {
+	domain.LLMInteractor
+	thread *domain.ChatThread
+	err    error
+}
+
+func (s *stubLLMInteractor) ChatThread(ctx context.Context, userID uuid.UUID, threadID uuid.UUID) (*domain.ChatThread, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.thread, nil
+}
+
+func TestLLMControllerChat(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	userID := uuid.New()
+	thread := &domain.ChatThread{ID: uuid.New(), UserID: userID}
+	tests := []struct {
+		name      string
+		method    string
+		target    string // путь с query
+		body      string
+		llmErr    error
+		threadErr error
+		want      int
+		wantBody  string
+		wantCalls int
+	}{
+		{
+			name:      "streams answer",
+			method:    http.MethodPost,
+			body:      `{"message":"hello"}`,
+			want:      http.StatusOK,
+			wantBody:  "data: ok\n\n",
+			wantCalls: 1,
+		},
+		{
+			name:      "explicit thread",
+			method:    http.MethodPost,
+			body:      `{"message":"hello","thread_id":"` + thread.ID.String() + `"}`,
+			want:      http.StatusOK,
+			wantBody:  "data: ok\n\n",
+			wantCalls: 1,
+		},
+		{
+			name:   "empty message",
+			method: http.MethodPost,
+			body:   `{"message":""}`,
+			want:   http.StatusBadRequest,
+		},
+		{
+			name:   "message too long",
+			method: http.MethodPost,
+			body:   `{"message":"` + strings.Repeat("a", 8001) + `"}`,
+			want:   http.StatusBadRequest,
+		},
+		{
+			name:   "invalid thread id",
+			method: http.MethodPost,
+			body:   `{"message":"hello","thread_id":"nope"}`,
+			want:   http.StatusBadRequest,
+		},
+		{
+			name:      "unknown thread",
+			method:    http.MethodPost,
+			body:      `{"message":"hello","thread_id":"` + uuid.NewString() + `"}`,
+			threadErr: domain.NotFound("thread_not_found", "thread not found"),
+			want:      http.StatusNotFound,
+		},
+		{
+			name:      "llm unavailable",
+			method:    http.MethodPost,
+			body:      `{"message":"hello"}`,
+			llmErr:    llmclient.ErrUnavailable,
+			want:      http.StatusBadGateway,
+			wantCalls: 1,
+		},
+		{
+			name:      "deprecated get",
+			method:    http.MethodGet,
+			target:    "?message=hello&thread_id=" + thread.ID.String(),
+			want:      http.StatusOK,
+			wantBody:  "data: ok\n\n",
+			wantCalls: 1,
+		},
+		{
+			name:   "deprecated get without message",
+			method: http.MethodGet,
+			target: "?thread_id=" + thread.ID.String(),
+			want:   http.StatusBadRequest,
+		},
+		{
+			name:   "deprecated get too long",
+			method: http.MethodGet,
+			target: "?message=" + strings.Repeat("a", 8001),
+			want:   http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := llmclient.NewFake()
+			fake.Err = tt.llmErr
+			c := NewLLMController(fake, &stubLLMInteractor{thread: thread, err: tt.threadErr})
+			router := gin.New()
+			router.Handle(tt.method, "/llm/chat", func(ctx *gin.Context) {
+				ctx.Set("userID", userID.String())
+			}, c.Chat)
+
+			req := httptest.NewRequest(tt.method, "/llm/chat"+tt.target, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d, body: %s", rec.Code, tt.want, rec.Body)
+			}
+			if len(fake.Calls) != tt.wantCalls {
+				t.Fatalf("llm calls = %v, want %d", fake.Calls, tt.wantCalls)
+			}
+			if tt.want != http.StatusOK {
+				if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/problem+json") {
+					t.Fatalf("Content-Type = %q, want problem details", ct)
+				}
+				return
+			}
+			if got := rec.Body.String(); got != tt.wantBody {
+				t.Fatalf("body = %q, want %q", got, tt.wantBody)
+			}
+			if got := rec.Header().Get(ThreadIDHeader); got != thread.ID.String() {
+				t.Fatalf("%s = %q, want %q", ThreadIDHeader, got, thread.ID)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != "text/event-stream" {
+				t.Fatalf("Content-Type = %q, want text/event-stream", ct)
+			}
+		})
+	}
+}
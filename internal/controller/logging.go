@@ -0,0 +1,11 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/immxrtalbeast/plandstu/internal/logging"
+)
+
+// withLogAttrs добавляет атрибуты к логгеру запроса, чтобы их видели интеракторы и репозитории.
+func withLogAttrs(ctx *gin.Context, args ...any) {
+	ctx.Request = ctx.Request.WithContext(logging.With(ctx.Request.Context(), args...))
+}
@@ -0,0 +1,60 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/immxrtalbeast/plandstu/internal/domain"
+	"github.com/immxrtalbeast/plandstu/internal/problem"
+)
+
+type ProfileController struct {
+	profileINT domain.ProfileInteractor
+}
+
+func NewProfileController(profileINT domain.ProfileInteractor) *ProfileController {
+	return &ProfileController{profileINT: profileINT}
+}
+
+func (c *ProfileController) Me(ctx *gin.Context) {
+	userID, ok := actorIDFromContext(ctx)
+	if !ok {
+		return
+	}
+	user, err := c.profileINT.Profile(ctx, userID)
+	if err != nil {
+		problem.Abort(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"user": newUserResponse(user)})
+}
+
+func (c *ProfileController) UpdateMe(ctx *gin.Context) {
+	type UpdateProfileRequest struct {
+		Faculty   *string    `json:"faculty"`
+		Direction *string    `json:"direction"`
+		GroupID   *uuid.UUID `json:"group_id"`
+		Email     *string    `json:"email" binding:"omitempty,email,max=254"`
+	}
+	userID, ok := actorIDFromContext(ctx)
+	if !ok {
+		return
+	}
+	var req UpdateProfileRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		problem.Abort(ctx, problem.InvalidBody(err))
+		return
+	}
+	user, err := c.profileINT.UpdateProfile(ctx, userID, domain.ProfileUpdate{
+		Faculty:   req.Faculty,
+		Direction: req.Direction,
+		GroupID:   req.GroupID,
+		Email:     req.Email,
+	})
+	if err != nil {
+		problem.Abort(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"user": newUserResponse(user)})
+}
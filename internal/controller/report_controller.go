@@ -2,12 +2,14 @@ package controller
 
 import (
 	"encoding/json"
+	"log/slog"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/immxrtalbeast/plandstu/internal/domain"
+	"github.com/immxrtalbeast/plandstu/internal/problem"
 	"gorm.io/datatypes"
 )
 
@@ -15,51 +17,46 @@ type ReportController struct {
 	reportINT  domain.ReportInteractor
 	roadmapINT domain.RoadmapInteractor
 	userINT    domain.UserInteractor
+	accessINT  domain.AccessInteractor
 }
 
-func NewReportController(reportINT domain.ReportInteractor, roadmapINT domain.RoadmapInteractor, userINT domain.UserInteractor) *ReportController {
-	return &ReportController{reportINT: reportINT, roadmapINT: roadmapINT, userINT: userINT}
+func NewReportController(reportINT domain.ReportInteractor, roadmapINT domain.RoadmapInteractor, userINT domain.UserInteractor, accessINT domain.AccessInteractor) *ReportController {
+	return &ReportController{reportINT: reportINT, roadmapINT: roadmapINT, userINT: userINT, accessINT: accessINT}
 }
 
 func (c *ReportController) CreateReport(ctx *gin.Context) {
 	disciplineIDStr := ctx.Query("discipline_id")
-	disciplineName := ctx.Query("discipline_name")
-
 	disciplineID, err := strconv.Atoi(disciplineIDStr)
 	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error parsing disciplineID", "detail": err.Error()})
-		return
-	}
-	userIDStr, ok := ctx.Keys["userID"].(string)
-	if !ok {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error parsing userID"})
+		problem.Abort(ctx, problem.InvalidParam("discipline_id", err))
 		return
 	}
-	userID, err := uuid.Parse(userIDStr)
+	withLogAttrs(ctx, slog.Int("discipline_id", disciplineID))
+	userID, err := userIDFromContext(ctx)
 	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error parsing userID to uuid"})
+		problem.Abort(ctx, err)
 		return
 	}
 	user, err := c.userINT.User(ctx, userID)
 	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error getting user"})
+		problem.Abort(ctx, err)
 		return
 	}
 	tests, err := c.roadmapINT.Report(ctx, userID, disciplineID)
 	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error getting tests"})
+		problem.Abort(ctx, err)
 		return
 	}
 	reportData := gin.H{"report": tests}
 	jsonBytes, err := json.Marshal(reportData)
 	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error marshaling report data"})
+		problem.Abort(ctx, err)
 		return
 	}
 
-	err = c.reportINT.CreateReport(ctx, disciplineID, datatypes.JSON(jsonBytes), userID, disciplineName, user.Group)
+	err = c.reportINT.CreateReport(ctx, disciplineID, datatypes.JSON(jsonBytes), userID, user.GroupID, user.Group)
 	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error creating report"})
+		problem.Abort(ctx, err)
 		return
 	}
 	ctx.JSON(http.StatusOK, gin.H{"report": tests})
@@ -69,12 +66,12 @@ func (c *ReportController) Report(ctx *gin.Context) {
 	reportIDStr := ctx.Query("report_id")
 	reportID, err := uuid.Parse(reportIDStr)
 	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error parsing reportID to uuid"})
+		problem.Abort(ctx, problem.InvalidParam("report_id", err))
 		return
 	}
 	report, err := c.reportINT.Report(ctx, reportID)
 	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error getting report"})
+		problem.Abort(ctx, err)
 		return
 	}
 	ctx.JSON(http.StatusOK, gin.H{"report": report})
@@ -84,43 +81,123 @@ func (c *ReportController) ReportsByDisciplineID(ctx *gin.Context) {
 	disciplineIDStr := ctx.Query("discipline_id")
 	disciplineID, err := strconv.Atoi(disciplineIDStr)
 	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error parsing disciplineID", "detail": err.Error()})
+		problem.Abort(ctx, problem.InvalidParam("discipline_id", err))
 		return
 	}
+	withLogAttrs(ctx, slog.Int("discipline_id", disciplineID))
 	reports, err := c.reportINT.ReportsByDisciplineID(ctx, disciplineID)
 	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error getting report"})
+		problem.Abort(ctx, err)
 		return
 	}
 	ctx.JSON(http.StatusOK, gin.H{"reports": reports})
 
 }
 func (c *ReportController) ReportsDisciplines(ctx *gin.Context) {
+	scope, err := loadAccessScope(ctx, c.accessINT)
+	if err != nil {
+		problem.Abort(ctx, err)
+		return
+	}
 	disciplines, err := c.reportINT.ReportDisciplines(ctx)
 	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error getting disciplines"})
+		problem.Abort(ctx, err)
 		return
 	}
-	ctx.JSON(http.StatusOK, gin.H{"disciplines": disciplines})
+	allowed := make([]domain.DisciplineResponse, 0, len(disciplines))
+	for _, discipline := range disciplines {
+		ok, err := c.disciplineVisible(ctx, scope, discipline)
+		if err != nil {
+			problem.Abort(ctx, err)
+			return
+		}
+		if ok {
+			allowed = append(allowed, discipline)
+		}
+	}
+	ctx.JSON(http.StatusOK, gin.H{"disciplines": allowed})
 }
 
 func (c *ReportController) ReportsGroup(ctx *gin.Context) {
-	disciplineName := ctx.Query("discipline_name")
-	groups, err := c.reportINT.ReportGroups(ctx, disciplineName)
+	disciplineID, err := strconv.Atoi(ctx.Query("discipline_id"))
+	if err != nil {
+		problem.Abort(ctx, problem.InvalidParam("discipline_id", err))
+		return
+	}
+	withLogAttrs(ctx, slog.Int("discipline_id", disciplineID))
+	scope, err := loadAccessScope(ctx, c.accessINT)
+	if err != nil {
+		problem.Abort(ctx, err)
+		return
+	}
+	groups, err := c.reportINT.ReportGroups(ctx, disciplineID)
 	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error getting disciplines"})
+		problem.Abort(ctx, err)
 		return
 	}
+	if !scope.discipline(disciplineID) {
+		// Без гранта на дисциплину видны только группы, на которые выдан грант
+		filtered := make([]*domain.Group, 0, len(groups))
+		for _, group := range groups {
+			if scope.group(group.ID) {
+				filtered = append(filtered, group)
+			}
+		}
+		if len(filtered) == 0 {
+			abortForbidden(ctx, "no access to discipline "+strconv.Itoa(disciplineID))
+			return
+		}
+		groups = filtered
+	}
 	ctx.JSON(http.StatusOK, gin.H{"groups": groups})
 }
 
 func (c *ReportController) ReportsByGroupAndDiscipline(ctx *gin.Context) {
-	disciplineName := ctx.Query("discipline_name")
-	groupName := ctx.Query("group")
-	reports, stats, err := c.reportINT.ReportsByGroupAndDiscipline(ctx, disciplineName, groupName)
+	disciplineID, err := strconv.Atoi(ctx.Query("discipline_id"))
+	if err != nil {
+		problem.Abort(ctx, problem.InvalidParam("discipline_id", err))
+		return
+	}
+	groupID, err := uuid.Parse(ctx.Query("group_id"))
+	if err != nil {
+		problem.Abort(ctx, problem.InvalidParam("group_id", err))
+		return
+	}
+	withLogAttrs(ctx, slog.Int("discipline_id", disciplineID), slog.String("group_id", groupID.String()))
+	scope, err := loadAccessScope(ctx, c.accessINT)
+	if err != nil {
+		problem.Abort(ctx, err)
+		return
+	}
+	if !scope.group(groupID) && !scope.discipline(disciplineID) {
+		abortForbidden(ctx, "no access to discipline "+strconv.Itoa(disciplineID)+" or group "+groupID.String())
+		return
+	}
+	reports, stats, err := c.reportINT.ReportsByGroupAndDiscipline(ctx, disciplineID, groupID)
 	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error getting disciplines"})
+		problem.Abort(ctx, err)
 		return
 	}
 	ctx.JSON(http.StatusOK, gin.H{"reports": reports, "stats": stats})
 }
+
+// disciplineVisible - дисциплина видна, если на неё есть грант
+// или в ней есть отчёты хотя бы одной группы с грантом.
+func (c *ReportController) disciplineVisible(ctx *gin.Context, scope *accessScope, discipline domain.DisciplineResponse) (bool, error) {
+	if scope.discipline(discipline.ID) {
+		return true, nil
+	}
+	if len(scope.groups) == 0 {
+		return false, nil
+	}
+	groups, err := c.reportINT.ReportGroups(ctx, discipline.ID)
+	if err != nil {
+		return false, err
+	}
+	for _, group := range groups {
+		if scope.group(group.ID) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
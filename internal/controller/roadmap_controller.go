@@ -2,15 +2,18 @@ package controller
 
 import (
 	"errors"
+	"log/slog"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 	"github.com/immxrtalbeast/plandstu/internal/domain"
+	"github.com/immxrtalbeast/plandstu/internal/problem"
 	"gorm.io/gorm"
 )
 
+var errHistoryNotFound = domain.NotFound("roadmap_history_not_found", "user has no roadmap history")
+
 type RoadmapController struct {
 	interactor domain.RoadmapInteractor
 }
@@ -20,29 +23,24 @@ func NewRoadmapController(RoadmapINT domain.RoadmapInteractor) *RoadmapControlle
 }
 
 func (c *RoadmapController) History(ctx *gin.Context) {
-	userIDStr, ok := ctx.Keys["userID"].(string)
-	if !ok {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error parsing userID"})
-		return
-	}
-	userID, err := uuid.Parse(userIDStr)
+	userID, err := userIDFromContext(ctx)
 	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error parsing userID to uuid"})
+		problem.Abort(ctx, err)
 		return
 	}
 	disciplineIDStr := ctx.Query("link")
 	disciplineID, err := strconv.Atoi(disciplineIDStr)
 	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error parsing disciplineID", "detail": err.Error()})
+		problem.Abort(ctx, problem.InvalidParam("discipline_id", err))
 		return
 	}
+	withLogAttrs(ctx, slog.Int("discipline_id", disciplineID))
 	history, err := c.interactor.History(ctx, userID, disciplineID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "У пользователя нет истории"})
-			return
+			err = errHistoryNotFound
 		}
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error while getting roadmap history", "detail": err.Error()})
+		problem.Abort(ctx, err)
 		return
 	}
 	ctx.JSON(http.StatusOK, gin.H{"roadmap_history": history})
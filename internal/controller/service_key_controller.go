@@ -0,0 +1,67 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/immxrtalbeast/plandstu/internal/domain"
+	"github.com/immxrtalbeast/plandstu/internal/problem"
+)
+
+type ServiceKeyController struct {
+	keyINT domain.ServiceKeyInteractor
+}
+
+func NewServiceKeyController(keyINT domain.ServiceKeyInteractor) *ServiceKeyController {
+	return &ServiceKeyController{keyINT: keyINT}
+}
+
+func (c *ServiceKeyController) ServiceKeys(ctx *gin.Context) {
+	keys, err := c.keyINT.ServiceKeys(ctx)
+	if err != nil {
+		problem.Abort(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"service_keys": keys})
+}
+
+// CreateServiceKey создаёт ключ. Секрет есть только в этом ответе.
+func (c *ServiceKeyController) CreateServiceKey(ctx *gin.Context) {
+	type CreateServiceKeyRequest struct {
+		Service string   `json:"service" binding:"required,max=64"`
+		Scopes  []string `json:"scopes" binding:"required,min=1"`
+	}
+	actorID, ok := actorIDFromContext(ctx)
+	if !ok {
+		return
+	}
+	var req CreateServiceKeyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		problem.Abort(ctx, problem.InvalidBody(err))
+		return
+	}
+	key, secret, err := c.keyINT.CreateServiceKey(ctx, actorID, req.Service, req.Scopes)
+	if err != nil {
+		problem.Abort(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, gin.H{"service_key": key, "secret": secret})
+}
+
+func (c *ServiceKeyController) RevokeServiceKey(ctx *gin.Context) {
+	actorID, ok := actorIDFromContext(ctx)
+	if !ok {
+		return
+	}
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		problem.Abort(ctx, problem.InvalidParam("id", err))
+		return
+	}
+	if err := c.keyINT.RevokeServiceKey(ctx, actorID, id); err != nil {
+		problem.Abort(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{})
+}
@@ -1,7 +1,7 @@
 package controller
 
 import (
-	"errors"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"time"
@@ -9,16 +9,41 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/immxrtalbeast/plandstu/internal/domain"
+	"github.com/immxrtalbeast/plandstu/internal/problem"
 	"gorm.io/datatypes"
-	"gorm.io/gorm"
 )
 
 type TeacherTestController struct {
 	teacherTestINT domain.TeacherTestInteractor
+	accessINT      domain.AccessInteractor
 }
 
-func NewTeacherTestController(teacherTestINT domain.TeacherTestInteractor) *TeacherTestController {
-	return &TeacherTestController{teacherTestINT: teacherTestINT}
+func NewTeacherTestController(teacherTestINT domain.TeacherTestInteractor, accessINT domain.AccessInteractor) *TeacherTestController {
+	return &TeacherTestController{teacherTestINT: teacherTestINT, accessINT: accessINT}
+}
+
+// checkDiscipline прерывает запрос с 403, если у пользователя нет гранта на дисциплину.
+func (c *TeacherTestController) checkDiscipline(ctx *gin.Context, disciplineID int) bool {
+	scope, err := loadAccessScope(ctx, c.accessINT)
+	if err != nil {
+		problem.Abort(ctx, err)
+		return false
+	}
+	if !scope.discipline(disciplineID) {
+		abortForbidden(ctx, "no access to discipline "+strconv.Itoa(disciplineID))
+		return false
+	}
+	return true
+}
+
+// checkTest проверяет грант на дисциплину, к которой относится тест.
+func (c *TeacherTestController) checkTest(ctx *gin.Context, testID uuid.UUID) bool {
+	test, err := c.teacherTestINT.TeacherTestByID(ctx, testID)
+	if err != nil {
+		problem.Abort(ctx, err)
+		return false
+	}
+	return c.checkDiscipline(ctx, test.DisciplineID)
 }
 
 func (c *TeacherTestController) TeacherTest(ctx *gin.Context) {
@@ -26,16 +51,16 @@ func (c *TeacherTestController) TeacherTest(ctx *gin.Context) {
 
 	disciplineID, err := strconv.Atoi(disciplineIDStr)
 	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error parsing disciplineID", "detail": err.Error()})
+		problem.Abort(ctx, problem.InvalidParam("discipline_id", err))
+		return
+	}
+	withLogAttrs(ctx, slog.Int("discipline_id", disciplineID))
+	if !c.checkDiscipline(ctx, disciplineID) {
 		return
 	}
 	test, err := c.teacherTestINT.TeacherTests(ctx, disciplineID)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Теста не существует."})
-			return
-		}
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error getting tests", "detail": err.Error()})
+		problem.Abort(ctx, err)
 		return
 	}
 	ctx.JSON(http.StatusOK, gin.H{"test": test})
@@ -45,11 +70,14 @@ func (c *TeacherTestController) DeleteTeacherTest(ctx *gin.Context) {
 
 	testID, err := uuid.Parse(testIDStr)
 	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error parsing testID", "detail": err.Error()})
+		problem.Abort(ctx, problem.InvalidParam("test_id", err))
+		return
+	}
+	if !c.checkTest(ctx, testID) {
 		return
 	}
 	if err := c.teacherTestINT.DeleteTeacherTest(ctx, testID); err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error deleting test", "detail": err.Error()})
+		problem.Abort(ctx, err)
 		return
 	}
 	ctx.JSON(http.StatusOK, gin.H{})
@@ -64,12 +92,15 @@ func (c *TeacherTestController) UpdateTeacherTest(ctx *gin.Context) {
 	}
 	var request UpdateTeacherTestRequest
 	if err := ctx.ShouldBindJSON(&request); err != nil {
-		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		problem.Abort(ctx, problem.InvalidBody(err))
+		return
+	}
+	if !c.checkTest(ctx, request.TestID) {
 		return
 	}
 
 	if err := c.teacherTestINT.UpdateTeacherTest(ctx, request.TestID, request.Test, request.Answers); err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error creating test", "detail": err.Error()})
+		problem.Abort(ctx, err)
 		return
 	}
 	ctx.JSON(http.StatusOK, gin.H{})
@@ -81,7 +112,7 @@ func (c *TeacherTestController) CreateTeacherTest(ctx *gin.Context) {
 	}
 	var request CreateTeacherTestRequest
 	if err := ctx.ShouldBindJSON(&request); err != nil {
-		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		problem.Abort(ctx, problem.InvalidBody(err))
 		return
 	}
 
@@ -89,12 +120,16 @@ func (c *TeacherTestController) CreateTeacherTest(ctx *gin.Context) {
 
 	disciplineID, err := strconv.Atoi(disciplineIDStr)
 	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error parsing disciplineID", "detail": err.Error()})
+		problem.Abort(ctx, problem.InvalidParam("discipline_id", err))
+		return
+	}
+	withLogAttrs(ctx, slog.Int("discipline_id", disciplineID))
+	if !c.checkDiscipline(ctx, disciplineID) {
 		return
 	}
 
 	if err = c.teacherTestINT.CreateTeacherTest(ctx, request.Test, request.Answers, disciplineID); err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error creating test", "detail": err.Error()})
+		problem.Abort(ctx, err)
 		return
 	}
 	ctx.JSON(http.StatusOK, gin.H{})
@@ -105,12 +140,16 @@ func (c *TeacherTestController) RandomTestTest(ctx *gin.Context) {
 
 	disciplineID, err := strconv.Atoi(disciplineIDStr)
 	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error parsing disciplineID", "detail": err.Error()})
+		problem.Abort(ctx, problem.InvalidParam("discipline_id", err))
+		return
+	}
+	withLogAttrs(ctx, slog.Int("discipline_id", disciplineID))
+	if !c.checkDiscipline(ctx, disciplineID) {
 		return
 	}
 	test, err := c.teacherTestINT.TeacherTestForUser(ctx, disciplineID)
 	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error getting test", "detail": err.Error()})
+		problem.Abort(ctx, err)
 		return
 	}
 	ctx.JSON(http.StatusOK, gin.H{"test": test})
@@ -1,66 +1,61 @@
 package controller
 
 import (
-	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
+	"log/slog"
 	"net/http"
 	"strconv"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/hibiken/asynq"
 	"github.com/immxrtalbeast/plandstu/internal/domain"
+	"github.com/immxrtalbeast/plandstu/internal/llmclient"
+	"github.com/immxrtalbeast/plandstu/internal/logging"
+	"github.com/immxrtalbeast/plandstu/internal/problem"
 	"github.com/immxrtalbeast/plandstu/internal/task"
+	"github.com/immxrtalbeast/plandstu/internal/tracing"
+	"go.opentelemetry.io/otel/trace"
 	"gorm.io/datatypes"
+	"gorm.io/gorm"
 )
 
+var errTaskNotFound = domain.NotFound("task_not_found", "task not found")
+
 type TestsController struct {
-	llmURL         string
+	llm            llmclient.Client
 	roadmapINT     domain.RoadmapInteractor
 	testINT        domain.TestInteractor
 	redisURL       string
 	teacherTestINT domain.TeacherTestInteractor
 }
 
-func NewTestsController(llmURL string, roadmapINT domain.RoadmapInteractor, testINT domain.TestInteractor, redisURL string, teacherTestINT domain.TeacherTestInteractor) *TestsController {
-	return &TestsController{llmURL: llmURL, roadmapINT: roadmapINT, testINT: testINT, redisURL: redisURL, teacherTestINT: teacherTestINT}
+func NewTestsController(llm llmclient.Client, roadmapINT domain.RoadmapInteractor, testINT domain.TestInteractor, redisURL string, teacherTestINT domain.TeacherTestInteractor) *TestsController {
+	return &TestsController{llm: llm, roadmapINT: roadmapINT, testINT: testINT, redisURL: redisURL, teacherTestINT: teacherTestINT}
 }
 
 // TODO: Можно объеденить FirtsTest и просто Test
 func (c *TestsController) FirstTest(ctx *gin.Context) {
-	type GenerateTestRequest struct {
-		TestID uuid.UUID `json:"test_id"`
-		Themes []string  `json:"themes"`
-	}
-
 	type CreateTestRequets struct {
 		Themes []string `json:"themes"`
 	}
 	var request CreateTestRequets
 	if err := ctx.ShouldBindJSON(&request); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "invalid request body",
-			"details": err.Error(),
-		})
+		problem.Abort(ctx, problem.InvalidBody(err))
 		return
 	}
 	disciplineIDStr := ctx.Query("discipline_id")
 	disciplineID, err := strconv.Atoi(disciplineIDStr)
 	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error parsing disciplineID", "detail": err.Error()})
+		problem.Abort(ctx, problem.InvalidParam("discipline_id", err))
 		return
 	}
-	userIDStr, ok := ctx.Keys["userID"].(string)
-	if !ok {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error parsing userID"})
-		return
-	}
-	userID, err := uuid.Parse(userIDStr)
+	withLogAttrs(ctx, slog.Int("discipline_id", disciplineID))
+	userID, err := userIDFromContext(ctx)
 	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error parsing userID to uuid"})
+		problem.Abort(ctx, err)
 		return
 	}
 	var history *domain.RoadmapHistory
@@ -68,22 +63,19 @@ func (c *TestsController) FirstTest(ctx *gin.Context) {
 	if err != nil {
 		newHistory, err := c.roadmapINT.CreateHistory(ctx, userID, disciplineID)
 		if err != nil {
-			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error creating history", "detail": err.Error()})
+			problem.Abort(ctx, err)
 			return
 		}
 		history = newHistory
 	} else {
 		history = existingHistory
 	}
-	client := &http.Client{
-		Timeout: 20 * time.Second,
-	}
 	teacherTest, _ := c.teacherTestINT.TeacherTestForUser(ctx, disciplineID)
 	if teacherTest != nil {
 		generatedTestID := uuid.New()
-		result, err := c.SendAnswers(ctx, client, teacherTest, history.ID, generatedTestID)
+		result, err := c.SendAnswers(ctx, teacherTest, history.ID, generatedTestID)
 		if err != nil {
-			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error with preload test", "detail": err.Error()})
+			problem.Abort(ctx, err)
 			return
 		}
 
@@ -92,128 +84,90 @@ func (c *TestsController) FirstTest(ctx *gin.Context) {
 	}
 
 	generatedTestID := uuid.New()
-	genReq := GenerateTestRequest{
+	data, err := c.llm.ExampleTest(ctx.Request.Context(), llmclient.TestRequest{
 		TestID: generatedTestID,
 		Themes: request.Themes,
-	}
-	requestBody, err := json.Marshal(genReq)
-	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error creating request"})
-		return
-	}
-	req, err := http.NewRequest("GET", c.llmURL+"test-exmpl/", bytes.NewBuffer(requestBody))
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Failed to create request", "details": err.Error()})
-		return
-
-	}
-	req.Header.Set("User-Agent", "LLM/1.0")
-	resp, err := client.Do(req)
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Request failed", "details": err.Error()})
-		return
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Unknown status code", "details": resp.Status})
-		return
-	}
-	data, err := io.ReadAll(resp.Body)
+	})
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to read response body",
-			"details": err.Error(),
-		})
-		return
-	}
-	if !json.Valid(data) {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid JSON format in response",
-		})
+		problem.Abort(ctx, err)
 		return
 	}
 	var jsonData map[string]interface{}
 	if err := json.Unmarshal(data, &jsonData); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to parse response JSON",
-			"details": err.Error(),
-		})
+		problem.Abort(ctx, llmclient.ErrUnavailable.Wrap(err))
 		return
 	}
 	jsonData["id"] = generatedTestID.String() // Добавление ID
 	modifiedData, err := json.Marshal(jsonData)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to marshal modified JSON",
-			"details": err.Error(),
-		})
+		problem.Abort(ctx, err)
+		return
+	}
+	answers, err := c.testINT.FetchCorrectAnswers(ctx, generatedTestID)
+	if err != nil {
+		problem.Abort(ctx, err)
 		return
 	}
-	_, err = c.testINT.CreateTest(ctx, generatedTestID, datatypes.JSON(data), history.ID, true)
+	_, err = c.testINT.CreateTest(ctx, generatedTestID, datatypes.JSON(data), answers, history.ID, true)
 	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error creating test", "detail": err.Error()})
+		problem.Abort(ctx, err)
 		return
 	}
-	ctx.Data(resp.StatusCode, "application/json", modifiedData)
+	ctx.Data(http.StatusOK, "application/json", modifiedData)
 }
 
 func (c *TestsController) CreateTest(ctx *gin.Context) {
-	type GenerateTestRequest struct {
-		TestID uuid.UUID `json:"test_id"`
-		Themes []string  `json:"themes"`
-	}
 	type CreateTestRequets struct {
 		Themes []string `json:"themes"`
 	}
 	var request CreateTestRequets
 	if err := ctx.ShouldBindJSON(&request); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "invalid request body",
-			"details": err.Error(),
-		})
+		problem.Abort(ctx, problem.InvalidBody(err))
 		return
 	}
 	disciplineIDStr := ctx.Query("discipline_id")
 	disciplineID, err := strconv.Atoi(disciplineIDStr)
 	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error parsing disciplineID", "detail": err.Error()})
-		return
-	}
-	userIDStr, ok := ctx.Keys["userID"].(string)
-	if !ok {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error parsing userID"})
+		problem.Abort(ctx, problem.InvalidParam("discipline_id", err))
 		return
 	}
-	userID, err := uuid.Parse(userIDStr)
+	withLogAttrs(ctx, slog.Int("discipline_id", disciplineID))
+	userID, err := userIDFromContext(ctx)
 	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error parsing userID to uuid"})
+		problem.Abort(ctx, err)
 		return
 	}
 	history, err := c.roadmapINT.History(ctx, userID, disciplineID)
 	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error creating history", "detail": err.Error()})
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = errHistoryNotFound
+		}
+		problem.Abort(ctx, err)
 		return
 	}
 
 	generatedTestID := uuid.New()
 	payload := task.GenerateTestPayload{
-		TestID:        generatedTestID.String(),
-		Themes:        request.Themes,
-		UserID:        userID.String(),
-		DisciplineID:  disciplineID,
-		HistoryID:     history.ID,
-		LLMServiceURL: c.llmURL,
-	}
+		TestID:       generatedTestID.String(),
+		Themes:       request.Themes,
+		UserID:       userID.String(),
+		DisciplineID: disciplineID,
+		HistoryID:    history.ID,
+	}
+	enqueueCtx, span := tracing.Start(ctx.Request.Context(), "task.enqueue "+task.QueueGenerateTest, trace.WithSpanKind(trace.SpanKindProducer))
+	defer span.End()
+	payload.TraceContext = tracing.Inject(enqueueCtx)
+	payload.RequestID = logging.RequestID(enqueueCtx)
 	t, err := task.NewGenerateTestTask(payload)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create task"})
+		problem.Abort(ctx, err)
 		return
 	}
 
-	info, err := task.RedisClient.Enqueue(t)
+	info, err := task.RedisClient.EnqueueContext(enqueueCtx, t)
+	tracing.RecordError(span, err)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to enqueue task"})
+		problem.Abort(ctx, err)
 		return
 	}
 
@@ -229,18 +183,12 @@ func (c *TestsController) Answers(ctx *gin.Context) {
 	}
 	var req AnswersRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "invalid request body",
-			"details": err.Error(),
-		})
+		problem.Abort(ctx, problem.InvalidBody(err))
 		return
 	}
 	test_result, err := c.testINT.Answers(ctx, req.TestID, req.Answers)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "failed to post answers",
-			"details": err.Error(),
-		})
+		problem.Abort(ctx, err)
 		return
 	}
 	ctx.JSON(http.StatusOK, datatypes.JSON(test_result))
@@ -251,7 +199,10 @@ func (c *TestsController) GetTaskStatus(ctx *gin.Context) {
 	inspector := asynq.NewInspector(asynq.RedisClientOpt{Addr: c.redisURL})
 	taskInfo, err := inspector.GetTaskInfo("default", taskID)
 	if err != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+		if errors.Is(err, asynq.ErrTaskNotFound) || errors.Is(err, asynq.ErrQueueNotFound) {
+			err = errTaskNotFound
+		}
+		problem.Abort(ctx, err)
 		return
 	}
 
@@ -264,34 +215,25 @@ func (c *TestsController) MyHistory(ctx *gin.Context) {
 	disciplineIDStr := ctx.Query("discipline_id")
 	disciplineID, err := strconv.Atoi(disciplineIDStr)
 	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error parsing disciplineID", "detail": err.Error()})
+		problem.Abort(ctx, problem.InvalidParam("discipline_id", err))
 		return
 	}
-	userIDStr, ok := ctx.Keys["userID"].(string)
-	if !ok {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error parsing userID"})
-		return
-	}
-	userID, err := uuid.Parse(userIDStr)
+	withLogAttrs(ctx, slog.Int("discipline_id", disciplineID))
+	userID, err := userIDFromContext(ctx)
 	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error parsing userID to uuid"})
+		problem.Abort(ctx, err)
 		return
 	}
 
 	tests, err := c.roadmapINT.Report(ctx, userID, disciplineID)
 	if err != nil {
-		var tests []string
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"history": tests, "error": "Error getting tests"})
+		problem.Abort(ctx, err)
 		return
 	}
 	ctx.JSON(http.StatusOK, gin.H{"history": tests})
 }
 
-func (c *TestsController) SendAnswers(ctx *gin.Context, client *http.Client, teacherTest *domain.TestResponse, historyID uuid.UUID, generatedTestID uuid.UUID) (*domain.TestResponse, error) {
-	type SetAnswersRequest struct {
-		TestID  uuid.UUID `json:"test_id"`
-		Answers []string  `json:"answers"`
-	}
+func (c *TestsController) SendAnswers(ctx *gin.Context, teacherTest *domain.TestResponse, historyID uuid.UUID, generatedTestID uuid.UUID) (*domain.TestResponse, error) {
 	teacherTestFull, err := c.teacherTestINT.TeacherTestByID(ctx, teacherTest.ID)
 	if err != nil {
 		return nil, err
@@ -301,28 +243,6 @@ func (c *TestsController) SendAnswers(ctx *gin.Context, client *http.Client, tea
 	if err := json.Unmarshal(teacherTestFull.Answers, &answers); err != nil {
 		return nil, err
 	}
-	setAnswersReq := SetAnswersRequest{
-		TestID:  generatedTestID,
-		Answers: answers,
-	}
-	requestBody, err := json.Marshal(setAnswersReq)
-	if err != nil {
-		return nil, err
-	}
-	req, err := http.NewRequest("POST", c.llmURL+"test/set-answers", bytes.NewBuffer(requestBody))
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("User-Agent", "LLM/1.0")
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, err
-	}
 	wrappedJSON := []byte(`{"test":` + string(teacherTestFull.DetailsJSONB) + `}`)
 
 	// Проверяем валидность JSON
@@ -330,7 +250,7 @@ func (c *TestsController) SendAnswers(ctx *gin.Context, client *http.Client, tea
 		return nil, fmt.Errorf("failed to wrapp json")
 	}
 	wrappedDetails := datatypes.JSON(wrappedJSON)
-	_, err = c.testINT.CreateTest(ctx, generatedTestID, wrappedDetails, historyID, true)
+	_, err = c.testINT.CreateTest(ctx, generatedTestID, wrappedDetails, answers, historyID, true)
 	if err != nil {
 		return nil, err
 	}
@@ -1,88 +1,91 @@
 package controller
 
 import (
+	"errors"
 	"net/http"
 	"regexp"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/immxrtalbeast/plandstu/internal/domain"
+	"github.com/immxrtalbeast/plandstu/internal/lib"
+	"github.com/immxrtalbeast/plandstu/internal/middleware"
+	"github.com/immxrtalbeast/plandstu/internal/problem"
+	"github.com/immxrtalbeast/plandstu/internal/usecase/user"
 )
 
+const (
+	RefreshCookie = "refresh_token"
+	userIDCookie  = "user_id"
+
+	tokenDeliveryBody = "body"
+)
+
+var passwordRegex = regexp.MustCompile(`^[a-zA-Z0-9!@#$%^&*()_+\[\]{};:<>,./?~\\-]+$`)
+
+var (
+	errPasswordCharacters   = domain.Validation("invalid_password", "password contains forbidden characters")
+	errRefreshTokenRequired = domain.Unauthorized("refresh_token_required", "refresh token is required")
+)
+
+// CookieSettings - атрибуты кук авторизации.
+type CookieSettings struct {
+	Domain   string
+	Secure   bool
+	HTTPOnly bool
+	SameSite http.SameSite
+}
+
 type UserController struct {
 	interactor  domain.UserInteractor
+	passwordINT domain.PasswordInteractor
+	cookies     CookieSettings
 	tokenTTL    time.Duration
-	tokenSecret string
+	refreshTTL  time.Duration
 }
 
-func NewUserController(interactor domain.UserInteractor, tokenTTL time.Duration, tokenSecret string) *UserController {
-	return &UserController{interactor: interactor, tokenTTL: tokenTTL, tokenSecret: tokenSecret}
+func NewUserController(interactor domain.UserInteractor, passwordINT domain.PasswordInteractor, cookies CookieSettings, tokenTTL time.Duration, refreshTTL time.Duration) *UserController {
+	return &UserController{interactor: interactor, passwordINT: passwordINT, cookies: cookies, tokenTTL: tokenTTL, refreshTTL: refreshTTL}
 }
 
 func (c *UserController) Register(ctx *gin.Context) {
 	type RegisterRequest struct {
-		Login string `json:"login" binding:"required,min=3,max=50"`
-		Pass  string `json:"password" binding:"required,min=8,max=50"`
-		Group string `json:"group"`
+		Login   string     `json:"login" binding:"required,min=3,max=50"`
+		Pass    string     `json:"password" binding:"required,min=8,max=50"`
+		GroupID *uuid.UUID `json:"group_id"`
 	}
 
 	var req RegisterRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "invalid request body",
-			"details": err.Error(),
-		})
+		problem.Abort(ctx, problem.InvalidBody(err))
 		return
 	}
 
 	// Валидация пароля
-	passRegex := regexp.MustCompile(`^[a-zA-Z0-9!@#$%^&*()_+\[\]{};:<>,./?~\\-]+$`)
-	if !passRegex.MatchString(req.Pass) {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "invalid password",
-			"details": "Password contains forbidden characters",
-		})
+	if !passwordRegex.MatchString(req.Pass) {
+		problem.Abort(ctx, errPasswordCharacters)
 		return
 	}
 
 	// Если все проверки пройдены
-	id, err := c.interactor.CreateUser(ctx, req.Login, req.Pass, req.Group)
+	id, err := c.interactor.CreateUser(ctx, req.Login, req.Pass, req.GroupID)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "failed to create user",
-			"details": err.Error(),
-		})
+		problem.Abort(ctx, err)
 		return
 	}
-	token, err := c.interactor.Login(ctx, req.Login, req.Pass)
+	tokens, err := c.interactor.Login(ctx, req.Login, req.Pass, clientInfo(ctx))
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "failed to login",
-			"details": err.Error(),
-		})
-		return
-	}
-	ctx.SetSameSite(http.SameSiteLaxMode)
-	ctx.SetCookie(
-		"jwt",                     // Имя куки
-		token,                     // Значение токена
-		int(c.tokenTTL.Seconds()), // Макс возраст в секундах
-		"/",                       // Путь
-		"",                        // Домен (пусто для текущего домена)
-		false,                     // Secure (использовать true в production для HTTPS)
-		false,                     // HttpOnly
-	)
-
-	// Устанавливаем куку
-	ctx.SetCookie(
-		"user_id",
-		id.String(),
-		int(c.tokenTTL.Seconds()),
-		"/",
-		"",
-		false,
-		false, // HttpOnly=false чтобы клиент мог читать JS
-	)
+		problem.Abort(ctx, err)
+		return
+	}
+	if err := c.setAuthCookies(ctx, tokens); err != nil {
+		problem.Abort(ctx, err)
+		return
+	}
+	// HttpOnly=false, чтобы клиент мог читать ID из JS
+	c.setCookie(ctx, userIDCookie, id.String(), int(c.tokenTTL.Seconds()), "/", false)
 
 	ctx.JSON(http.StatusOK, gin.H{
 		"message": "user created",
@@ -91,36 +94,217 @@ func (c *UserController) Register(ctx *gin.Context) {
 }
 func (c *UserController) Login(ctx *gin.Context) {
 	type LoginRequest struct {
-		Login string `json:"login" binding:"required"`
+		Login string `json:"login" binding:"required,max=50"`
 		Pass  string `json:"password" binding:"required"`
+		// TokenDelivery - body для клиентов без кук (мобильные, скрипты)
+		TokenDelivery string `json:"token_delivery" binding:"omitempty,oneof=cookie body"`
 	}
 	var req LoginRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "invalid request body",
-			"details": err.Error(),
-		})
+		problem.Abort(ctx, problem.InvalidBody(err))
+		return
+	}
+	tokens, err := c.interactor.Login(ctx, req.Login, req.Pass, clientInfo(ctx))
+	if err != nil {
+		problem.Abort(ctx, err)
+		return
+	}
+	c.respondTokens(ctx, tokens, req.TokenDelivery == tokenDeliveryBody)
+}
+
+// Refresh выдаёт новую пару токенов по refresh-токену из куки или тела запроса.
+func (c *UserController) Refresh(ctx *gin.Context) {
+	type RefreshRequest struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	var req RefreshRequest
+	_ = ctx.ShouldBindJSON(&req)
+	// Клиент, приславший токен в теле, кук не использует и получает новый токен так же
+	inBody := req.RefreshToken != ""
+	if !inBody {
+		req.RefreshToken, _ = ctx.Cookie(RefreshCookie)
+	}
+	if req.RefreshToken == "" {
+		problem.Abort(ctx, errRefreshTokenRequired)
+		return
+	}
+
+	tokens, err := c.interactor.Refresh(ctx, req.RefreshToken, clientInfo(ctx))
+	if err != nil {
+		if errors.Is(err, user.ErrInvalidRefreshToken) {
+			c.clearAuthCookies(ctx)
+		}
+		problem.Abort(ctx, err)
+		return
+	}
+	c.respondTokens(ctx, tokens, inBody)
+}
+
+// respondTokens отдаёт токены в теле либо ставит куки. Во втором случае
+// refresh-токен в тело не попадает, иначе HttpOnly кука ничего бы не давала.
+func (c *UserController) respondTokens(ctx *gin.Context, tokens *domain.TokenPair, inBody bool) {
+	if inBody {
+		ctx.JSON(http.StatusOK, tokens)
 		return
 	}
-	token, err := c.interactor.Login(ctx, req.Login, req.Pass)
+	if err := c.setAuthCookies(ctx, tokens); err != nil {
+		problem.Abort(ctx, err)
+		return
+	}
+	withoutRefresh := *tokens
+	withoutRefresh.RefreshToken = ""
+	ctx.JSON(http.StatusOK, withoutRefresh)
+}
+
+// Logout отзывает текущий access-токен и refresh-сессию и очищает куки.
+func (c *UserController) Logout(ctx *gin.Context) {
+	type LogoutRequest struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	var req LogoutRequest
+	_ = ctx.ShouldBindJSON(&req)
+	if req.RefreshToken == "" {
+		req.RefreshToken, _ = ctx.Cookie(RefreshCookie)
+	}
+	userID, err := userIDFromContext(ctx)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "failed to login",
-			"details": err.Error(),
-		})
+		problem.Abort(ctx, err)
 		return
 	}
+	jti, _ := ctx.Keys["tokenID"].(string)
+	exp, ok := ctx.Keys["tokenExp"].(time.Time)
+	if !ok {
+		exp = time.Now().Add(c.tokenTTL)
+	}
 
-	ctx.SetSameSite(http.SameSiteLaxMode)
-	ctx.SetCookie(
-		"jwt",                     // Имя куки
-		token,                     // Значение токена
-		int(c.tokenTTL.Seconds()), // Макс возраст в секундах
-		"/",                       // Путь
-		"",                        // Домен (пусто для текущего домена)
-		false,                     // Secure (использовать true в production для HTTPS)
-		false,                     // HttpOnly
-	)
+	if err := c.interactor.Logout(ctx, req.RefreshToken, jti, userID, exp); err != nil {
+		problem.Abort(ctx, err)
+		return
+	}
+	c.clearAuthCookies(ctx)
 
 	ctx.JSON(http.StatusOK, gin.H{})
 }
+
+// ChangePassword меняет пароль текущего пользователя. Все сессии, включая
+// текущую, завершаются, после смены нужно войти заново.
+func (c *UserController) ChangePassword(ctx *gin.Context) {
+	type ChangePasswordRequest struct {
+		OldPass string `json:"old_password" binding:"required"`
+		NewPass string `json:"new_password" binding:"required,min=8,max=50"`
+	}
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		problem.Abort(ctx, err)
+		return
+	}
+	var req ChangePasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		problem.Abort(ctx, problem.InvalidBody(err))
+		return
+	}
+	if !passwordRegex.MatchString(req.NewPass) {
+		problem.Abort(ctx, errPasswordCharacters)
+		return
+	}
+	if err := c.passwordINT.ChangePassword(ctx, userID, req.OldPass, req.NewPass); err != nil {
+		problem.Abort(ctx, err)
+		return
+	}
+	// Сессии и выданные токены, включая текущий, уже отозваны
+	c.clearAuthCookies(ctx)
+
+	ctx.JSON(http.StatusOK, gin.H{})
+}
+
+// ForgotPassword отправляет письмо со ссылкой сброса. Ответ одинаковый
+// независимо от того, найден ли пользователь.
+func (c *UserController) ForgotPassword(ctx *gin.Context) {
+	type ForgotPasswordRequest struct {
+		Login string `json:"login" binding:"required,max=254"`
+	}
+	var req ForgotPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		problem.Abort(ctx, problem.InvalidBody(err))
+		return
+	}
+	if err := c.passwordINT.RequestReset(ctx, req.Login); err != nil {
+		problem.Abort(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusAccepted, gin.H{})
+}
+
+// ResetPassword задаёт новый пароль по токену из письма.
+func (c *UserController) ResetPassword(ctx *gin.Context) {
+	type ResetPasswordRequest struct {
+		Token   string `json:"token" binding:"required,max=128"`
+		NewPass string `json:"new_password" binding:"required,min=8,max=50"`
+	}
+	var req ResetPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		problem.Abort(ctx, problem.InvalidBody(err))
+		return
+	}
+	if !passwordRegex.MatchString(req.NewPass) {
+		problem.Abort(ctx, errPasswordCharacters)
+		return
+	}
+	if err := c.passwordINT.Reset(ctx, req.Token, req.NewPass); err != nil {
+		problem.Abort(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{})
+}
+
+// LoginEvents отдаёт журнал входов текущего пользователя, новые записи первыми.
+func (c *UserController) LoginEvents(ctx *gin.Context) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		problem.Abort(ctx, err)
+		return
+	}
+	limit, _ := strconv.Atoi(ctx.Query("limit"))
+	offset, _ := strconv.Atoi(ctx.Query("offset"))
+	events, total, err := c.interactor.LoginEvents(ctx, userID, domain.LoginEventFilter{Limit: limit, Offset: offset})
+	if err != nil {
+		problem.Abort(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"logins": events, "total": total})
+}
+
+// setAuthCookies ставит куки с токенами и новый CSRF-токен. CSRF-токен
+// дублируется в заголовке ответа: фронтенд на другом домене куку прочитать не может.
+func (c *UserController) setAuthCookies(ctx *gin.Context, tokens *domain.TokenPair) error {
+	csrfToken, _, err := lib.NewOpaqueToken()
+	if err != nil {
+		return err
+	}
+	c.setCookie(ctx, middleware.AccessCookie, tokens.AccessToken, int(c.tokenTTL.Seconds()), "/", c.cookies.HTTPOnly)
+	// Refresh-токен нужен только эндпоинтам /refresh и /logout, JS его не читает
+	c.setCookie(ctx, RefreshCookie, tokens.RefreshToken, int(c.refreshTTL.Seconds()), "/api/v1", true)
+	// CSRF-токен клиент читает и отправляет в заголовке, поэтому без HttpOnly
+	c.setCookie(ctx, middleware.CSRFCookie, csrfToken, int(c.refreshTTL.Seconds()), "/", false)
+	ctx.Header(middleware.CSRFHeader, csrfToken)
+	return nil
+}
+
+func (c *UserController) clearAuthCookies(ctx *gin.Context) {
+	c.setCookie(ctx, middleware.AccessCookie, "", -1, "/", c.cookies.HTTPOnly)
+	c.setCookie(ctx, RefreshCookie, "", -1, "/api/v1", true)
+	c.setCookie(ctx, middleware.CSRFCookie, "", -1, "/", false)
+	c.setCookie(ctx, userIDCookie, "", -1, "/", false)
+}
+
+func (c *UserController) setCookie(ctx *gin.Context, name string, value string, maxAge int, path string, httpOnly bool) {
+	ctx.SetSameSite(c.cookies.SameSite)
+	ctx.SetCookie(name, value, maxAge, path, c.cookies.Domain, c.cookies.Secure, httpOnly)
+}
+
+func clientInfo(ctx *gin.Context) domain.ClientInfo {
+	return domain.ClientInfo{
+		IP:        ctx.ClientIP(),
+		UserAgent: ctx.Request.UserAgent(),
+	}
+}
@@ -0,0 +1,84 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	RoleUser    = "User"
+	RoleTeacher = "Teacher"
+	RoleAdmin   = "Admin"
+)
+
+const (
+	PermReportsRead        = "reports:read"
+	PermTeacherTestsManage = "teacher_tests:manage"
+	PermGrantsManage       = "grants:manage"
+	PermUsersManage        = "users:manage"
+	PermGroupsManage       = "groups:manage"
+	PermSystemStatus       = "system:status"
+	PermCatalogSync        = "catalog:sync"
+	PermServiceKeysManage  = "service_keys:manage"
+	// PermAllScopes снимает проверку скоупов: доступны все дисциплины и группы.
+	PermAllScopes = "scopes:all"
+)
+
+const (
+	ScopeDiscipline = "discipline"
+	ScopeGroup      = "group"
+)
+
+// DefaultRolePermissions засевается в БД при старте, если записей ещё нет.
+var DefaultRolePermissions = map[string][]string{
+	RoleUser:    {},
+	RoleTeacher: {PermReportsRead, PermTeacherTestsManage},
+	RoleAdmin:   {PermReportsRead, PermTeacherTestsManage, PermGrantsManage, PermUsersManage, PermGroupsManage, PermSystemStatus, PermCatalogSync, PermServiceKeysManage, PermAllScopes},
+}
+
+type Role struct {
+	Name        string `gorm:"primaryKey;size:50"`
+	Description string
+	CreatedAt   time.Time
+}
+
+type Permission struct {
+	Name      string `gorm:"primaryKey;size:100"`
+	CreatedAt time.Time
+}
+
+type RolePermission struct {
+	RoleName       string `gorm:"primaryKey;size:50"`
+	PermissionName string `gorm:"primaryKey;size:100"`
+}
+
+// AccessGrant ограничивает действие прав конкретным объектом,
+// например преподаватель -> дисциплина или преподаватель -> группа.
+type AccessGrant struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_access_grant" json:"user_id"`
+	Scope     string    `gorm:"size:32;not null;uniqueIndex:idx_access_grant" json:"scope"`
+	Value     string    `gorm:"not null;uniqueIndex:idx_access_grant" json:"value"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type AccessInteractor interface {
+	HasPermission(ctx context.Context, role string, permission string) (bool, error)
+	CheckScope(ctx context.Context, userID uuid.UUID, role string, scope string, value string) error
+	ScopeValues(ctx context.Context, userID uuid.UUID, role string, scope string) (values []string, all bool, err error)
+	Grants(ctx context.Context, userID uuid.UUID) ([]*AccessGrant, error)
+	CreateGrant(ctx context.Context, userID uuid.UUID, scope string, value string) (*AccessGrant, error)
+	DeleteGrant(ctx context.Context, grantID uuid.UUID) error
+}
+
+type AccessRepository interface {
+	SeedRoles(ctx context.Context, roles map[string][]string) error
+	RoleExists(ctx context.Context, role string) (bool, error)
+	RolePermissions(ctx context.Context, role string) ([]string, error)
+	GrantValues(ctx context.Context, userID uuid.UUID, scope string) ([]string, error)
+	Grants(ctx context.Context, userID uuid.UUID) ([]*AccessGrant, error)
+	CreateGrant(ctx context.Context, grant *AccessGrant) error
+	DeleteGrant(ctx context.Context, grantID uuid.UUID) error
+}
@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+const (
+	AuditUserCreated       = "user.created"
+	AuditUserRoleChanged   = "user.role_changed"
+	AuditUserDisabled      = "user.disabled"
+	AuditUserEnabled       = "user.enabled"
+	AuditUserDeleted       = "user.deleted"
+	AuditUserPasswordReset = "user.password_reset"
+	AuditUserUnlocked      = "user.unlocked"
+	AuditServiceKeyCreated = "service_key.created"
+	AuditServiceKeyRevoked = "service_key.revoked"
+)
+
+// AuditLog - запись об административном действии. Не удаляется вместе с пользователем.
+type AuditLog struct {
+	ID         uuid.UUID      `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	ActorID    uuid.UUID      `gorm:"type:uuid;index" json:"actor_id"`
+	Action     string         `gorm:"size:64;not null;index" json:"action"`
+	TargetType string         `gorm:"size:32" json:"target_type"`
+	TargetID   string         `gorm:"index" json:"target_id"`
+	Details    datatypes.JSON `gorm:"type:jsonb" json:"details"`
+	CreatedAt  time.Time      `gorm:"index" json:"created_at"`
+}
+
+type AuditFilter struct {
+	ActorID  uuid.UUID
+	TargetID string
+	Action   string
+	Limit    int
+	Offset   int
+}
+
+type AuditRepository interface {
+	WriteAudit(ctx context.Context, entry *AuditLog) error
+	AuditLogs(ctx context.Context, filter AuditFilter) ([]*AuditLog, int64, error)
+}
@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// CatalogEntry - закэшированный ответ парсера. Key - путь ресурса у парсера.
+type CatalogEntry struct {
+	Key       string         `gorm:"primaryKey" json:"key"`
+	Kind      string         `gorm:"size:32;not null;index" json:"kind"`
+	Payload   datatypes.JSON `gorm:"type:jsonb;not null" json:"-"`
+	FetchedAt time.Time      `gorm:"not null" json:"fetched_at"`
+}
+
+func (CatalogEntry) TableName() string {
+	return "catalog_cache"
+}
+
+// CatalogSyncResult - итог принудительной синхронизации справочников.
+type CatalogSyncResult struct {
+	Refreshed int `json:"refreshed"`
+	// Failed - ключи, которые не удалось обновить, для них остаются прежние данные.
+	Failed []string `json:"failed"`
+	// Disciplines - сколько дисциплин сохранено в локальный справочник.
+	Disciplines int `json:"disciplines"`
+}
+
+type CatalogRepository interface {
+	CatalogEntry(ctx context.Context, key string) (*CatalogEntry, error)
+	SaveCatalogEntry(ctx context.Context, entry *CatalogEntry) error
+	// CatalogEntries возвращает записи без Payload.
+	CatalogEntries(ctx context.Context) ([]*CatalogEntry, error)
+}
@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const DefaultThreadTitle = "New chat"
+
+// ChatThread - отдельный разговор с LLM. Может быть привязан к дисциплине
+// и блоку её роадмапа, тогда LLM-сервис получает этот контекст вместе с ID треда.
+type ChatThread struct {
+	ID            uuid.UUID     `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID        uuid.UUID     `gorm:"type:uuid;not null;index" json:"-"`
+	Title         string        `gorm:"size:200;not null" json:"title"`
+	DisciplineID  *int          `gorm:"index" json:"discipline_id"`
+	Discipline    *Discipline   `gorm:"foreignKey:DisciplineID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"-"`
+	RoadmapBlock  string        `json:"roadmap_block,omitempty"`
+	ArchivedAt    *time.Time    `json:"archived_at"`
+	LastMessageAt *time.Time    `json:"last_message_at"`
+	CreatedAt     time.Time     `json:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at"`
+	Messages      []ChatMessage `gorm:"foreignKey:ThreadID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+type ChatThreadFilter struct {
+	Archived bool
+	Limit    int
+	Offset   int
+}
+
+// ChatThreadUpdate - изменяемые поля треда, nil означает "не менять".
+type ChatThreadUpdate struct {
+	Title    *string
+	Archived *bool
+}
@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+var ErrUnknownDiscipline = Validation("unknown_discipline", "unknown discipline")
+
+// Faculty, Direction и Discipline - локальная копия справочника парсера, ID берутся из него.
+// Синхронизация записи только добавляет и обновляет: на дисциплины ссылаются отчёты и тесты.
+type Faculty struct {
+	ID        string    `gorm:"primaryKey" json:"id"`
+	Name      string    `gorm:"not null" json:"name"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type Direction struct {
+	ID        string    `gorm:"primaryKey" json:"id"`
+	FacultyID string    `gorm:"not null;index" json:"faculty_id"`
+	Faculty   *Faculty  `gorm:"foreignKey:FacultyID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Name      string    `gorm:"not null" json:"name"`
+	Link      string    `json:"link"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type Discipline struct {
+	ID         int         `gorm:"primaryKey;autoIncrement:false" json:"id"`
+	Name       string      `gorm:"not null" json:"name"`
+	Link       string      `json:"link"`
+	Directions []Direction `gorm:"many2many:direction_disciplines;" json:"directions,omitempty"`
+	UpdatedAt  time.Time   `json:"updated_at"`
+}
+
+type DirectionDiscipline struct {
+	DirectionID  string `gorm:"primaryKey"`
+	DisciplineID int    `gorm:"primaryKey;autoIncrement:false"`
+}
+
+// CatalogSnapshot - справочник, собранный из ответов парсера. LoadedDirections -
+// направления, список дисциплин которых загружен полностью: их связи заменяются на Links.
+type CatalogSnapshot struct {
+	Faculties        []Faculty
+	Directions       []Direction
+	Disciplines      []Discipline
+	Links            []DirectionDiscipline
+	LoadedDirections []string
+}
+
+type DisciplineFilter struct {
+	FacultyID   string
+	DirectionID string
+	Query       string
+}
+
+type DisciplineInteractor interface {
+	Discipline(ctx context.Context, id int) (*Discipline, error)
+	Disciplines(ctx context.Context, filter DisciplineFilter) ([]*Discipline, error)
+}
+
+type DisciplineRepository interface {
+	Discipline(ctx context.Context, id int) (*Discipline, error)
+	Disciplines(ctx context.Context, filter DisciplineFilter) ([]*Discipline, error)
+	SaveCatalog(ctx context.Context, snapshot *CatalogSnapshot) error
+}
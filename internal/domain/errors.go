@@ -0,0 +1,107 @@
+package domain
+
+import "time"
+
+// ErrorKind - класс ошибки, по нему выбирается HTTP статус.
+type ErrorKind string
+
+const (
+	KindValidation   ErrorKind = "validation"
+	KindUnauthorized ErrorKind = "unauthorized"
+	KindForbidden    ErrorKind = "forbidden"
+	KindNotFound     ErrorKind = "not_found"
+	KindConflict     ErrorKind = "conflict"
+	KindRateLimited  ErrorKind = "rate_limited"
+	KindUpstream     ErrorKind = "upstream_unavailable"
+	KindInternal     ErrorKind = "internal"
+)
+
+// Error - ошибка из каталога. Code стабилен и предназначен для клиентов,
+// Message можно показывать пользователю, Err - внутренняя причина, наружу не отдаётся.
+type Error struct {
+	Kind    ErrorKind
+	Code    string
+	Message string
+	// Fields - ошибки валидации по полям запроса.
+	Fields map[string]string
+	// RetryAfter - через сколько можно повторить запрос, уходит в заголовок Retry-After.
+	RetryAfter time.Duration
+	Err        error
+}
+
+func NewError(kind ErrorKind, code string, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func Validation(code string, message string) *Error {
+	return NewError(KindValidation, code, message)
+}
+
+func Unauthorized(code string, message string) *Error {
+	return NewError(KindUnauthorized, code, message)
+}
+
+func Forbidden(code string, message string) *Error {
+	return NewError(KindForbidden, code, message)
+}
+
+func NotFound(code string, message string) *Error {
+	return NewError(KindNotFound, code, message)
+}
+
+func Conflict(code string, message string) *Error {
+	return NewError(KindConflict, code, message)
+}
+
+func RateLimited(code string, message string) *Error {
+	return NewError(KindRateLimited, code, message)
+}
+
+func Upstream(code string, message string) *Error {
+	return NewError(KindUpstream, code, message)
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is сравнивает ошибки по коду, поэтому errors.Is(err, ErrX) срабатывает
+// и для копий, созданных через Wrap или WithFields.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// Wrap возвращает копию ошибки с внутренней причиной.
+func (e *Error) Wrap(err error) *Error {
+	c := *e
+	c.Err = err
+	return &c
+}
+
+// WithFields возвращает копию ошибки с ошибками по полям.
+func (e *Error) WithFields(fields map[string]string) *Error {
+	c := *e
+	c.Fields = fields
+	return &c
+}
+
+// WithRetryAfter возвращает копию ошибки со временем до повторной попытки.
+func (e *Error) WithRetryAfter(d time.Duration) *Error {
+	c := *e
+	c.RetryAfter = d
+	return &c
+}
+
+var (
+	ErrNotFound = NotFound("not_found", "resource not found")
+	ErrConflict = Conflict("conflict", "resource already exists")
+	ErrInternal = NewError(KindInternal, "internal", "internal server error")
+)
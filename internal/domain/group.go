@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Group - учебная группа. Пользователи и отчёты ссылаются на неё по GroupID,
+// строковое поле Group у них хранит копию названия для отображения.
+type Group struct {
+	ID        uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	Name      string     `gorm:"size:50;uniqueIndex;not null" json:"name"`
+	Faculty   string     `json:"faculty"`
+	Direction string     `json:"direction"`
+	Year      int        `json:"year"`
+	CuratorID *uuid.UUID `gorm:"type:uuid;index" json:"curator_id"`
+	Curator   *User      `gorm:"foreignKey:CuratorID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"-"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type GroupFilter struct {
+	Faculty   string
+	Direction string
+	Year      int
+}
+
+type GroupInteractor interface {
+	Groups(ctx context.Context, filter GroupFilter) ([]*Group, error)
+	Group(ctx context.Context, id uuid.UUID) (*Group, error)
+	CreateGroup(ctx context.Context, group Group) (*Group, error)
+	UpdateGroup(ctx context.Context, group Group) (*Group, error)
+	DeleteGroup(ctx context.Context, id uuid.UUID) error
+}
+
+type GroupRepository interface {
+	Groups(ctx context.Context, filter GroupFilter) ([]*Group, error)
+	Group(ctx context.Context, id uuid.UUID) (*Group, error)
+	GroupByName(ctx context.Context, name string) (*Group, error)
+	CreateGroup(ctx context.Context, group *Group) error
+	UpdateGroup(ctx context.Context, group *Group) error
+	DeleteGroup(ctx context.Context, id uuid.UUID) error
+}
@@ -5,13 +5,33 @@ import (
 
 	"github.com/google/uuid"
 	"gorm.io/datatypes"
+	"gorm.io/gorm"
 )
 
-type History struct {
-	ID            uuid.UUID      `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
-	UserID        uuid.UUID      `gorm:"uniqueIndex"` // Внешний ключ (можно добавить индекс)
-	MessagesJSONB datatypes.JSON `gorm:"type:jsonb"`  // Весь чат как JSONB
-	CreatedAt     time.Time
+// ChatMessage - сообщение чата с LLM. ContentHash вместе с ThreadID, Role и SentAt
+// однозначно определяет сообщение, поэтому повторная выгрузка истории из
+// LLM-сервиса не создаёт дублей. Очищенные сообщения удаляются мягко, чтобы
+// они не вернулись при следующей выгрузке.
+type ChatMessage struct {
+	ID          uuid.UUID      `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID      uuid.UUID      `gorm:"type:uuid;not null" json:"-"`
+	ThreadID    uuid.UUID      `gorm:"type:uuid;not null" json:"thread_id"`
+	Role        string         `gorm:"size:32;not null" json:"role"`
+	Content     string         `gorm:"not null" json:"content"`
+	Metadata    datatypes.JSON `gorm:"type:jsonb" json:"metadata,omitempty"`
+	ContentHash string         `gorm:"size:64;not null" json:"-"`
+	SentAt      time.Time      `gorm:"not null" json:"sent_at"`
+	CreatedAt   time.Time      `json:"created_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// ChatFilter - поиск по сообщениям. uuid.Nil в ThreadID - по всем тредам пользователя.
+type ChatFilter struct {
+	ThreadID uuid.UUID
+	Query    string
+	Role     string
+	Limit    int
+	Offset   int
 }
 
 type SaveHistoryRequest struct {
@@ -24,4 +44,6 @@ type SaveHistoryRequest struct {
 		Timestamp        time.Time      `json:"timestamp"`
 	} `json:"history"`
 	UserID string `json:"user_id"`
+	// ThreadID пуст у старых версий LLM-сервиса, тогда история пишется в тред по умолчанию.
+	ThreadID string `json:"thread_id"`
 }
@@ -7,8 +7,37 @@ import (
 )
 
 type LLMInteractor interface {
-	SaveHistory(ctx context.Context, req SaveHistoryRequest, userID uuid.UUID) (uuid.UUID, error)
+	SaveHistory(ctx context.Context, req SaveHistoryRequest, userID uuid.UUID, threadID uuid.UUID) (int64, error)
+	ChatHistory(ctx context.Context, userID uuid.UUID, filter ChatFilter) ([]*ChatMessage, int64, error)
+	ExportHistory(ctx context.Context, userID uuid.UUID, threadID uuid.UUID) ([]*ChatMessage, error)
+	// ClearHistory очищает тред, а при uuid.Nil - все треды пользователя.
+	ClearHistory(ctx context.Context, userID uuid.UUID, threadID uuid.UUID) error
+
+	// ChatThread возвращает тред для нового сообщения: указанный или тред по умолчанию.
+	ChatThread(ctx context.Context, userID uuid.UUID, threadID uuid.UUID) (*ChatThread, error)
+	Threads(ctx context.Context, userID uuid.UUID, filter ChatThreadFilter) ([]*ChatThread, int64, error)
+	Thread(ctx context.Context, userID uuid.UUID, threadID uuid.UUID) (*ChatThread, error)
+	CreateThread(ctx context.Context, userID uuid.UUID, title string, disciplineID *int, roadmapBlock string) (*ChatThread, error)
+	UpdateThread(ctx context.Context, userID uuid.UUID, threadID uuid.UUID, update ChatThreadUpdate) (*ChatThread, error)
+	DeleteThread(ctx context.Context, userID uuid.UUID, threadID uuid.UUID) error
 }
 type LLMRepository interface {
-	SaveHistory(ctx context.Context, history *History) (uuid.UUID, error)
+	// SaveMessages сохраняет новые сообщения треда, уже известные пропускает. Возвращает число добавленных.
+	SaveMessages(ctx context.Context, threadID uuid.UUID, messages []ChatMessage) (int64, error)
+	// Messages возвращает страницу сообщений, от новых к старым.
+	Messages(ctx context.Context, userID uuid.UUID, filter ChatFilter) ([]*ChatMessage, int64, error)
+	// AllMessages возвращает историю в хронологическом порядке, uuid.Nil - всех тредов.
+	AllMessages(ctx context.Context, userID uuid.UUID, threadID uuid.UUID) ([]*ChatMessage, error)
+	DeleteMessages(ctx context.Context, userID uuid.UUID, threadID uuid.UUID) error
+
+	CreateThread(ctx context.Context, thread *ChatThread) error
+	// Thread ищет тред среди тредов пользователя.
+	Thread(ctx context.Context, userID uuid.UUID, threadID uuid.UUID) (*ChatThread, error)
+	// LatestThread - последний активный неархивный тред пользователя.
+	LatestThread(ctx context.Context, userID uuid.UUID) (*ChatThread, error)
+	Threads(ctx context.Context, userID uuid.UUID, filter ChatThreadFilter) ([]*ChatThread, int64, error)
+	// ThreadIDs - все треды пользователя, включая архивные.
+	ThreadIDs(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error)
+	UpdateThread(ctx context.Context, thread *ChatThread) error
+	DeleteThread(ctx context.Context, userID uuid.UUID, threadID uuid.UUID) error
 }
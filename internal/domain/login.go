@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Виды счётчиков неудачных входов.
+const (
+	LoginThrottleLogin = "login"
+	LoginThrottleIP    = "ip"
+)
+
+// Причины неуспешного входа в журнале.
+const (
+	LoginReasonInvalidCredentials = "invalid_credentials"
+	LoginReasonThrottled          = "throttled"
+	LoginReasonLocked             = "locked"
+	LoginReasonDisabled           = "disabled"
+)
+
+// LoginThrottle - счётчик неудачных входов подряд по логину или IP.
+// Счётчик начинается заново, если с последней ошибки прошло больше окна.
+type LoginThrottle struct {
+	Kind          string    `gorm:"size:16;primaryKey"`
+	Key           string    `gorm:"size:255;primaryKey"`
+	Failures      int       `gorm:"not null;default:0"`
+	LastFailureAt time.Time `gorm:"not null"`
+}
+
+// LoginEvent - запись журнала входов. UserID пуст, если логин не найден.
+type LoginEvent struct {
+	ID        uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID    *uuid.UUID `gorm:"type:uuid;index" json:"-"`
+	User      *User      `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Login     string     `gorm:"size:255;not null" json:"-"`
+	Success   bool       `gorm:"not null" json:"success"`
+	Reason    string     `gorm:"size:32" json:"reason,omitempty"`
+	IP        string     `json:"ip"`
+	UserAgent string     `json:"user_agent"`
+	CreatedAt time.Time  `gorm:"index" json:"created_at"`
+}
+
+type LoginEventFilter struct {
+	Limit  int
+	Offset int
+}
+
+type LoginRepository interface {
+	// RecordFailure увеличивает счётчик и возвращает его новое значение.
+	// Ошибки до windowStart не учитываются.
+	RecordFailure(ctx context.Context, kind string, key string, windowStart time.Time) (int, error)
+	Throttle(ctx context.Context, kind string, key string) (*LoginThrottle, error)
+	ResetFailures(ctx context.Context, kind string, key string) error
+	LockUser(ctx context.Context, userID uuid.UUID, until *time.Time) error
+	WriteLoginEvent(ctx context.Context, event *LoginEvent) error
+	LoginEvents(ctx context.Context, userID uuid.UUID, filter LoginEventFilter) ([]*LoginEvent, int64, error)
+}
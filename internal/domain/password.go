@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PasswordResetToken - одноразовый токен сброса пароля. Сам токен уходит
+// пользователю письмом, в БД хранится только его хэш.
+type PasswordResetToken struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	UserID    uuid.UUID `gorm:"type:uuid;index;not null"`
+	User      *User     `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	TokenHash string    `gorm:"size:64;uniqueIndex;not null"`
+	ExpiresAt time.Time `gorm:"not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+type PasswordInteractor interface {
+	ChangePassword(ctx context.Context, userID uuid.UUID, oldPass string, newPass string) error
+	RequestReset(ctx context.Context, login string) error
+	Reset(ctx context.Context, token string, newPass string) error
+}
+
+type PasswordResetRepository interface {
+	// CreateResetToken сохраняет токен, прежние токены пользователя удаляются.
+	CreateResetToken(ctx context.Context, token *PasswordResetToken) error
+	ResetRequestedSince(ctx context.Context, userID uuid.UUID, since time.Time) (bool, error)
+	// UseResetToken атомарно помечает токен использованным. Если токена нет,
+	// он уже использован или истёк, возвращает gorm.ErrRecordNotFound.
+	UseResetToken(ctx context.Context, tokenHash string) (*PasswordResetToken, error)
+	DeleteResetTokens(ctx context.Context, userID uuid.UUID) error
+}
@@ -0,0 +1,21 @@
+package domain
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// ProfileUpdate - изменяемые поля профиля. nil означает "не менять",
+// uuid.Nil в GroupID - выйти из группы, пустой Email - удалить почту.
+type ProfileUpdate struct {
+	Faculty   *string
+	Direction *string
+	GroupID   *uuid.UUID
+	Email     *string
+}
+
+type ProfileInteractor interface {
+	Profile(ctx context.Context, userID uuid.UUID) (*User, error)
+	UpdateProfile(ctx context.Context, userID uuid.UUID, update ProfileUpdate) (*User, error)
+}
@@ -18,8 +18,11 @@ type TimelineStat struct {
 type Report struct {
 	ID              uuid.UUID      `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
 	DisciplineTitle string         `gorm:"not null"`
-	DisciplineID    int            `gorm:"not null"`
+	DisciplineID    int            `gorm:"not null;index"`
+	Discipline      *Discipline    `gorm:"foreignKey:DisciplineID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;" json:"-"`
 	Group           string         `gorm:"not null"`
+	GroupID         *uuid.UUID     `gorm:"type:uuid;index"`
+	StudyGroup      *Group         `gorm:"foreignKey:GroupID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"-"`
 	UserID          uuid.UUID      `gorm:"type:uuid;index"`
 	DetailsJSONB    datatypes.JSON `gorm:"type:jsonb"`
 	CreatedAt       time.Time
@@ -31,12 +34,12 @@ type DisciplineResponse struct {
 }
 
 type ReportInteractor interface {
-	CreateReport(ctx context.Context, discplineID int, resultsJSONB datatypes.JSON, userID uuid.UUID, disciplineTitle string, group string) error
+	CreateReport(ctx context.Context, discplineID int, resultsJSONB datatypes.JSON, userID uuid.UUID, groupID *uuid.UUID, group string) error
 	Report(ctx context.Context, reportID uuid.UUID) (*Report, error)
 	ReportsByDisciplineID(ctx context.Context, disciplineID int) ([]*Report, error)
 	ReportDisciplines(ctx context.Context) ([]DisciplineResponse, error)
-	ReportGroups(ctx context.Context, disciplineName string) ([]string, error)
-	ReportsByGroupAndDiscipline(ctx context.Context, disciplineName, group string) ([]*Report, *TimelineStat, error)
+	ReportGroups(ctx context.Context, disciplineID int) ([]*Group, error)
+	ReportsByGroupAndDiscipline(ctx context.Context, disciplineID int, groupID uuid.UUID) ([]*Report, *TimelineStat, error)
 }
 type ReportRepository interface {
 	CreateReport(ctx context.Context, report Report) error
@@ -45,6 +48,6 @@ type ReportRepository interface {
 	ReportsByDisciplineID(ctx context.Context, disciplineID int) ([]*Report, error)
 	ReportByUserAndDisciplineIDs(ctx context.Context, disciplineID int, userID uuid.UUID) (*Report, error)
 	ReportDisciplines(ctx context.Context) ([]DisciplineResponse, error)
-	ReportGroups(ctx context.Context, disciplineName string) ([]string, error)
-	ReportsByGroupAndDiscipline(ctx context.Context, disciplineName string, group string) ([]*Report, *TimelineStat, error)
+	ReportGroups(ctx context.Context, disciplineID int) ([]*Group, error)
+	ReportsByGroupAndDiscipline(ctx context.Context, disciplineID int, groupID uuid.UUID) ([]*Report, *TimelineStat, error)
 }
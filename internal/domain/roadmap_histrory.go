@@ -10,7 +10,8 @@ import (
 
 type RoadmapHistory struct {
 	ID           uuid.UUID      `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
-	DisciplineID int            `gorm:"not null"`
+	DisciplineID int            `gorm:"not null;index"`
+	Discipline   *Discipline    `gorm:"foreignKey:DisciplineID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;" json:"-"`
 	UserID       uuid.UUID      `gorm:"type:uuid;index"`
 	BlocksJSONB  datatypes.JSON `gorm:"type:jsonb"`
 	CreatedAt    time.Time
@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+const (
+	// ScopeChatHistoryWrite разрешает сервису записывать историю чата пользователей.
+	ScopeChatHistoryWrite = "chat_history:write"
+)
+
+var ServiceScopes = []string{ScopeChatHistoryWrite}
+
+// ServiceKey - ключ внутреннего сервиса. Запросы подписываются HMAC-SHA256 на секрете,
+// поэтому в Secret он хранится зашифрованным ключом из конфига, а не хэшем.
+// Открытый секрет отдаётся только при создании.
+type ServiceKey struct {
+	ID         uuid.UUID                   `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	Service    string                      `gorm:"size:64;not null" json:"service"`
+	Secret     string                      `gorm:"not null" json:"-"`
+	Scopes     datatypes.JSONSlice[string] `gorm:"type:jsonb;not null" json:"scopes"`
+	CreatedBy  *uuid.UUID                  `gorm:"type:uuid" json:"created_by"`
+	CreatedAt  time.Time                   `json:"created_at"`
+	LastUsedAt *time.Time                  `json:"last_used_at"`
+	RevokedAt  *time.Time                  `json:"revoked_at"`
+}
+
+// ServiceNonce - использованный nonce подписанного запроса. Хранится, пока
+// запрос с таким timestamp ещё может пройти проверку времени.
+type ServiceNonce struct {
+	KeyID     uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Nonce     string    `gorm:"size:64;primaryKey"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+}
+
+// SignedRequest - данные запроса, по которым проверяется подпись.
+type SignedRequest struct {
+	KeyID     uuid.UUID
+	Timestamp int64
+	Nonce     string
+	Signature string
+	Method    string
+	Path      string
+	Body      []byte
+}
+
+type ServiceKeyInteractor interface {
+	Authenticate(ctx context.Context, req SignedRequest, scope string) (*ServiceKey, error)
+	// CreateServiceKey возвращает ключ и секрет, секрет показывается только один раз.
+	CreateServiceKey(ctx context.Context, actorID uuid.UUID, service string, scopes []string) (*ServiceKey, string, error)
+	ServiceKeys(ctx context.Context) ([]*ServiceKey, error)
+	RevokeServiceKey(ctx context.Context, actorID uuid.UUID, id uuid.UUID) error
+}
+
+type ServiceKeyRepository interface {
+	CreateServiceKey(ctx context.Context, key *ServiceKey) error
+	ServiceKey(ctx context.Context, id uuid.UUID) (*ServiceKey, error)
+	ServiceKeys(ctx context.Context) ([]*ServiceKey, error)
+	RevokeServiceKey(ctx context.Context, id uuid.UUID, at time.Time) error
+	TouchServiceKey(ctx context.Context, id uuid.UUID, at time.Time) error
+	UpdateServiceKeySecret(ctx context.Context, id uuid.UUID, secret string) error
+	// UseNonce запоминает nonce. false - такой nonce уже был.
+	UseNonce(ctx context.Context, nonce *ServiceNonce) (bool, error)
+	PurgeNonces(ctx context.Context, before time.Time) (int64, error)
+}
@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RefreshSession хранит хэш refresh-токена. Сам токен в БД не попадает.
+type RefreshSession struct {
+	ID         uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	UserID     uuid.UUID `gorm:"type:uuid;index;not null"`
+	TokenHash  string    `gorm:"size:64;uniqueIndex;not null"`
+	UserAgent  string
+	IP         string
+	ExpiresAt  time.Time `gorm:"not null"`
+	RevokedAt  *time.Time
+	ReplacedBy *uuid.UUID `gorm:"type:uuid"`
+	CreatedAt  time.Time
+}
+
+// RevokedToken - access-токен (по jti), отозванный до истечения срока.
+type RevokedToken struct {
+	JTI       string    `gorm:"primaryKey;size:64"`
+	UserID    uuid.UUID `gorm:"type:uuid;index"`
+	ExpiresAt time.Time `gorm:"index;not null"`
+	CreatedAt time.Time
+}
+
+type TokenPair struct {
+	AccessToken      string    `json:"access_token"`
+	RefreshToken     string    `json:"refresh_token,omitempty"`
+	AccessExpiresAt  time.Time `json:"access_expires_at"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
+
+type SessionRepository interface {
+	CreateSession(ctx context.Context, session *RefreshSession) error
+	SessionByHash(ctx context.Context, tokenHash string) (*RefreshSession, error)
+	RotateSession(ctx context.Context, oldID uuid.UUID, next *RefreshSession) error
+	RevokeSession(ctx context.Context, sessionID uuid.UUID) error
+	// RevokeUserSessions отзывает все refresh-сессии пользователя и все уже выданные ему access-токены.
+	RevokeUserSessions(ctx context.Context, userID uuid.UUID) error
+	RevokeToken(ctx context.Context, token RevokedToken) error
+	TokenRevoked(ctx context.Context, jti string) (bool, error)
+	PurgeRevokedTokens(ctx context.Context, before time.Time) (int64, error)
+}
@@ -10,7 +10,8 @@ import (
 
 type TeacherTest struct {
 	ID           uuid.UUID      `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
-	DisciplineID int            `gorm:"not null"`
+	DisciplineID int            `gorm:"not null;index"`
+	Discipline   *Discipline    `gorm:"foreignKey:DisciplineID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;" json:"-"`
 	DetailsJSONB datatypes.JSON `gorm:"type:jsonb"`
 	Answers      datatypes.JSON `gorm:"type:jsonb"`
 	CreatedAt    time.Time
@@ -14,6 +14,7 @@ type RoadmapTest struct {
 	Status           string         `gorm:"size:50;default:'pending'"`
 	DetailsJSONB     datatypes.JSON `gorm:"type:jsonb"`
 	ResultsJSONB     datatypes.JSON `gorm:"type:jsonb"`
+	AnswersJSONB     datatypes.JSON `gorm:"type:jsonb" json:"-"` // Ключ ответов, никогда не отдаётся клиенту
 	IsFirst          bool           `gorm:"default:false"`
 	CreatedAt        time.Time
 	PassedAt         time.Time
@@ -33,9 +34,10 @@ type TestResult struct {
 	PassedAt     time.Time      `gorm:"column:passed_at"`
 }
 type TestInteractor interface {
-	CreateTest(ctx context.Context, generatedTestID uuid.UUID, detailsData datatypes.JSON, roadmapHistoryID uuid.UUID, isFirst bool) (*RoadmapTest, error)
+	CreateTest(ctx context.Context, generatedTestID uuid.UUID, detailsData datatypes.JSON, answers []string, roadmapHistoryID uuid.UUID, isFirst bool) (*RoadmapTest, error)
 	Answers(ctx context.Context, testID uuid.UUID, answers []string) ([]byte, error)
 	GetCorrectAnswers(ctx context.Context, testID uuid.UUID) ([]string, error)
+	FetchCorrectAnswers(ctx context.Context, testID uuid.UUID) ([]string, error)
 }
 
 type TestRepository interface {
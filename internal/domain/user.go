@@ -10,13 +10,19 @@ import (
 type User struct {
 	ID               uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
 	Login            string    `gorm:"unique;not null"`
-	PassHash         []byte    `gorm:"not null"`
+	PassHash         []byte    `gorm:"not null" json:"-"`
+	Email            *string   `gorm:"size:254;uniqueIndex"`
 	CreatedAt        time.Time
 	Faculty          string
 	Role             string `gorm:"default:'User';not null"`
 	Direction        string
 	Group            string
-	Histories        History          `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	GroupID          *uuid.UUID `gorm:"type:uuid;index"`
+	StudyGroup       *Group     `gorm:"foreignKey:GroupID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
+	Disabled         bool       `gorm:"default:false;not null"`
+	LockedUntil      *time.Time
+	TokensValidAfter *time.Time       `json:"-"`
+	ChatMessages     []ChatMessage    `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
 	RoadmapHistories []RoadmapHistory `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
 	Reports          []Report         `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
 }
@@ -25,14 +31,49 @@ type UserDTO struct {
 	Login string `json:"login"`
 	Pass  string `json:"password"`
 }
+
+// ClientInfo - данные клиента, с которого пришёл запрос.
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+type UserFilter struct {
+	Query  string
+	Role   string
+	Limit  int
+	Offset int
+}
+
 type UserInteractor interface {
-	CreateUser(ctx context.Context, login string, pass string, group string) (uuid.UUID, error)
-	Login(ctx context.Context, login string, passhash string) (string, error)
+	CreateUser(ctx context.Context, login string, pass string, groupID *uuid.UUID) (uuid.UUID, error)
+	Login(ctx context.Context, login string, passhash string, client ClientInfo) (*TokenPair, error)
+	Refresh(ctx context.Context, refreshToken string, client ClientInfo) (*TokenPair, error)
+	Logout(ctx context.Context, refreshToken string, accessJTI string, userID uuid.UUID, accessExp time.Time) error
+	TokenActive(ctx context.Context, userID uuid.UUID, jti string, issuedAt time.Time) (bool, error)
 	User(ctx context.Context, id uuid.UUID) (*User, error)
+	LoginEvents(ctx context.Context, userID uuid.UUID, filter LoginEventFilter) ([]*LoginEvent, int64, error)
+
+	Users(ctx context.Context, filter UserFilter) ([]*User, int64, error)
+	AdminCreateUser(ctx context.Context, actorID uuid.UUID, login string, pass string, role string, groupID *uuid.UUID) (uuid.UUID, error)
+	ChangeRole(ctx context.Context, actorID uuid.UUID, userID uuid.UUID, role string) error
+	SetDisabled(ctx context.Context, actorID uuid.UUID, userID uuid.UUID, disabled bool) error
+	DeleteUser(ctx context.Context, actorID uuid.UUID, userID uuid.UUID) error
+	ResetPassword(ctx context.Context, actorID uuid.UUID, userID uuid.UUID, newPass string) (string, error)
+	UnlockUser(ctx context.Context, actorID uuid.UUID, userID uuid.UUID) error
+	AuditLogs(ctx context.Context, filter AuditFilter) ([]*AuditLog, int64, error)
 }
 
 type UserRepository interface {
 	CreateUser(ctx context.Context, user *User) (uuid.UUID, error)
 	User(ctx context.Context, id uuid.UUID) (*User, error)
 	UserByLogin(ctx context.Context, login string) (*User, error)
+	UserByEmail(ctx context.Context, email string) (*User, error)
+	Users(ctx context.Context, filter UserFilter) ([]*User, int64, error)
+	UpdateRole(ctx context.Context, userID uuid.UUID, role string) error
+	SetDisabled(ctx context.Context, userID uuid.UUID, disabled bool) error
+	UpdatePassword(ctx context.Context, userID uuid.UUID, passHash []byte) error
+	// UpdateProfile сохраняет профиль, а при moveReports в той же транзакции переносит отчёты в новую группу.
+	UpdateProfile(ctx context.Context, user *User, moveReports bool) error
+	DeleteUser(ctx context.Context, userID uuid.UUID) error
 }
@@ -0,0 +1,148 @@
+// Package health проверяет доступность зависимостей сервиса
+// и запоминает результат последней проверки каждой из них.
+package health
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type CheckFunc func(ctx context.Context) error
+
+// DependencyStatus - результат последней проверки зависимости.
+type DependencyStatus struct {
+	Name        string     `json:"name"`
+	OK          bool       `json:"ok"`
+	Optional    bool       `json:"optional,omitempty"`
+	LatencyMS   int64      `json:"latency_ms"`
+	CheckedAt   time.Time  `json:"checked_at"`
+	LastError   string     `json:"last_error,omitempty"`
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+}
+
+type check struct {
+	name     string
+	fn       CheckFunc
+	optional bool
+}
+
+type Checker struct {
+	timeout time.Duration
+	checks  []check
+
+	mu     sync.RWMutex
+	status map[string]DependencyStatus
+}
+
+// New создаёт проверку, где каждая зависимость должна ответить за timeout.
+func New(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout, status: make(map[string]DependencyStatus)}
+}
+
+// Register добавляет зависимость. Вызывается до начала обслуживания запросов.
+func (c *Checker) Register(name string, fn CheckFunc) {
+	c.checks = append(c.checks, check{name: name, fn: fn})
+}
+
+// RegisterOptional добавляет зависимость, без которой сервис продолжает работать.
+// Она показывается в статусе, но не влияет на Healthy.
+func (c *Checker) RegisterOptional(name string, fn CheckFunc) {
+	c.checks = append(c.checks, check{name: name, fn: fn, optional: true})
+}
+
+// Check параллельно опрашивает все зависимости и возвращает их состояние
+// в порядке регистрации.
+func (c *Checker) Check(ctx context.Context) []DependencyStatus {
+	var wg sync.WaitGroup
+	for _, ch := range c.checks {
+		wg.Add(1)
+		go func(ch check) {
+			defer wg.Done()
+			c.run(ctx, ch)
+		}(ch)
+	}
+	wg.Wait()
+	return c.Status()
+}
+
+// Status возвращает результаты последних проверок без новых запросов.
+func (c *Checker) Status() []DependencyStatus {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	statuses := make([]DependencyStatus, 0, len(c.checks))
+	for _, ch := range c.checks {
+		status, ok := c.status[ch.name]
+		if !ok {
+			status = DependencyStatus{Name: ch.name, Optional: ch.optional}
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+func (c *Checker) run(ctx context.Context, ch check) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := withContext(ctx, ch.fn)
+	latency := time.Since(start)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	status := c.status[ch.name]
+	status.Name = ch.name
+	status.Optional = ch.optional
+	status.OK = err == nil
+	status.LatencyMS = latency.Milliseconds()
+	status.CheckedAt = start
+	if err != nil {
+		status.LastError = err.Error()
+		status.LastErrorAt = &start
+	}
+	c.status[ch.name] = status
+}
+
+// withContext не даёт проверке, которая игнорирует контекст, зависнуть дольше таймаута.
+func withContext(ctx context.Context, fn CheckFunc) error {
+	done := make(chan error, 1)
+	go func() { done <- fn(ctx) }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Healthy сообщает, что все обязательные зависимости прошли проверку.
+func Healthy(statuses []DependencyStatus) bool {
+	for _, status := range statuses {
+		if !status.OK && !status.Optional {
+			return false
+		}
+	}
+	return true
+}
+
+// HTTPCheck считает сервис доступным, если он ответил на GET без 5xx.
+func HTTPCheck(client *http.Client, url string) CheckFunc {
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode >= http.StatusInternalServerError {
+			return fmt.Errorf("unexpected status %s", resp.Status)
+		}
+		return nil
+	}
+}
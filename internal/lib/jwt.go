@@ -3,22 +3,28 @@ package lib
 import (
 	"time"
 
-	"github.com/golang-jwt/jwt"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/immxrtalbeast/plandstu/internal/domain"
 )
 
-func NewToken(user *domain.User, duration time.Duration, secret string) (string, error) {
-	token := jwt.New(jwt.SigningMethodHS256)
+const TokenIssuer = "plandstu"
 
-	claims := token.Claims.(jwt.MapClaims)
-	claims["uid"] = user.ID
-	claims["login"] = user.Login
-	claims["exp"] = time.Now().Add(duration).Unix()
-	claims["role"] = user.Role
+func NewToken(user *domain.User, duration time.Duration, keys *KeySet) (string, error) {
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"uid":   user.ID,
+		"login": user.Login,
+		"iss":   TokenIssuer,
+		"iat":   now.Unix(),
+		"exp":   now.Add(duration).Unix(),
+		"role":  user.Role,
+		"jti":   uuid.NewString(),
+	}
 
-	tokenString, err := token.SignedString([]byte(secret))
+	tokenString, err := keys.Sign(claims)
 	if err != nil {
-		return "", nil
+		return "", err
 	}
 	return tokenString, nil
 }
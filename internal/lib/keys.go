@@ -0,0 +1,242 @@
+package lib
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var ErrUnknownKey = errors.New("unknown signing key")
+
+// SigningKey - ключ подписи JWT. Private == nil означает, что ключ
+// используется только для проверки (выведен из ротации).
+type SigningKey struct {
+	KID     string
+	Method  jwt.SigningMethod
+	Private crypto.Signer
+	Public  crypto.PublicKey
+}
+
+// KeySet хранит все действующие ключи. Подписывает активный ключ,
+// проверка идёт по kid из заголовка токена.
+type KeySet struct {
+	active string
+	keys   map[string]*SigningKey
+}
+
+// LoadKeySet читает ключи из каталога: каждый файл <kid>.pem содержит
+// PKCS#8 приватный ключ (RSA или Ed25519) либо PKIX публичный ключ.
+// Если activeKID пуст, активным становится последний по имени приватный ключ.
+func LoadKeySet(dir string, activeKID string) (*KeySet, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	ks := &KeySet{keys: make(map[string]*SigningKey)}
+	for _, file := range files {
+		kid := strings.TrimSuffix(filepath.Base(file), ".pem")
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("read key %s: %w", kid, err)
+		}
+		key, err := parseKey(kid, data)
+		if err != nil {
+			return nil, fmt.Errorf("parse key %s: %w", kid, err)
+		}
+		ks.keys[kid] = key
+		if activeKID == "" && key.Private != nil {
+			ks.active = kid
+		}
+	}
+	if activeKID != "" {
+		ks.active = activeKID
+	}
+	if err := ks.validate(); err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+// NewEphemeralKeySet создаёт одноразовый Ed25519 ключ. Только для локальной разработки:
+// после перезапуска все выданные токены становятся недействительными.
+func NewEphemeralKeySet() (*KeySet, error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	const kid = "ephemeral"
+	return &KeySet{
+		active: kid,
+		keys: map[string]*SigningKey{
+			kid: {KID: kid, Method: jwt.SigningMethodEdDSA, Private: priv, Public: pub},
+		},
+	}, nil
+}
+
+func (ks *KeySet) validate() error {
+	if len(ks.keys) == 0 {
+		return errors.New("no signing keys found")
+	}
+	key, ok := ks.keys[ks.active]
+	if !ok {
+		return fmt.Errorf("active key %q not found", ks.active)
+	}
+	if key.Private == nil {
+		return fmt.Errorf("active key %q has no private part", ks.active)
+	}
+	return nil
+}
+
+// Sign подписывает claims активным ключом и проставляет kid в заголовок.
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	key := ks.keys[ks.active]
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.KID
+	return token.SignedString(key.Private)
+}
+
+// Keyfunc возвращает публичный ключ по kid и проверяет, что алгоритм совпадает с ключом.
+func (ks *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKey, kid)
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.Public, nil
+}
+
+// Parse разбирает и проверяет токен, подписанный одним из ключей набора.
+// Токен должен быть выпущен этим сервисом и иметь срок действия.
+func (ks *KeySet) Parse(tokenString string) (*jwt.Token, error) {
+	return jwt.Parse(tokenString, ks.Keyfunc,
+		jwt.WithValidMethods(ks.methods()),
+		jwt.WithIssuer(TokenIssuer),
+		jwt.WithExpirationRequired(),
+	)
+}
+
+func (ks *KeySet) methods() []string {
+	seen := make(map[string]bool)
+	var methods []string
+	for _, key := range ks.keys {
+		alg := key.Method.Alg()
+		if !seen[alg] {
+			seen[alg] = true
+			methods = append(methods, alg)
+		}
+	}
+	return methods
+}
+
+// JWK - публичный ключ в формате RFC 7517.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS возвращает публичные части всех ключей, включая выведенные из ротации,
+// чтобы сервисы могли проверять ещё не истёкшие токены.
+func (ks *KeySet) JWKS() JWKS {
+	kids := make([]string, 0, len(ks.keys))
+	for kid := range ks.keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	set := JWKS{Keys: make([]JWK, 0, len(kids))}
+	for _, kid := range kids {
+		key := ks.keys[kid]
+		jwk := JWK{Kid: kid, Use: "sig", Alg: key.Method.Alg()}
+		switch pub := key.Public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+func parseKey(kid string, data []byte) (*SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	switch block.Type {
+	case "PRIVATE KEY":
+		priv, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := priv.(crypto.Signer)
+		if !ok {
+			return nil, errors.New("key is not a signer")
+		}
+		method, err := methodFor(signer.Public())
+		if err != nil {
+			return nil, err
+		}
+		return &SigningKey{KID: kid, Method: method, Private: signer, Public: signer.Public()}, nil
+	case "RSA PRIVATE KEY":
+		priv, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return &SigningKey{KID: kid, Method: jwt.SigningMethodRS256, Private: priv, Public: priv.Public()}, nil
+	case "PUBLIC KEY":
+		pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		method, err := methodFor(pub)
+		if err != nil {
+			return nil, err
+		}
+		return &SigningKey{KID: kid, Method: method, Public: pub}, nil
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+}
+
+func methodFor(pub crypto.PublicKey) (jwt.SigningMethod, error) {
+	switch pub.(type) {
+	case *rsa.PublicKey:
+		return jwt.SigningMethodRS256, nil
+	case ed25519.PublicKey:
+		return jwt.SigningMethodEdDSA, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", pub)
+	}
+}
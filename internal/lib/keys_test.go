@@ -0,0 +1,129 @@
+package lib
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestKeySetParse(t *testing.T) {
+	edPub, edPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaPriv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, foreignPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaPubDER, err := x509.MarshalPKIXPublicKey(rsaPriv.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ks := &KeySet{
+		active: "ed",
+		keys: map[string]*SigningKey{
+			"ed":  {KID: "ed", Method: jwt.SigningMethodEdDSA, Private: edPriv, Public: edPub},
+			"rsa": {KID: "rsa", Method: jwt.SigningMethodRS256, Public: rsaPriv.Public()},
+		},
+	}
+
+	valid := func() jwt.MapClaims {
+		return jwt.MapClaims{"iss": TokenIssuer, "exp": time.Now().Add(time.Minute).Unix()}
+	}
+	sign := func(method jwt.SigningMethod, key any, kid string, claims jwt.MapClaims) string {
+		token := jwt.NewWithClaims(method, claims)
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+		s, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{
+			name:  "active key",
+			token: sign(jwt.SigningMethodEdDSA, crypto.Signer(edPriv), "ed", valid()),
+		},
+		{
+			name:  "retired rsa key",
+			token: sign(jwt.SigningMethodRS256, rsaPriv, "rsa", valid()),
+		},
+		{
+			name:    "unknown kid",
+			token:   sign(jwt.SigningMethodEdDSA, crypto.Signer(edPriv), "old", valid()),
+			wantErr: ErrUnknownKey,
+		},
+		{
+			name:    "missing kid",
+			token:   sign(jwt.SigningMethodEdDSA, crypto.Signer(edPriv), "", valid()),
+			wantErr: ErrUnknownKey,
+		},
+		{
+			name:    "alg does not match kid",
+			token:   sign(jwt.SigningMethodEdDSA, crypto.Signer(edPriv), "rsa", valid()),
+			wantErr: jwt.ErrTokenUnverifiable,
+		},
+		{
+			name:    "hmac with public key as secret",
+			token:   sign(jwt.SigningMethodHS256, rsaPubDER, "rsa", valid()),
+			wantErr: jwt.ErrTokenSignatureInvalid,
+		},
+		{
+			name:    "alg none",
+			token:   sign(jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, "ed", valid()),
+			wantErr: jwt.ErrTokenSignatureInvalid,
+		},
+		{
+			name:    "foreign key with known kid",
+			token:   sign(jwt.SigningMethodEdDSA, crypto.Signer(foreignPriv), "ed", valid()),
+			wantErr: jwt.ErrTokenSignatureInvalid,
+		},
+		{
+			name:    "wrong issuer",
+			token:   sign(jwt.SigningMethodEdDSA, crypto.Signer(edPriv), "ed", jwt.MapClaims{"iss": "other", "exp": time.Now().Add(time.Minute).Unix()}),
+			wantErr: jwt.ErrTokenInvalidIssuer,
+		},
+		{
+			name:    "missing exp",
+			token:   sign(jwt.SigningMethodEdDSA, crypto.Signer(edPriv), "ed", jwt.MapClaims{"iss": TokenIssuer}),
+			wantErr: jwt.ErrTokenRequiredClaimMissing,
+		},
+		{
+			name:    "expired",
+			token:   sign(jwt.SigningMethodEdDSA, crypto.Signer(edPriv), "ed", jwt.MapClaims{"iss": TokenIssuer, "exp": time.Now().Add(-time.Minute).Unix()}),
+			wantErr: jwt.ErrTokenExpired,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := ks.Parse(tt.token)
+			if tt.wantErr == nil {
+				if err != nil || !token.Valid {
+					t.Fatalf("Parse() error = %v, want valid token", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Parse() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package lib
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const sealedPrefix = "v1."
+
+var (
+	ErrUnknownSecretKey = errors.New("secret is sealed with an unknown key")
+	ErrNotSealed        = errors.New("secret is not sealed")
+)
+
+// SecretBox шифрует секреты для хранения в базе (AES-256-GCM). Зашифрованное
+// значение имеет вид "v1.<kid>.<base64(nonce|ciphertext)>", kid - начало
+// SHA-256 ключа. Шифрует активный ключ, расшифровывать можно и старыми.
+type SecretBox struct {
+	active string
+	aeads  map[string]cipher.AEAD
+}
+
+// NewSecretBox принимает ключи по 32 байта в base64: активный и прежние.
+func NewSecretBox(active string, old ...string) (*SecretBox, error) {
+	b := &SecretBox{aeads: make(map[string]cipher.AEAD)}
+	for i, encoded := range append([]string{active}, old...) {
+		kid, aead, err := newAEAD(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("secret key %d: %w", i, err)
+		}
+		if i == 0 {
+			b.active = kid
+		}
+		b.aeads[kid] = aead
+	}
+	return b, nil
+}
+
+func newAEAD(encoded string) (string, cipher.AEAD, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(key) != 32 {
+		return "", nil, fmt.Errorf("key must be 32 bytes, got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", nil, err
+	}
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:4]), aead, nil
+}
+
+// Seal шифрует секрет активным ключом. aad привязывает шифротекст к записи:
+// расшифровать его можно только с тем же aad.
+func (b *SecretBox) Seal(plaintext string, aad string) (string, error) {
+	aead := b.aeads[b.active]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), []byte(aad))
+	return sealedPrefix + b.active + "." + base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// Open расшифровывает значение, полученное от Seal.
+func (b *SecretBox) Open(sealed string, aad string) (string, error) {
+	if !IsSealed(sealed) {
+		return "", ErrNotSealed
+	}
+	kid, payload, _ := strings.Cut(strings.TrimPrefix(sealed, sealedPrefix), ".")
+	aead, ok := b.aeads[kid]
+	if !ok {
+		return "", fmt.Errorf("%w: %q", ErrUnknownSecretKey, kid)
+	}
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil || len(data) < aead.NonceSize() {
+		return "", errors.New("malformed sealed secret")
+	}
+	plaintext, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], []byte(aad))
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// Current сообщает, что значение зашифровано активным ключом.
+func (b *SecretBox) Current(sealed string) bool {
+	return strings.HasPrefix(sealed, sealedPrefix+b.active+".")
+}
+
+// IsSealed отличает зашифрованное значение от секрета, сохранённого до
+// шифрования: в секретах из NewServiceSecret нет точек.
+func IsSealed(value string) bool {
+	return strings.HasPrefix(value, sealedPrefix)
+}
@@ -0,0 +1,87 @@
+package lib
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func TestSecretBox(t *testing.T) {
+	newKey := func() string {
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			t.Fatal(err)
+		}
+		return base64.StdEncoding.EncodeToString(key)
+	}
+	oldKey, activeKey, foreignKey := newKey(), newKey(), newKey()
+	box, err := NewSecretBox(activeKey, oldKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seal := func(key string, aad string) string {
+		b, err := NewSecretBox(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sealed, err := b.Seal("secret", aad)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sealed
+	}
+	sealed := seal(activeKey, "key-1")
+	// Меняем символ в середине шифротекста
+	mid := len(sealed) / 2
+	flipped := byte('A')
+	if sealed[mid] == 'A' {
+		flipped = 'B'
+	}
+	tampered := sealed[:mid] + string(flipped) + sealed[mid+1:]
+
+	tests := []struct {
+		name        string
+		sealed      string
+		aad         string
+		wantErr     error // nil и wantFail - любая ошибка
+		wantFail    bool
+		wantCurrent bool
+	}{
+		{name: "active key", sealed: sealed, aad: "key-1", wantCurrent: true},
+		{name: "old key", sealed: seal(oldKey, "key-1"), aad: "key-1"},
+		{name: "other record", sealed: sealed, aad: "key-2", wantFail: true, wantCurrent: true},
+		{name: "unknown key", sealed: seal(foreignKey, "key-1"), aad: "key-1", wantErr: ErrUnknownSecretKey},
+		{name: "plaintext", sealed: "c2VjcmV0", aad: "key-1", wantErr: ErrNotSealed},
+		{name: "tampered", sealed: tampered, aad: "key-1", wantFail: true, wantCurrent: true},
+		{name: "truncated", sealed: sealed[:len(sealed)-30], aad: "key-1", wantFail: true, wantCurrent: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := box.Current(tt.sealed); got != tt.wantCurrent {
+				t.Fatalf("Current() = %v, want %v", got, tt.wantCurrent)
+			}
+			got, err := box.Open(tt.sealed, tt.aad)
+			if tt.wantErr == nil && !tt.wantFail {
+				if err != nil || got != "secret" {
+					t.Fatalf("Open() = %q, %v, want secret", got, err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Open() = %q, want error", got)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Open() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewSecretBoxRejectsBadKeys(t *testing.T) {
+	for _, key := range []string{"", "not base64", base64.StdEncoding.EncodeToString(make([]byte, 16))} {
+		if _, err := NewSecretBox(key); err == nil {
+			t.Errorf("NewSecretBox(%q) succeeded, want error", key)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package lib
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strconv"
+	"strings"
+)
+
+// SignRequest подписывает запрос сервиса: HMAC-SHA256 на secret от строки
+// "METHOD\nPATH\nTIMESTAMP\nNONCE\nhex(sha256(body))", результат в hex.
+// PATH - путь вместе с query, как он пришёл в запросе.
+func SignRequest(secret string, method string, path string, timestamp int64, nonce string, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+	payload := strings.Join([]string{
+		strings.ToUpper(method),
+		path,
+		strconv.FormatInt(timestamp, 10),
+		nonce,
+		hex.EncodeToString(bodyHash[:]),
+	}, "\n")
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewServiceSecret возвращает случайный секрет для ключа сервиса.
+func NewServiceSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -0,0 +1,73 @@
+package llmclient
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/immxrtalbeast/plandstu/internal/logging"
+)
+
+// breaker - circuit breaker по подряд идущим ошибкам. После threshold ошибок
+// запросы отклоняются сразу на время openTimeout, затем пропускается один
+// пробный запрос: успех замыкает цепь, ошибка снова размыкает.
+type breaker struct {
+	threshold   int
+	openTimeout time.Duration
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func newBreaker(threshold int, openTimeout time.Duration) *breaker {
+	return &breaker{threshold: threshold, openTimeout: openTimeout}
+}
+
+func (b *breaker) allow() bool {
+	if b.threshold <= 0 {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures < b.threshold {
+		return true
+	}
+	if b.probing || time.Since(b.openedAt) < b.openTimeout {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+func (b *breaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = 0
+	b.probing = false
+}
+
+// release снимает пробный запрос без результата, например при отмене клиентом.
+func (b *breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+func (b *breaker) failure(ctx context.Context) {
+	if b.threshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	if b.failures >= b.threshold {
+		if !b.probing {
+			logging.FromContext(ctx).Warn("llm circuit breaker opened", slog.Int("failures", b.failures))
+		}
+		b.openedAt = time.Now()
+		b.probing = false
+	}
+}
@@ -0,0 +1,85 @@
+package llmclient
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	const (
+		allow   = "allow"
+		deny    = "deny"
+		fail    = "failure"
+		succeed = "success"
+		release = "release"
+		expire  = "expire" // прошло openTimeout с момента размыкания
+	)
+	tests := []struct {
+		name      string
+		threshold int
+		steps     []string
+	}{
+		{
+			name:      "closed below threshold",
+			threshold: 3,
+			steps:     []string{allow, fail, allow, fail, allow},
+		},
+		{
+			name:      "opens at threshold",
+			threshold: 2,
+			steps:     []string{fail, fail, deny, deny},
+		},
+		{
+			name:      "success resets failures",
+			threshold: 2,
+			steps:     []string{fail, succeed, fail, allow},
+		},
+		{
+			name:      "single probe after timeout",
+			threshold: 1,
+			steps:     []string{fail, deny, expire, allow, deny},
+		},
+		{
+			name:      "probe success closes",
+			threshold: 1,
+			steps:     []string{fail, expire, allow, succeed, allow, allow},
+		},
+		{
+			name:      "probe failure reopens",
+			threshold: 1,
+			steps:     []string{fail, expire, allow, fail, deny, expire, allow},
+		},
+		{
+			name:      "released probe can be retried",
+			threshold: 1,
+			steps:     []string{fail, expire, allow, release, allow},
+		},
+		{
+			name:      "disabled",
+			threshold: 0,
+			steps:     []string{fail, fail, fail, allow},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBreaker(tt.threshold, time.Minute)
+			for i, step := range tt.steps {
+				switch step {
+				case allow, deny:
+					if got := b.allow(); got != (step == allow) {
+						t.Fatalf("step %d: allow() = %v, want %v", i, got, step == allow)
+					}
+				case fail:
+					b.failure(context.Background())
+				case succeed:
+					b.success()
+				case release:
+					b.release()
+				case expire:
+					b.openedAt = b.openedAt.Add(-b.openTimeout)
+				}
+			}
+		})
+	}
+}
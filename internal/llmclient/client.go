@@ -0,0 +1,305 @@
+// Package llmclient - клиент Python-сервиса генерации тестов и чата.
+package llmclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/immxrtalbeast/plandstu/internal/domain"
+	"github.com/immxrtalbeast/plandstu/internal/metrics"
+)
+
+var (
+	// ErrUnavailable возвращается при любой ошибке обращения к сервису, причина лежит внутри.
+	ErrUnavailable = domain.Upstream("llm_unavailable", "llm service is unavailable")
+	ErrCircuitOpen = errors.New("circuit breaker is open")
+)
+
+// Client - операции LLM-сервиса. Реализации: HTTPClient и Fake.
+type Client interface {
+	// ExampleTest генерирует первый тест по темам синхронно.
+	ExampleTest(ctx context.Context, req TestRequest) (json.RawMessage, error)
+	// GenerateTest запускает полный сценарий генерации теста, может идти десятки минут.
+	GenerateTest(ctx context.Context, req TestRequest) (json.RawMessage, error)
+	CorrectAnswers(ctx context.Context, testID uuid.UUID) ([]string, error)
+	// ChatStream возвращает поток SSE, закрыть его должен вызывающий.
+	ChatStream(ctx context.Context, req ChatRequest) (io.ReadCloser, error)
+	History(ctx context.Context, userID uuid.UUID, threadID uuid.UUID) (json.RawMessage, error)
+	ClearHistory(ctx context.Context, userID uuid.UUID, threadID uuid.UUID) (json.RawMessage, error)
+}
+
+type TestRequest struct {
+	TestID uuid.UUID `json:"test_id"`
+	Themes []string  `json:"themes"`
+}
+
+// ChatRequest - сообщение в тред. DisciplineID и RoadmapBlock - контекст треда, если он задан.
+type ChatRequest struct {
+	UserID       uuid.UUID `json:"user_id"`
+	ThreadID     uuid.UUID `json:"thread_id"`
+	Prompt       string    `json:"prompt"`
+	DisciplineID *int      `json:"discipline_id,omitempty"`
+	RoadmapBlock string    `json:"roadmap_block,omitempty"`
+}
+
+type historyRequest struct {
+	UserID   uuid.UUID `json:"user_id"`
+	ThreadID uuid.UUID `json:"thread_id"`
+}
+
+type answersResponse struct {
+	Answers []string `json:"answers"`
+}
+
+// StatusError - сервис ответил статусом не из 2xx.
+type StatusError struct {
+	Status int
+	Body   string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("llm returned status %d: %s", e.Status, e.Body)
+}
+
+// operation описывает эндпоинт сервиса. Часть эндпоинтов принимает тело в GET-запросе,
+// так устроен сам сервис, поэтому метод задаётся здесь, а не в местах вызова.
+type operation struct {
+	name       string
+	method     string
+	path       string
+	timeout    time.Duration
+	idempotent bool
+}
+
+var (
+	opExample      = operation{name: "test_example", method: http.MethodGet, path: "test-exmpl/", timeout: 20 * time.Second}
+	opWorkflow     = operation{name: "test_workflow", method: http.MethodPost, path: "api/test-workflow", timeout: 60 * time.Minute}
+	opAnswers      = operation{name: "test_answers", method: http.MethodGet, path: "test-exmpl-answers/", timeout: 20 * time.Second, idempotent: true}
+	opChatStream   = operation{name: "chat_stream", method: http.MethodGet, path: "chat-stream/"}
+	opHistory      = operation{name: "get_history", method: http.MethodGet, path: "get_history/", timeout: 20 * time.Second, idempotent: true}
+	opClearHistory = operation{name: "clear_history", method: http.MethodGet, path: "clear_history/", timeout: 20 * time.Second, idempotent: true}
+)
+
+type Options struct {
+	// MaxAttempts - попыток для идемпотентных операций, включая первую.
+	MaxAttempts int
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+	// FailureThreshold подряд идущих ошибок размыкает цепь на OpenTimeout.
+	FailureThreshold int
+	OpenTimeout      time.Duration
+}
+
+func DefaultOptions() Options {
+	return Options{
+		MaxAttempts:      3,
+		BackoffBase:      200 * time.Millisecond,
+		BackoffMax:       2 * time.Second,
+		FailureThreshold: 5,
+		OpenTimeout:      30 * time.Second,
+	}
+}
+
+type HTTPClient struct {
+	baseURL string
+	http    *http.Client
+	opts    Options
+	breaker *breaker
+}
+
+var _ Client = (*HTTPClient)(nil)
+
+// New создаёт клиент. baseURL должен заканчиваться на "/".
+func New(baseURL string, opts Options) *HTTPClient {
+	if opts.MaxAttempts < 1 {
+		opts.MaxAttempts = 1
+	}
+	return &HTTPClient{
+		baseURL: baseURL,
+		// Таймауты задаются на операцию через контекст, у потока чата их нет
+		http:    metrics.HTTPClient("llm", "other", 0),
+		opts:    opts,
+		breaker: newBreaker(opts.FailureThreshold, opts.OpenTimeout),
+	}
+}
+
+func (c *HTTPClient) ExampleTest(ctx context.Context, req TestRequest) (json.RawMessage, error) {
+	return c.raw(ctx, opExample, "", req)
+}
+
+func (c *HTTPClient) GenerateTest(ctx context.Context, req TestRequest) (json.RawMessage, error) {
+	return c.raw(ctx, opWorkflow, "", req)
+}
+
+func (c *HTTPClient) CorrectAnswers(ctx context.Context, testID uuid.UUID) ([]string, error) {
+	data, err := c.raw(ctx, opAnswers, testID.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	var resp answersResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, ErrUnavailable.Wrap(fmt.Errorf("%s: decode response: %w", opAnswers.name, err))
+	}
+	return resp.Answers, nil
+}
+
+func (c *HTTPClient) History(ctx context.Context, userID uuid.UUID, threadID uuid.UUID) (json.RawMessage, error) {
+	return c.raw(ctx, opHistory, "", historyRequest{UserID: userID, ThreadID: threadID})
+}
+
+func (c *HTTPClient) ClearHistory(ctx context.Context, userID uuid.UUID, threadID uuid.UUID) (json.RawMessage, error) {
+	return c.raw(ctx, opClearHistory, "", historyRequest{UserID: userID, ThreadID: threadID})
+}
+
+// ChatStream не повторяется: часть ответа уже могла уйти клиенту.
+func (c *HTTPClient) ChatStream(ctx context.Context, req ChatRequest) (io.ReadCloser, error) {
+	op := opChatStream
+	if !c.breaker.allow() {
+		return nil, ErrUnavailable.Wrap(fmt.Errorf("%s: %w", op.name, ErrCircuitOpen))
+	}
+	resp, err := c.send(ctx, op, "", req)
+	c.record(ctx, err)
+	if err != nil {
+		return nil, ErrUnavailable.Wrap(fmt.Errorf("%s: %w", op.name, err))
+	}
+	return resp.Body, nil
+}
+
+// raw выполняет операцию и проверяет, что ответ - валидный JSON.
+func (c *HTTPClient) raw(ctx context.Context, op operation, suffix string, body any) (json.RawMessage, error) {
+	attempts := 1
+	if op.idempotent {
+		attempts = c.opts.MaxAttempts
+	}
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			if werr := sleep(ctx, c.backoff(attempt)); werr != nil {
+				break
+			}
+		}
+		if !c.breaker.allow() {
+			err = ErrCircuitOpen
+			break
+		}
+		var data []byte
+		data, err = c.do(ctx, op, suffix, body)
+		c.record(ctx, err)
+		if err == nil {
+			if !json.Valid(data) {
+				return nil, ErrUnavailable.Wrap(fmt.Errorf("%s: response is not valid json", op.name))
+			}
+			return data, nil
+		}
+		if !retryable(ctx, err) {
+			break
+		}
+	}
+	return nil, ErrUnavailable.Wrap(fmt.Errorf("%s: %w", op.name, err))
+}
+
+func (c *HTTPClient) do(ctx context.Context, op operation, suffix string, body any) ([]byte, error) {
+	if op.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, op.timeout)
+		defer cancel()
+	}
+	resp, err := c.send(ctx, op, suffix, body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+// send отправляет запрос и возвращает ответ со статусом 2xx.
+func (c *HTTPClient) send(ctx context.Context, op operation, suffix string, body any) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(metrics.WithEndpoint(ctx, op.name), op.method, c.baseURL+op.path+suffix, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "LLM/1.0")
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, &StatusError{Status: resp.StatusCode, Body: string(data)}
+	}
+	return resp, nil
+}
+
+// record учитывает результат в circuit breaker. Отмена запроса клиентом
+// и ошибки 4xx не считаются отказом сервиса.
+func (c *HTTPClient) record(ctx context.Context, err error) {
+	if err == nil {
+		c.breaker.success()
+		return
+	}
+	if ctx.Err() != nil {
+		c.breaker.release()
+		return
+	}
+	var se *StatusError
+	if errors.As(err, &se) && se.Status < 500 && se.Status != http.StatusTooManyRequests {
+		c.breaker.success()
+		return
+	}
+	c.breaker.failure(ctx)
+}
+
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var se *StatusError
+	if errors.As(err, &se) {
+		return se.Status >= 500 || se.Status == http.StatusTooManyRequests
+	}
+	return true
+}
+
+// backoff - экспоненциальная задержка с полным джиттером.
+func (c *HTTPClient) backoff(attempt int) time.Duration {
+	d := c.opts.BackoffBase << (attempt - 1)
+	if d <= 0 || d > c.opts.BackoffMax {
+		d = c.opts.BackoffMax
+	}
+	if d <= 0 {
+		return 0
+	}
+	return rand.N(d)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
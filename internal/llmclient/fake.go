@@ -0,0 +1,84 @@
+package llmclient
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// Fake - Client в памяти для тестов без Python-сервиса. Если Err задан,
+// все операции возвращают его. Calls хранит имена вызванных операций.
+type Fake struct {
+	Test        json.RawMessage
+	Answers     []string
+	Chat        string
+	ChatHistory json.RawMessage
+	Err         error
+
+	mu    sync.Mutex
+	Calls []string
+}
+
+var _ Client = (*Fake)(nil)
+
+func NewFake() *Fake {
+	return &Fake{
+		Test:        json.RawMessage(`{"test":[]}`),
+		Answers:     []string{},
+		Chat:        "data: ok\n\n",
+		ChatHistory: json.RawMessage(`{"history":[]}`),
+	}
+}
+
+func (f *Fake) call(name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Calls = append(f.Calls, name)
+	return f.Err
+}
+
+func (f *Fake) ExampleTest(ctx context.Context, req TestRequest) (json.RawMessage, error) {
+	if err := f.call(opExample.name); err != nil {
+		return nil, err
+	}
+	return f.Test, nil
+}
+
+func (f *Fake) GenerateTest(ctx context.Context, req TestRequest) (json.RawMessage, error) {
+	if err := f.call(opWorkflow.name); err != nil {
+		return nil, err
+	}
+	return f.Test, nil
+}
+
+func (f *Fake) CorrectAnswers(ctx context.Context, testID uuid.UUID) ([]string, error) {
+	if err := f.call(opAnswers.name); err != nil {
+		return nil, err
+	}
+	return f.Answers, nil
+}
+
+func (f *Fake) ChatStream(ctx context.Context, req ChatRequest) (io.ReadCloser, error) {
+	if err := f.call(opChatStream.name); err != nil {
+		return nil, err
+	}
+	return io.NopCloser(strings.NewReader(f.Chat)), nil
+}
+
+func (f *Fake) History(ctx context.Context, userID uuid.UUID, threadID uuid.UUID) (json.RawMessage, error) {
+	if err := f.call(opHistory.name); err != nil {
+		return nil, err
+	}
+	return f.ChatHistory, nil
+}
+
+func (f *Fake) ClearHistory(ctx context.Context, userID uuid.UUID, threadID uuid.UUID) (json.RawMessage, error) {
+	if err := f.call(opClearHistory.name); err != nil {
+		return nil, err
+	}
+	return f.ChatHistory, nil
+}
@@ -0,0 +1,72 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// GormLogger пишет ошибки и медленные запросы gorm в логгер из контекста,
+// поэтому у записей есть request_id и user_id запроса, который их вызвал.
+type GormLogger struct {
+	SlowThreshold time.Duration
+	level         logger.LogLevel
+}
+
+func NewGormLogger(slowThreshold time.Duration) *GormLogger {
+	return &GormLogger{SlowThreshold: slowThreshold, level: logger.Warn}
+}
+
+func (l *GormLogger) LogMode(level logger.LogLevel) logger.Interface {
+	copy := *l
+	copy.level = level
+	return &copy
+}
+
+func (l *GormLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= logger.Info {
+		FromContext(ctx).InfoContext(ctx, msg, slog.Any("args", args))
+	}
+}
+
+func (l *GormLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= logger.Warn {
+		FromContext(ctx).WarnContext(ctx, msg, slog.Any("args", args))
+	}
+}
+
+func (l *GormLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= logger.Error {
+		FromContext(ctx).ErrorContext(ctx, msg, slog.Any("args", args))
+	}
+}
+
+func (l *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if l.level <= logger.Silent {
+		return
+	}
+	elapsed := time.Since(begin)
+	switch {
+	case err != nil && l.level >= logger.Error && !errors.Is(err, gorm.ErrRecordNotFound):
+		sql, rows := fc()
+		FromContext(ctx).ErrorContext(ctx, "db query failed",
+			slog.String("sql", sql), slog.Int64("rows", rows), slog.Duration("elapsed", elapsed), Err(err))
+	case l.SlowThreshold > 0 && elapsed > l.SlowThreshold && l.level >= logger.Warn:
+		sql, rows := fc()
+		FromContext(ctx).WarnContext(ctx, "slow db query",
+			slog.String("sql", sql), slog.Int64("rows", rows), slog.Duration("elapsed", elapsed))
+	case l.level >= logger.Info:
+		sql, rows := fc()
+		FromContext(ctx).DebugContext(ctx, "db query",
+			slog.String("sql", sql), slog.Int64("rows", rows), slog.Duration("elapsed", elapsed))
+	}
+}
+
+// ParamsFilter убирает значения параметров из SQL в логах: там бывают хэши паролей и токенов.
+func (l *GormLogger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	return sql, nil
+}
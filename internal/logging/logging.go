@@ -0,0 +1,64 @@
+// Package logging переносит *slog.Logger и request ID через context.Context,
+// чтобы интеракторы и репозитории писали логи с атрибутами текущего запроса.
+package logging
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"gorm.io/gorm"
+)
+
+// RequestIDHeader - заголовок, в котором request ID приходит от клиента,
+// возвращается в ответе и передаётся во внешние сервисы.
+const RequestIDHeader = "X-Request-ID"
+
+type loggerKey struct{}
+type requestIDKey struct{}
+
+// WithLogger кладёт логгер в контекст.
+func WithLogger(ctx context.Context, log *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, log)
+}
+
+// FromContext возвращает логгер запроса или slog.Default, если его нет.
+func FromContext(ctx context.Context) *slog.Logger {
+	if log, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return log
+	}
+	return slog.Default()
+}
+
+// With добавляет атрибуты к логгеру в контексте.
+func With(ctx context.Context, args ...any) context.Context {
+	return WithLogger(ctx, FromContext(ctx).With(args...))
+}
+
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	ctx = context.WithValue(ctx, requestIDKey{}, requestID)
+	return With(ctx, slog.String("request_id", requestID))
+}
+
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+func Err(err error) slog.Attr {
+	return slog.String("error", err.Error())
+}
+
+// OnError пишет ошибку операции в лог запроса. Вызывается через defer
+// с указателем на именованный результат err.
+// Отсутствие записи - обычная ситуация, поэтому пишется как предупреждение.
+func OnError(ctx context.Context, op string, err *error) {
+	if *err == nil {
+		return
+	}
+	level := slog.LevelError
+	if errors.Is(*err, gorm.ErrRecordNotFound) {
+		level = slog.LevelWarn
+	}
+	FromContext(ctx).Log(ctx, level, "operation failed", slog.String("op", op), Err(*err))
+}
@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// TaskMiddleware замеряет время обработки задач asynq и считает повторы.
+func TaskMiddleware(next asynq.Handler) asynq.Handler {
+	return asynq.HandlerFunc(func(ctx context.Context, t *asynq.Task) error {
+		if retry, ok := asynq.GetRetryCount(ctx); ok && retry > 0 {
+			taskRetries.WithLabelValues(t.Type()).Inc()
+		}
+		start := time.Now()
+		err := next.ProcessTask(ctx, t)
+		taskDuration.WithLabelValues(t.Type()).Observe(time.Since(start).Seconds())
+		result := "ok"
+		if err != nil {
+			result = "error"
+		}
+		taskProcessed.WithLabelValues(t.Type(), result).Inc()
+		return err
+	})
+}
+
+var (
+	queueSizeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "queue", "tasks"),
+		"Tasks in the asynq queue by state.",
+		[]string{"queue", "state"}, nil,
+	)
+	queueLatencyDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "queue", "latency_seconds"),
+		"Age of the oldest pending task in the queue.",
+		[]string{"queue"}, nil,
+	)
+	queueScrapeErrorDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "queue", "scrape_error"),
+		"1 if the queue state could not be read from Redis.",
+		[]string{"queue"}, nil,
+	)
+)
+
+// QueueCollector читает состояние очередей asynq из Redis при каждом опросе /metrics.
+type QueueCollector struct {
+	inspector *asynq.Inspector
+	queues    []string
+}
+
+func NewQueueCollector(inspector *asynq.Inspector, queues ...string) *QueueCollector {
+	return &QueueCollector{inspector: inspector, queues: queues}
+}
+
+func (c *QueueCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- queueSizeDesc
+	ch <- queueLatencyDesc
+	ch <- queueScrapeErrorDesc
+}
+
+func (c *QueueCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, queue := range c.queues {
+		info, err := c.inspector.GetQueueInfo(queue)
+		if errors.Is(err, asynq.ErrQueueNotFound) {
+			// Очередь появляется в Redis только после первой задачи
+			info, err = &asynq.QueueInfo{Queue: queue}, nil
+		}
+		if err != nil {
+			ch <- prometheus.MustNewConstMetric(queueScrapeErrorDesc, prometheus.GaugeValue, 1, queue)
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(queueScrapeErrorDesc, prometheus.GaugeValue, 0, queue)
+		states := map[string]int{
+			"pending":   info.Pending,
+			"active":    info.Active,
+			"scheduled": info.Scheduled,
+			"retry":     info.Retry,
+			"archived":  info.Archived,
+			"completed": info.Completed,
+		}
+		for state, n := range states {
+			ch <- prometheus.MustNewConstMetric(queueSizeDesc, prometheus.GaugeValue, float64(n), queue, state)
+		}
+		ch <- prometheus.MustNewConstMetric(queueLatencyDesc, prometheus.GaugeValue, info.Latency.Seconds(), queue)
+	}
+}
@@ -0,0 +1,94 @@
+// Package metrics собирает метрики Prometheus: входящие HTTP запросы,
+// запросы к БД, обращения к внешним сервисам и очередь asynq.
+package metrics
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "plandstu"
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Incoming HTTP requests by route and status.",
+	}, []string{"method", "route", "status"})
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Incoming HTTP request latency by route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	dbQueries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "db_queries_total",
+		Help:      "Database queries by operation, table and result.",
+	}, []string{"operation", "table", "result"})
+	dbDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Database query latency by operation and table.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation", "table"})
+
+	outboundRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "outbound_requests_total",
+		Help:      "Requests to external services by endpoint and status.",
+	}, []string{"service", "endpoint", "status"})
+	outboundDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "outbound_request_duration_seconds",
+		Help:      "Latency of requests to external services until response headers.",
+		Buckets:   []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60, 120, 300},
+	}, []string{"service", "endpoint"})
+
+	taskProcessed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "task_processed_total",
+		Help:      "Processed background tasks by type and result.",
+	}, []string{"task", "result"})
+	taskRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "task_retries_total",
+		Help:      "Background task attempts that were retries of a failed attempt.",
+	}, []string{"task"})
+	taskDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "task_duration_seconds",
+		Help:      "Background task processing time by type.",
+		Buckets:   []float64{1, 5, 10, 30, 60, 120, 300, 600, 1200, 1800, 3600},
+	}, []string{"task"})
+
+	rateLimited = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limited_total",
+		Help:      "Requests rejected by rate limits and quotas by rule.",
+	}, []string{"rule"})
+
+	loginFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "login_failures_total",
+		Help:      "Rejected login attempts by reason.",
+	}, []string{"reason"})
+)
+
+// RateLimited учитывает запрос, отклонённый правилом rule.
+func RateLimited(rule string) {
+	rateLimited.WithLabelValues(rule).Inc()
+}
+
+// LoginFailed учитывает неудачную попытку входа.
+func LoginFailed(reason string) {
+	loginFailures.WithLabelValues(reason).Inc()
+}
+
+// Handler отдаёт метрики в формате Prometheus.
+func Handler() gin.HandlerFunc {
+	return gin.WrapH(promhttp.Handler())
+}
@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/immxrtalbeast/plandstu/internal/logging"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+type endpointKey struct{}
+
+// WithEndpoint задаёт имя эндпоинта для метрик запроса, сделанного клиентом из HTTPClient.
+func WithEndpoint(ctx context.Context, endpoint string) context.Context {
+	return context.WithValue(ctx, endpointKey{}, endpoint)
+}
+
+// Transport считает запросы к внешнему сервису. Эндпоинт берётся из контекста запроса,
+// иначе используется значение по умолчанию.
+type Transport struct {
+	Service  string
+	Endpoint string
+	Base     http.RoundTripper
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	endpoint := t.Endpoint
+	if e, ok := req.Context().Value(endpointKey{}).(string); ok {
+		endpoint = e
+	}
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	start := time.Now()
+	resp, err := base.RoundTrip(req)
+	outboundDuration.WithLabelValues(t.Service, endpoint).Observe(time.Since(start).Seconds())
+	status := "error"
+	if err == nil {
+		status = strconv.Itoa(resp.StatusCode)
+	}
+	outboundRequests.WithLabelValues(t.Service, endpoint, status).Inc()
+	return resp, err
+}
+
+// HTTPClient создаёт клиент с метриками и спанами OpenTelemetry, контекст трассировки
+// и request ID передаются в заголовках. timeout == 0 означает отсутствие таймаута, например для потоковых ответов.
+func HTTPClient(service, endpoint string, timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &Transport{
+			Service:  service,
+			Endpoint: endpoint,
+			Base: &logging.Transport{
+				Base: otelhttp.NewTransport(http.DefaultTransport,
+					otelhttp.WithSpanNameFormatter(func(_ string, req *http.Request) string {
+						return service + " " + req.Method + " " + req.URL.Path
+					}),
+				),
+			},
+		},
+	}
+}
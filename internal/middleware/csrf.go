@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/immxrtalbeast/plandstu/internal/domain"
+	"github.com/immxrtalbeast/plandstu/internal/problem"
+)
+
+const (
+	CSRFCookie = "csrf_token"
+	CSRFHeader = "X-CSRF-Token"
+)
+
+var errCSRFTokenInvalid = domain.Forbidden("csrf_token_invalid", "CSRF token is missing or does not match")
+
+// CSRF защищает запросы, авторизованные кукой, методом double submit: для
+// изменяющих методов заголовок X-CSRF-Token должен совпадать с кукой csrf_token.
+// Чужой сайт может заставить браузер отправить куки, но прочитать их не может.
+// Запросы без кук сессии или с заголовком Authorization не проверяются:
+// его браузер сам не подставляет.
+func CSRF(sessionCookies ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+		if c.GetHeader("Authorization") != "" || !hasCookie(c, sessionCookies) {
+			c.Next()
+			return
+		}
+		cookie, err := c.Cookie(CSRFCookie)
+		header := c.GetHeader(CSRFHeader)
+		if err != nil || cookie == "" || subtle.ConstantTimeCompare([]byte(cookie), []byte(header)) != 1 {
+			problem.Abort(c, errCSRFTokenInvalid)
+			return
+		}
+		c.Next()
+	}
+}
+
+func hasCookie(c *gin.Context, names []string) bool {
+	for _, name := range names {
+		if value, err := c.Cookie(name); err == nil && value != "" {
+			return true
+		}
+	}
+	return false
+}
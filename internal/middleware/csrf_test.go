@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCSRF(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name    string
+		method  string
+		cookies map[string]string
+		headers map[string]string
+		want    int
+	}{
+		{
+			name:    "safe method is not checked",
+			method:  http.MethodGet,
+			cookies: map[string]string{AccessCookie: "token"},
+			want:    http.StatusOK,
+		},
+		{
+			name:   "no session cookie",
+			method: http.MethodPost,
+			want:   http.StatusOK,
+		},
+		{
+			name:    "authorization header",
+			method:  http.MethodPost,
+			cookies: map[string]string{AccessCookie: "token"},
+			headers: map[string]string{"Authorization": "Bearer token"},
+			want:    http.StatusOK,
+		},
+		{
+			name:    "matching token",
+			method:  http.MethodPost,
+			cookies: map[string]string{AccessCookie: "token", CSRFCookie: "csrf"},
+			headers: map[string]string{CSRFHeader: "csrf"},
+			want:    http.StatusOK,
+		},
+		{
+			name:    "matching token on delete with refresh cookie",
+			method:  http.MethodDelete,
+			cookies: map[string]string{"refresh_token": "token", CSRFCookie: "csrf"},
+			headers: map[string]string{CSRFHeader: "csrf"},
+			want:    http.StatusOK,
+		},
+		{
+			name:    "missing header",
+			method:  http.MethodPost,
+			cookies: map[string]string{AccessCookie: "token", CSRFCookie: "csrf"},
+			want:    http.StatusForbidden,
+		},
+		{
+			name:    "missing cookie",
+			method:  http.MethodPut,
+			cookies: map[string]string{AccessCookie: "token"},
+			headers: map[string]string{CSRFHeader: "csrf"},
+			want:    http.StatusForbidden,
+		},
+		{
+			name:    "empty cookie and header",
+			method:  http.MethodPatch,
+			cookies: map[string]string{AccessCookie: "token", CSRFCookie: ""},
+			headers: map[string]string{CSRFHeader: ""},
+			want:    http.StatusForbidden,
+		},
+		{
+			name:    "mismatched token",
+			method:  http.MethodPost,
+			cookies: map[string]string{AccessCookie: "token", CSRFCookie: "csrf"},
+			headers: map[string]string{CSRFHeader: "other"},
+			want:    http.StatusForbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Handle(tt.method, "/", CSRF(AccessCookie, "refresh_token"), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+			req := httptest.NewRequest(tt.method, "/", nil)
+			for name, value := range tt.cookies {
+				req.AddCookie(&http.Cookie{Name: name, Value: value})
+			}
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d, body: %s", rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,13 @@
+package middleware
+
+import "github.com/gin-gonic/gin"
+
+// Deprecated помечает устаревший маршрут заголовками Deprecation и Link (RFC 9745),
+// чтобы клиенты видели, куда переходить. successor - путь маршрута на замену.
+func Deprecated(successor string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Deprecation", "true")
+		c.Header("Link", "<"+successor+`>; rel="successor-version"`)
+		c.Next()
+	}
+}
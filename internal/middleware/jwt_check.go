@@ -1,69 +1,124 @@
 package middleware
 
 import (
-	"fmt"
+	"context"
+	"errors"
+	"log/slog"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/immxrtalbeast/plandstu/internal/domain"
+	"github.com/immxrtalbeast/plandstu/internal/lib"
+	"github.com/immxrtalbeast/plandstu/internal/logging"
+	"github.com/immxrtalbeast/plandstu/internal/problem"
 )
 
-func AuthMiddleware(appSecret string) gin.HandlerFunc {
+// TokenChecker сообщает, принимается ли ещё access-токен. Подпись и срок токена
+// проверяет сам middleware, здесь - выход, отключение пользователя и отзыв
+// всех его токенов после смены роли или пароля.
+type TokenChecker interface {
+	TokenActive(ctx context.Context, userID uuid.UUID, jti string, issuedAt time.Time) (bool, error)
+}
+
+// AccessCookie - кука с access-токеном для браузерных клиентов.
+const AccessCookie = "jwt"
+
+var (
+	errTokenRequired = domain.Unauthorized("token_required", "bearer token is required")
+	errTokenInvalid  = domain.Unauthorized("token_invalid", "token is invalid")
+	errTokenExpired  = domain.Unauthorized("token_expired", "token has expired")
+	errTokenRevoked  = domain.Unauthorized("token_revoked", "token has been revoked")
+)
+
+func AuthMiddleware(keys *lib.KeySet, checker TokenChecker) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 		if authHeader == "" {
 			var err error
-			tokenString, err = c.Cookie("jwt")
+			tokenString, err = c.Cookie(AccessCookie)
 			if err != nil {
-				c.AbortWithStatusJSON(401, gin.H{"error": "JWT required"})
+				problem.Abort(c, errTokenRequired)
 				return
 			}
 		}
 
 		if tokenString == authHeader {
-			c.AbortWithStatusJSON(401, gin.H{"error": "Bearer token required"})
+			problem.Abort(c, errTokenRequired)
 			return
 		}
 
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			return []byte(appSecret), nil
-		})
-
+		token, err := keys.Parse(tokenString)
 		if err != nil {
-			c.AbortWithStatusJSON(401, gin.H{"error": "Invalid token: " + tokenString})
+			// Истёкший токен различаем, чтобы клиент знал, что нужно обновить его через /refresh
+			if errors.Is(err, jwt.ErrTokenExpired) {
+				problem.Abort(c, errTokenExpired.Wrap(err))
+				return
+			}
+			problem.Abort(c, errTokenInvalid.Wrap(err))
 			return
 		}
 
 		if !token.Valid {
-			c.AbortWithStatusJSON(401, gin.H{"error": "Invalid token"})
+			problem.Abort(c, errTokenInvalid)
 			return
 		}
 
 		claims, ok := token.Claims.(jwt.MapClaims)
 		if !ok {
-			c.AbortWithStatusJSON(401, gin.H{"error": "Invalid token claims"})
+			problem.Abort(c, errTokenInvalid)
 			return
 		}
 
 		if exp, ok := claims["exp"].(float64); ok {
 			if time.Now().Unix() > int64(exp) {
-				c.AbortWithStatusJSON(401, gin.H{"error": "Token expired"})
+				problem.Abort(c, errTokenExpired)
 				return
 			}
 		}
 
-		userID, ok := claims["uid"]
+		userID, ok := claims["uid"].(string)
+		if !ok {
+			problem.Abort(c, errTokenInvalid)
+			return
+		}
+		uid, err := uuid.Parse(userID)
+		if err != nil {
+			problem.Abort(c, errTokenInvalid.Wrap(err))
+			return
+		}
+		jti, _ := claims["jti"].(string)
+		iat, ok := claims["iat"].(float64)
 		if !ok {
-			c.AbortWithStatusJSON(401, gin.H{"error": "Invalid user ID in token"})
+			problem.Abort(c, errTokenInvalid)
 			return
 		}
 
+		active, err := checker.TokenActive(c, uid, jti, time.Unix(int64(iat), 0))
+		if err != nil {
+			problem.Abort(c, err)
+			return
+		}
+		if !active {
+			problem.Abort(c, errTokenRevoked)
+			return
+		}
+		if jti != "" {
+			c.Set("tokenID", jti)
+		}
+		if exp, ok := claims["exp"].(float64); ok {
+			c.Set("tokenExp", time.Unix(int64(exp), 0))
+		}
+
+		if role, ok := claims["role"].(string); ok {
+			c.Set("role", role)
+		}
+
 		c.Set("userID", userID)
+		c.Request = c.Request.WithContext(logging.With(c.Request.Context(), slog.Any("user_id", userID)))
 
 		c.Next()
 	}
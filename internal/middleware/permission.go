@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/immxrtalbeast/plandstu/internal/domain"
+	"github.com/immxrtalbeast/plandstu/internal/problem"
+)
+
+type PermissionChecker interface {
+	HasPermission(ctx context.Context, role string, permission string) (bool, error)
+}
+
+// RequirePermission пропускает запрос, только если роль из токена имеет все перечисленные права.
+// Должен стоять после AuthMiddleware.
+func RequirePermission(checker PermissionChecker, permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role, ok := c.Keys["role"].(string)
+		if !ok {
+			problem.Abort(c, domain.Forbidden("forbidden", "role is missing in token"))
+			return
+		}
+		for _, permission := range permissions {
+			allowed, err := checker.HasPermission(c, role, permission)
+			if err != nil {
+				problem.Abort(c, err)
+				return
+			}
+			if !allowed {
+				problem.Abort(c, domain.Forbidden("permission_required", "permission "+permission+" is required"))
+				return
+			}
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+	"github.com/immxrtalbeast/plandstu/internal/domain"
+	"github.com/immxrtalbeast/plandstu/internal/logging"
+	"github.com/immxrtalbeast/plandstu/internal/metrics"
+	"github.com/immxrtalbeast/plandstu/internal/problem"
+	"github.com/immxrtalbeast/plandstu/internal/ratelimit"
+)
+
+type RateLimiter interface {
+	Allow(ctx context.Context, rule ratelimit.Rule, subject string, limit int) (ratelimit.Decision, error)
+}
+
+var (
+	errRateLimited   = domain.RateLimited("rate_limited", "too many requests, try again later")
+	errQuotaExceeded = domain.RateLimited("quota_exceeded", "daily quota is exhausted")
+)
+
+// RateLimit проверяет правила по порядку для пользователя из токена, лимит
+// правила умножается на множитель его роли. Должен стоять после AuthMiddleware.
+// Если хранилище счётчиков недоступно, запрос пропускается.
+func RateLimit(limiter RateLimiter, multipliers map[string]int, rules ...ratelimit.Rule) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := c.Keys["userID"].(string)
+		if !ok {
+			problem.Abort(c, errTokenRequired)
+			return
+		}
+		role, _ := c.Keys["role"].(string)
+		if allow(c, limiter, userID, max(multipliers[role], 1), rules) {
+			c.Next()
+		}
+	}
+}
+
+// RateLimitByIP - лимиты для запросов без авторизации (вход, сброс пароля),
+// счётчик ведётся по IP клиента.
+func RateLimitByIP(limiter RateLimiter, rules ...ratelimit.Rule) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if allow(c, limiter, c.ClientIP(), 1, rules) {
+			c.Next()
+		}
+	}
+}
+
+// allow проверяет правила по порядку и при превышении прерывает запрос с 429.
+func allow(c *gin.Context, limiter RateLimiter, subject string, multiplier int, rules []ratelimit.Rule) bool {
+	for _, rule := range rules {
+		decision, err := limiter.Allow(c, rule, subject, rule.Limit*multiplier)
+		if err != nil {
+			logging.FromContext(c.Request.Context()).Warn("rate limit check failed", slog.String("rule", rule.Name), logging.Err(err))
+			continue
+		}
+		if decision.Allowed {
+			continue
+		}
+		metrics.RateLimited(rule.Name)
+		if rule.Quota {
+			problem.Abort(c, errQuotaExceeded.WithRetryAfter(decision.RetryAfter))
+			return false
+		}
+		problem.Abort(c, errRateLimited.WithRetryAfter(decision.RetryAfter))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"log/slog"
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/immxrtalbeast/plandstu/internal/logging"
+)
+
+// Чужой request ID принимаем, только если он не сломает логи и заголовки.
+var requestIDRegex = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+// RequestID берёт request ID из заголовка или создаёт новый, возвращает его в ответе
+// и кладёт в контекст запроса логгер с request_id.
+func RequestID(log *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(logging.RequestIDHeader)
+		if !requestIDRegex.MatchString(requestID) {
+			requestID = uuid.NewString()
+		}
+		c.Header(logging.RequestIDHeader, requestID)
+		c.Set("requestID", requestID)
+
+		ctx := logging.WithLogger(c.Request.Context(), log)
+		c.Request = c.Request.WithContext(logging.WithRequestID(ctx, requestID))
+		c.Next()
+	}
+}
+
+// AccessLog пишет строку на каждый запрос логгером запроса вместо стандартного логгера gin.
+func AccessLog() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		attrs := []any{
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.String("route", c.FullPath()),
+			slog.Int("status", status),
+			slog.Duration("latency", time.Since(start)),
+			slog.String("client_ip", c.ClientIP()),
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("errors", c.Errors.String()))
+		}
+		log := logging.FromContext(c.Request.Context())
+		switch {
+		case status >= 500:
+			log.Error("request completed", attrs...)
+		case status >= 400:
+			log.Warn("request completed", attrs...)
+		default:
+			log.Info("request completed", attrs...)
+		}
+	}
+}
@@ -1,61 +0,0 @@
-package middleware
-
-import (
-	"fmt"
-	"strings"
-
-	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
-)
-
-func TeacherMiddleware(appSecret string) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-		if authHeader == "" {
-			var err error
-			tokenString, err = c.Cookie("jwt")
-			if err != nil {
-				c.AbortWithStatusJSON(401, gin.H{"error": "JWT required"})
-				return
-			}
-		}
-
-		if tokenString == authHeader {
-			c.AbortWithStatusJSON(401, gin.H{"error": "Bearer token required"})
-			return
-		}
-
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			return []byte(appSecret), nil
-		})
-
-		if err != nil {
-			c.AbortWithStatusJSON(401, gin.H{"error": "Invalid token: " + tokenString})
-			return
-		}
-
-		if !token.Valid {
-			c.AbortWithStatusJSON(401, gin.H{"error": "Invalid token"})
-			return
-		}
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			c.AbortWithStatusJSON(401, gin.H{"error": "Invalid token claims"})
-			return
-		}
-		role, ok := claims["role"].(string)
-		if !ok {
-			c.AbortWithStatusJSON(401, gin.H{"error": "Invalid user ID in token"})
-			return
-		}
-		if role != "Teacher" {
-			c.AbortWithStatusJSON(401, gin.H{"error": "Not allowed. Your role is " + role})
-			return
-		}
-		c.Next()
-	}
-}
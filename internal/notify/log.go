@@ -0,0 +1,47 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/immxrtalbeast/plandstu/internal/logging"
+)
+
+// Log складывает письма в файл или, если путь не задан, в лог. Письма содержат
+// одноразовые токены, поэтому годится только для разработки.
+type Log struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewLog(path string) *Log {
+	return &Log{path: path}
+}
+
+func (l *Log) Send(ctx context.Context, msg Message) error {
+	if l.path == "" {
+		logging.FromContext(ctx).Info("notification",
+			slog.String("to", msg.To),
+			slog.String("subject", msg.Subject),
+			slog.String("body", msg.Body))
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("notify: open log file: %w", err)
+	}
+	_, err = fmt.Fprintf(f, "Date: %s\nTo: %s\nSubject: %s\n\n%s\n\n", time.Now().Format(time.RFC1123Z), msg.To, msg.Subject, msg.Body)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("notify: write log file: %w", err)
+	}
+	return nil
+}
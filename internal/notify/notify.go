@@ -0,0 +1,9 @@
+// Package notify доставляет письма пользователям.
+package notify
+
+// Message - письмо пользователю. Body - обычный текст.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
@@ -0,0 +1,54 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+)
+
+// SMTP отправляет письма через SMTP сервер. Если сервер поддерживает STARTTLS,
+// net/smtp включает его сам.
+type SMTP struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+// NewSMTP создаёт отправителя. Без username сервер используется без авторизации.
+func NewSMTP(host string, port int, username string, password string, from string) *SMTP {
+	s := &SMTP{addr: net.JoinHostPort(host, strconv.Itoa(port)), from: from}
+	if username != "" {
+		s.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return s
+}
+
+func (s *SMTP) Send(ctx context.Context, msg Message) error {
+	// Адрес попадает в заголовки, перевод строки позволил бы дописать свои
+	if strings.ContainsAny(msg.To, "\r\n") {
+		return errors.New("notify: invalid recipient address")
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", s.from)
+	fmt.Fprintf(&buf, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	buf.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+
+	// net/smtp не принимает контекст, поэтому отмену проверяем хотя бы перед отправкой
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := smtp.SendMail(s.addr, s.auth, s.from, []string{msg.To}, buf.Bytes()); err != nil {
+		return fmt.Errorf("notify: send mail: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package parser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/immxrtalbeast/plandstu/internal/domain"
+	"github.com/immxrtalbeast/plandstu/internal/metrics"
+)
+
+var (
+	ErrUnavailable = domain.Upstream("parser_unavailable", "parser service is unavailable")
+	ErrNotFound    = domain.NotFound("parser_not_found", "resource not found")
+)
+
+// Виды ресурсов парсера.
+const (
+	KindFaculties   = "faculties"
+	KindFaculty     = "faculty"
+	KindDisciplines = "disciplines"
+	KindRoadmap     = "roadmap"
+)
+
+// timeouts - таймауты запросов по видам ресурсов, они же имена эндпоинтов в метриках.
+var timeouts = map[string]time.Duration{
+	KindFaculties:   10 * time.Second,
+	KindFaculty:     10 * time.Second,
+	KindDisciplines: 20 * time.Second,
+	KindRoadmap:     40 * time.Second,
+}
+
+// Resource - ресурс парсера. Path однозначно его определяет и служит ключом кэша.
+type Resource struct {
+	Kind string
+	Path string
+}
+
+func FacultiesResource() Resource {
+	return Resource{Kind: KindFaculties, Path: "api/faculties/"}
+}
+
+func FacultyResource(id string) Resource {
+	return Resource{Kind: KindFaculty, Path: "api/faculties/" + url.PathEscape(id)}
+}
+
+func DisciplinesResource(direction string) Resource {
+	return Resource{Kind: KindDisciplines, Path: "api/get_disciplines/" + url.PathEscape(direction)}
+}
+
+func RoadmapResource(discipline string, link string) Resource {
+	return Resource{Kind: KindRoadmap, Path: "api/roadmaps/" + url.PathEscape(discipline) + "/" + url.PathEscape(link)}
+}
+
+// Client - клиент сервиса-парсера учебных планов.
+type Client struct {
+	baseURL string
+	http    *http.Client
+}
+
+func NewClient(baseURL string) *Client {
+	return &Client{
+		baseURL: baseURL,
+		// Таймауты задаются на ресурс через контекст
+		http: metrics.HTTPClient("parser", "other", 0),
+	}
+}
+
+// ID в ответах парсера бывает и числом, и строкой.
+type ID string
+
+func (id *ID) UnmarshalJSON(data []byte) error {
+	*id = ID(strings.Trim(string(data), `"`))
+	return nil
+}
+
+type Direction struct {
+	ID   ID     `json:"id"`
+	Name string `json:"name"`
+	Link string `json:"link"`
+}
+
+type Faculty struct {
+	ID         ID          `json:"id"`
+	Name       string      `json:"name"`
+	Directions []Direction `json:"directions"`
+}
+
+type Discipline struct {
+	ID   ID     `json:"id"`
+	Name string `json:"name"`
+	Link string `json:"link"`
+}
+
+// DecodeDisciplines разбирает ответ на DisciplinesResource: парсер отдаёт
+// либо массив, либо объект с полем disciplines.
+func DecodeDisciplines(data json.RawMessage, err error) ([]Discipline, error) {
+	if err != nil {
+		return nil, err
+	}
+	var wrapped struct {
+		Disciplines []Discipline `json:"disciplines"`
+	}
+	if err := json.Unmarshal(data, &wrapped); err == nil {
+		return wrapped.Disciplines, nil
+	}
+	return Decode[[]Discipline](data, nil)
+}
+
+func (c *Client) Faculties(ctx context.Context) ([]Faculty, error) {
+	return Decode[[]Faculty](c.Fetch(ctx, FacultiesResource()))
+}
+
+func (c *Client) Faculty(ctx context.Context, id string) (*Faculty, error) {
+	faculty, err := Decode[Faculty](c.Fetch(ctx, FacultyResource(id)))
+	if err != nil {
+		return nil, err
+	}
+	return &faculty, nil
+}
+
+// Disciplines и Roadmap отдаются в формате парсера без разбора.
+func (c *Client) Disciplines(ctx context.Context, direction string) (json.RawMessage, error) {
+	return c.Fetch(ctx, DisciplinesResource(direction))
+}
+
+func (c *Client) Roadmap(ctx context.Context, discipline string, link string) (json.RawMessage, error) {
+	return c.Fetch(ctx, RoadmapResource(discipline, link))
+}
+
+// Fetch загружает ресурс и проверяет, что ответ - валидный JSON.
+// Ошибки оборачиваются в ErrUnavailable, 404 парсера - в ErrNotFound.
+func (c *Client) Fetch(ctx context.Context, r Resource) (json.RawMessage, error) {
+	if timeout, ok := timeouts[r.Kind]; ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(metrics.WithEndpoint(ctx, r.Kind), "GET", c.baseURL+r.Path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "Parser/1.0")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, ErrUnavailable.Wrap(fmt.Errorf("parser request failed: %w", err))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound.Wrap(fmt.Errorf("parser returned 404 for %s", r.Path))
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, ErrUnavailable.Wrap(fmt.Errorf("parser returned status %d: %s", resp.StatusCode, body))
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, ErrUnavailable.Wrap(fmt.Errorf("failed to read parser response: %w", err))
+	}
+	if !json.Valid(data) {
+		return nil, ErrUnavailable.Wrap(fmt.Errorf("parser response for %s is not valid json", r.Path))
+	}
+	return data, nil
+}
+
+// Decode разбирает ответ Fetch в типизированную структуру.
+func Decode[T any](data json.RawMessage, err error) (T, error) {
+	var v T
+	if err != nil {
+		return v, err
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return v, ErrUnavailable.Wrap(fmt.Errorf("failed to decode parser response: %w", err))
+	}
+	return v, nil
+}
@@ -0,0 +1,125 @@
+// Package problem отдаёт ошибки API в формате RFC 7807 (application/problem+json).
+package problem
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/immxrtalbeast/plandstu/internal/domain"
+	"github.com/immxrtalbeast/plandstu/internal/logging"
+	"gorm.io/gorm"
+)
+
+const ContentType = "application/problem+json"
+
+// Details - тело ответа с ошибкой.
+type Details struct {
+	Type      string            `json:"type"`
+	Title     string            `json:"title"`
+	Status    int               `json:"status"`
+	Detail    string            `json:"detail,omitempty"`
+	Instance  string            `json:"instance,omitempty"`
+	Code      string            `json:"code"`
+	RequestID string            `json:"request_id,omitempty"`
+	Errors    map[string]string `json:"errors,omitempty"`
+}
+
+var statuses = map[domain.ErrorKind]int{
+	domain.KindValidation:   http.StatusBadRequest,
+	domain.KindUnauthorized: http.StatusUnauthorized,
+	domain.KindForbidden:    http.StatusForbidden,
+	domain.KindNotFound:     http.StatusNotFound,
+	domain.KindConflict:     http.StatusConflict,
+	domain.KindRateLimited:  http.StatusTooManyRequests,
+	domain.KindUpstream:     http.StatusBadGateway,
+	domain.KindInternal:     http.StatusInternalServerError,
+}
+
+// Abort прерывает обработку запроса и отвечает ошибкой. Ошибки вне каталога
+// превращаются в 500 без текста: он попадает только в лог.
+func Abort(ctx *gin.Context, err error) {
+	e := Classify(err)
+	status := Status(e)
+	log := logging.FromContext(ctx.Request.Context())
+	if status >= http.StatusInternalServerError {
+		log.Error("request failed", slog.String("code", e.Code), logging.Err(err))
+	} else {
+		log.Debug("request rejected", slog.String("code", e.Code), logging.Err(err))
+	}
+
+	body := Details{
+		Type:      "/problems/" + e.Code,
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    e.Message,
+		Instance:  ctx.Request.URL.Path,
+		Code:      e.Code,
+		RequestID: logging.RequestID(ctx.Request.Context()),
+		Errors:    e.Fields,
+	}
+	data, _ := json.Marshal(body)
+	if e.RetryAfter > 0 {
+		ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(e.RetryAfter.Seconds()))))
+	}
+	ctx.Abort()
+	ctx.Data(status, ContentType, data)
+}
+
+// Classify приводит любую ошибку к ошибке каталога.
+func Classify(err error) *domain.Error {
+	var e *domain.Error
+	switch {
+	case errors.As(err, &e):
+		return e
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return domain.ErrNotFound
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return domain.ErrConflict
+	case errors.Is(err, gorm.ErrForeignKeyViolated):
+		return domain.Validation("invalid_reference", "referenced record does not exist")
+	case errors.Is(err, context.DeadlineExceeded):
+		return domain.Upstream("timeout", "dependency did not respond in time")
+	default:
+		return domain.ErrInternal
+	}
+}
+
+func Status(e *domain.Error) int {
+	if status, ok := statuses[e.Kind]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// InvalidBody описывает ошибку разбора тела запроса. Для ошибок валидации
+// перечисляются поля, текст ошибки декодера наружу не отдаётся.
+func InvalidBody(err error) *domain.Error {
+	e := domain.Validation("invalid_body", "request body is invalid").Wrap(err)
+	var verrs validator.ValidationErrors
+	if errors.As(err, &verrs) {
+		fields := make(map[string]string, len(verrs))
+		for _, fe := range verrs {
+			fields[fe.Field()] = fe.Tag()
+		}
+		return e.WithFields(fields)
+	}
+	if errors.Is(err, io.EOF) {
+		e.Message = "request body is empty"
+	}
+	return e
+}
+
+// InvalidParam - некорректный параметр пути или запроса.
+func InvalidParam(name string, err error) *domain.Error {
+	return domain.Validation("invalid_parameter", "parameter "+name+" is invalid").
+		WithFields(map[string]string{name: "invalid"}).
+		Wrap(err)
+}
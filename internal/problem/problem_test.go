@@ -0,0 +1,105 @@
+package problem
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/immxrtalbeast/plandstu/internal/domain"
+	"gorm.io/gorm"
+)
+
+func TestClassify(t *testing.T) {
+	errThreadNotFound := domain.NotFound("chat_thread_not_found", "chat thread not found")
+	tests := []struct {
+		name       string
+		err        error
+		wantCode   string
+		wantStatus int
+	}{
+		{name: "catalog error", err: errThreadNotFound, wantCode: "chat_thread_not_found", wantStatus: http.StatusNotFound},
+		{name: "wrapped catalog error", err: fmt.Errorf("uc.llm.thread: %w", errThreadNotFound), wantCode: "chat_thread_not_found", wantStatus: http.StatusNotFound},
+		{name: "catalog error with cause", err: domain.Validation("invalid_body", "request body is invalid").Wrap(errors.New("eof")), wantCode: "invalid_body", wantStatus: http.StatusBadRequest},
+		{name: "rate limited", err: domain.RateLimited("rate_limited", "too many requests"), wantCode: "rate_limited", wantStatus: http.StatusTooManyRequests},
+		{name: "upstream", err: domain.Upstream("llm_unavailable", "llm is unavailable"), wantCode: "llm_unavailable", wantStatus: http.StatusBadGateway},
+		{name: "record not found", err: fmt.Errorf("repo: %w", gorm.ErrRecordNotFound), wantCode: "not_found", wantStatus: http.StatusNotFound},
+		{name: "duplicated key", err: gorm.ErrDuplicatedKey, wantCode: "conflict", wantStatus: http.StatusConflict},
+		{name: "foreign key violated", err: gorm.ErrForeignKeyViolated, wantCode: "invalid_reference", wantStatus: http.StatusBadRequest},
+		{name: "deadline exceeded", err: fmt.Errorf("parser: %w", context.DeadlineExceeded), wantCode: "timeout", wantStatus: http.StatusBadGateway},
+		{name: "unknown error", err: errors.New("pq: connection refused"), wantCode: "internal", wantStatus: http.StatusInternalServerError},
+		{name: "unknown kind", err: domain.NewError("teapot", "teapot", "i am a teapot"), wantCode: "teapot", wantStatus: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := Classify(tt.err)
+			if e.Code != tt.wantCode {
+				t.Fatalf("Classify() code = %q, want %q", e.Code, tt.wantCode)
+			}
+			if got := Status(e); got != tt.wantStatus {
+				t.Fatalf("Status() = %d, want %d", got, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestAbort(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name           string
+		err            error
+		wantStatus     int
+		wantDetail     string
+		wantRetryAfter string
+	}{
+		{
+			name:       "internal error text is hidden",
+			err:        errors.New("pq: password authentication failed"),
+			wantStatus: http.StatusInternalServerError,
+			wantDetail: "internal server error",
+		},
+		{
+			name:           "retry after is rounded up",
+			err:            domain.RateLimited("rate_limited", "too many requests").WithRetryAfter(1500 * time.Millisecond),
+			wantStatus:     http.StatusTooManyRequests,
+			wantDetail:     "too many requests",
+			wantRetryAfter: "2",
+		},
+		{
+			name:       "validation fields",
+			err:        InvalidParam("id", errors.New("bad uuid")),
+			wantStatus: http.StatusBadRequest,
+			wantDetail: "parameter id is invalid",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/items", func(ctx *gin.Context) { Abort(ctx, tt.err) })
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/items", nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != ContentType {
+				t.Fatalf("Content-Type = %q, want %q", ct, ContentType)
+			}
+			if got := rec.Header().Get("Retry-After"); got != tt.wantRetryAfter {
+				t.Fatalf("Retry-After = %q, want %q", got, tt.wantRetryAfter)
+			}
+			var body Details
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Status != tt.wantStatus || body.Detail != tt.wantDetail || body.Instance != "/items" {
+				t.Fatalf("body = %+v, want status %d and detail %q", body, tt.wantStatus, tt.wantDetail)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval - как часто из памяти удаляются истёкшие счётчики.
+const sweepInterval = time.Minute
+
+type counter struct {
+	count    int64
+	expireAt time.Time
+}
+
+// MemoryStore хранит счётчики в памяти процесса. Подходит для разработки
+// и одного экземпляра сервиса.
+type MemoryStore struct {
+	mu        sync.Mutex
+	counters  map[string]*counter
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{counters: make(map[string]*counter)}
+}
+
+func (s *MemoryStore) Incr(ctx context.Context, key string, expireAt time.Time) (int64, error) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if now.Sub(s.lastSweep) >= sweepInterval {
+		for k, c := range s.counters {
+			if !now.Before(c.expireAt) {
+				delete(s.counters, k)
+			}
+		}
+		s.lastSweep = now
+	}
+	c, ok := s.counters[key]
+	if !ok || !now.Before(c.expireAt) {
+		c = &counter{expireAt: expireAt}
+		s.counters[key] = c
+	}
+	c.count++
+	return c.count, nil
+}
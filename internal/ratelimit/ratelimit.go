@@ -0,0 +1,58 @@
+// Package ratelimit ограничивает частоту запросов фиксированными окнами:
+// счётчик на ключ и окно, окно выравнивается по UTC. Суточная квота - то же
+// правило с окном в 24 часа, которое сбрасывается в полночь UTC.
+package ratelimit
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+// Store увеличивает счётчик ключа и возвращает новое значение. Ключ должен
+// исчезнуть не раньше expireAt.
+type Store interface {
+	Incr(ctx context.Context, key string, expireAt time.Time) (int64, error)
+}
+
+// Rule - лимит Limit запросов за Window. Quota отличает суточные квоты от
+// ограничения частоты в ответе клиенту.
+type Rule struct {
+	Name   string
+	Limit  int
+	Window time.Duration
+	Quota  bool
+}
+
+type Decision struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration
+}
+
+type Limiter struct {
+	store  Store
+	prefix string
+	now    func() time.Time
+}
+
+func New(store Store, prefix string) *Limiter {
+	return &Limiter{store: store, prefix: prefix, now: time.Now}
+}
+
+// Allow учитывает запрос subject в текущем окне правила. limit переопределяет
+// Rule.Limit, например с учётом роли.
+func (l *Limiter) Allow(ctx context.Context, rule Rule, subject string, limit int) (Decision, error) {
+	now := l.now().UTC()
+	start := now.Truncate(rule.Window)
+	end := start.Add(rule.Window)
+	key := l.prefix + ":" + rule.Name + ":" + subject + ":" + strconv.FormatInt(start.Unix(), 10)
+	count, err := l.store.Incr(ctx, key, end)
+	if err != nil {
+		return Decision{}, err
+	}
+	if count > int64(limit) {
+		return Decision{RetryAfter: end.Sub(now)}, nil
+	}
+	return Decision{Allowed: true, Remaining: limit - int(count)}, nil
+}
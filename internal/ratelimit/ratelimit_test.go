@@ -0,0 +1,87 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLimiterAllow(t *testing.T) {
+	// Окна считаются от UTC, поэтому берём время, выровненное по суткам
+	base := time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC)
+	type call struct {
+		at         time.Duration // смещение от base
+		subject    string
+		limit      int
+		want       bool
+		remaining  int
+		retryAfter time.Duration
+	}
+	tests := []struct {
+		name  string
+		rule  Rule
+		calls []call
+	}{
+		{
+			name: "limit within window",
+			rule: Rule{Name: "chat", Limit: 2, Window: time.Minute},
+			calls: []call{
+				{at: 0, subject: "u1", limit: 2, want: true, remaining: 1},
+				{at: 10 * time.Second, subject: "u1", limit: 2, want: true, remaining: 0},
+				{at: 20 * time.Second, subject: "u1", limit: 2, want: false, retryAfter: 40 * time.Second},
+				{at: 59 * time.Second, subject: "u1", limit: 2, want: false, retryAfter: time.Second},
+			},
+		},
+		{
+			name: "next window starts over",
+			rule: Rule{Name: "chat", Limit: 1, Window: time.Minute},
+			calls: []call{
+				{at: 30 * time.Second, subject: "u1", limit: 1, want: true, remaining: 0},
+				{at: 45 * time.Second, subject: "u1", limit: 1, want: false, retryAfter: 15 * time.Second},
+				{at: time.Minute, subject: "u1", limit: 1, want: true, remaining: 0},
+			},
+		},
+		{
+			name: "subjects are counted separately",
+			rule: Rule{Name: "chat", Limit: 1, Window: time.Minute},
+			calls: []call{
+				{at: 0, subject: "u1", limit: 1, want: true, remaining: 0},
+				{at: 0, subject: "u2", limit: 1, want: true, remaining: 0},
+				{at: 0, subject: "u1", limit: 1, want: false, retryAfter: time.Minute},
+			},
+		},
+		{
+			name: "limit overrides rule",
+			rule: Rule{Name: "chat", Limit: 1, Window: time.Minute},
+			calls: []call{
+				{at: 0, subject: "u1", limit: 3, want: true, remaining: 2},
+				{at: 0, subject: "u1", limit: 3, want: true, remaining: 1},
+			},
+		},
+		{
+			name: "daily quota resets at midnight UTC",
+			rule: Rule{Name: "quota", Limit: 1, Window: 24 * time.Hour, Quota: true},
+			calls: []call{
+				{at: 23 * time.Hour, subject: "u1", limit: 1, want: true, remaining: 0},
+				{at: 23*time.Hour + 30*time.Minute, subject: "u1", limit: 1, want: false, retryAfter: 30 * time.Minute},
+				{at: 24 * time.Hour, subject: "u1", limit: 1, want: true, remaining: 0},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := New(NewMemoryStore(), "test")
+			for i, c := range tt.calls {
+				l.now = func() time.Time { return base.Add(c.at) }
+				got, err := l.Allow(context.Background(), tt.rule, c.subject, c.limit)
+				if err != nil {
+					t.Fatalf("call %d: %v", i, err)
+				}
+				want := Decision{Allowed: c.want, Remaining: c.remaining, RetryAfter: c.retryAfter}
+				if got != want {
+					t.Fatalf("call %d: got %+v, want %+v", i, got, want)
+				}
+			}
+		})
+	}
+}
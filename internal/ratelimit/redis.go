@@ -0,0 +1,30 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// incrScript выставляет срок жизни только новому ключу, чтобы окно не продлевалось.
+var incrScript = redis.NewScript(`
+local n = redis.call('INCR', KEYS[1])
+if n == 1 then
+	redis.call('PEXPIREAT', KEYS[1], ARGV[1])
+end
+return n
+`)
+
+// RedisStore хранит счётчики в Redis, лимиты общие для всех экземпляров сервиса.
+type RedisStore struct {
+	client redis.UniversalClient
+}
+
+func NewRedisStore(client redis.UniversalClient) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+func (s *RedisStore) Incr(ctx context.Context, key string, expireAt time.Time) (int64, error) {
+	return incrScript.Run(ctx, s.client, []string{key}, expireAt.UnixMilli()).Int64()
+}
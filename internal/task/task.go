@@ -12,12 +12,14 @@ import (
 const QueueGenerateTest = "generate_test"
 
 type GenerateTestPayload struct {
-	TestID        string    `json:"test_id"`
-	Themes        []string  `json:"themes"`
-	UserID        string    `json:"user_id"`
-	DisciplineID  int       `json:"discipline_id"`
-	HistoryID     uuid.UUID `json:"history_id"`
-	LLMServiceURL string    `json:"llm_service_url"`
+	TestID       string    `json:"test_id"`
+	Themes       []string  `json:"themes"`
+	UserID       string    `json:"user_id"`
+	DisciplineID int       `json:"discipline_id"`
+	HistoryID    uuid.UUID `json:"history_id"`
+	// TraceContext - заголовки W3C trace context запроса, поставившего задачу.
+	TraceContext map[string]string `json:"trace_context,omitempty"`
+	RequestID    string            `json:"request_id,omitempty"`
 }
 
 var RedisClient *asynq.Client
@@ -26,6 +28,20 @@ func Init(redisAddr string) {
 	RedisClient = asynq.NewClient(asynq.RedisClientOpt{Addr: redisAddr})
 }
 
+func Ping() error {
+	if RedisClient == nil {
+		return fmt.Errorf("task client is not initialized")
+	}
+	return RedisClient.Ping()
+}
+
+func Close() error {
+	if RedisClient == nil {
+		return nil
+	}
+	return RedisClient.Close()
+}
+
 func NewGenerateTestTask(payload GenerateTestPayload) (*asynq.Task, error) {
 	payloadJSON, err := json.Marshal(payload)
 	if err != nil {
@@ -0,0 +1,168 @@
+package access
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/immxrtalbeast/plandstu/internal/domain"
+	"github.com/immxrtalbeast/plandstu/internal/logging"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrForbidden         = domain.Forbidden("forbidden", "access denied")
+	ErrInvalidScope      = domain.Validation("invalid_scope", "invalid scope")
+	ErrUnknownGroup      = domain.Validation("unknown_group", "unknown group")
+	ErrUnknownDiscipline = domain.Validation("unknown_discipline", "unknown discipline")
+)
+
+// Права ролей меняются редко, поэтому кэшируем их, чтобы не ходить в БД на каждый запрос.
+const permissionsCacheTTL = time.Minute
+
+type cachedPermissions struct {
+	permissions []string
+	loadedAt    time.Time
+}
+
+type AccessInteractor struct {
+	accessRepo     domain.AccessRepository
+	groupRepo      domain.GroupRepository
+	disciplineRepo domain.DisciplineRepository
+
+	mu    sync.RWMutex
+	cache map[string]cachedPermissions
+}
+
+func NewAccessInteractor(accessRepo domain.AccessRepository, groupRepo domain.GroupRepository, disciplineRepo domain.DisciplineRepository) *AccessInteractor {
+	return &AccessInteractor{accessRepo: accessRepo, groupRepo: groupRepo, disciplineRepo: disciplineRepo, cache: make(map[string]cachedPermissions)}
+}
+
+func (ai *AccessInteractor) HasPermission(ctx context.Context, role string, permission string) (_ bool, err error) {
+	const op = "uc.access.has_permission"
+	defer logging.OnError(ctx, op, &err)
+	permissions, err := ai.rolePermissions(ctx, role)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	return slices.Contains(permissions, permission), nil
+}
+
+// CheckScope возвращает ErrForbidden с причиной, если у пользователя нет гранта на объект.
+func (ai *AccessInteractor) CheckScope(ctx context.Context, userID uuid.UUID, role string, scope string, value string) (err error) {
+	const op = "uc.access.check_scope"
+	defer logging.OnError(ctx, op, &err)
+	values, all, err := ai.ScopeValues(ctx, userID, role, scope)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if all || slices.Contains(values, value) {
+		return nil
+	}
+	return domain.Forbidden(ErrForbidden.Code, fmt.Sprintf("no access to %s %s", scope, value))
+}
+
+// ScopeValues возвращает объекты, на которые у пользователя есть гранты.
+// all=true означает доступ ко всем объектам скоупа.
+func (ai *AccessInteractor) ScopeValues(ctx context.Context, userID uuid.UUID, role string, scope string) (_ []string, _ bool, err error) {
+	const op = "uc.access.scope_values"
+	defer logging.OnError(ctx, op, &err)
+	all, err := ai.HasPermission(ctx, role, domain.PermAllScopes)
+	if err != nil {
+		return nil, false, fmt.Errorf("%s: %w", op, err)
+	}
+	if all {
+		return nil, true, nil
+	}
+	values, err := ai.accessRepo.GrantValues(ctx, userID, scope)
+	if err != nil {
+		return nil, false, fmt.Errorf("%s: %w", op, err)
+	}
+	return values, false, nil
+}
+
+func (ai *AccessInteractor) Grants(ctx context.Context, userID uuid.UUID) (_ []*domain.AccessGrant, err error) {
+	const op = "uc.access.grants"
+	defer logging.OnError(ctx, op, &err)
+	grants, err := ai.accessRepo.Grants(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return grants, nil
+}
+
+// CreateGrant выдаёт грант на существующий объект. Для групп value - ID
+// группы: по названию грант потерялся бы при переименовании.
+func (ai *AccessInteractor) CreateGrant(ctx context.Context, userID uuid.UUID, scope string, value string) (_ *domain.AccessGrant, err error) {
+	const op = "uc.access.create_grant"
+	defer logging.OnError(ctx, op, &err)
+	switch scope {
+	case domain.ScopeDiscipline:
+		disciplineID, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w: %v", op, ErrUnknownDiscipline, err)
+		}
+		if _, err := ai.disciplineRepo.Discipline(ctx, disciplineID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, fmt.Errorf("%s: %w", op, ErrUnknownDiscipline)
+			}
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		value = strconv.Itoa(disciplineID)
+	case domain.ScopeGroup:
+		groupID, err := uuid.Parse(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w: %v", op, ErrUnknownGroup, err)
+		}
+		if _, err := ai.groupRepo.Group(ctx, groupID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, fmt.Errorf("%s: %w", op, ErrUnknownGroup)
+			}
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		value = groupID.String()
+	default:
+		return nil, fmt.Errorf("%s: %w: %q", op, ErrInvalidScope, scope)
+	}
+	grant := &domain.AccessGrant{
+		UserID: userID,
+		Scope:  scope,
+		Value:  value,
+	}
+	if err := ai.accessRepo.CreateGrant(ctx, grant); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return grant, nil
+}
+
+func (ai *AccessInteractor) DeleteGrant(ctx context.Context, grantID uuid.UUID) (err error) {
+	const op = "uc.access.delete_grant"
+	defer logging.OnError(ctx, op, &err)
+	if err := ai.accessRepo.DeleteGrant(ctx, grantID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (ai *AccessInteractor) rolePermissions(ctx context.Context, role string) ([]string, error) {
+	ai.mu.RLock()
+	cached, ok := ai.cache[role]
+	ai.mu.RUnlock()
+	if ok && time.Since(cached.loadedAt) < permissionsCacheTTL {
+		return cached.permissions, nil
+	}
+
+	permissions, err := ai.accessRepo.RolePermissions(ctx, role)
+	if err != nil {
+		return nil, err
+	}
+	ai.mu.Lock()
+	ai.cache[role] = cachedPermissions{permissions: permissions, loadedAt: time.Now()}
+	ai.mu.Unlock()
+	return permissions, nil
+}
@@ -0,0 +1,163 @@
+package access
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/immxrtalbeast/plandstu/internal/domain"
+	"gorm.io/gorm"
+)
+
+// memoryAccessRepo - роли и гранты в памяти.
+type memoryAccessRepo struct {
+	domain.AccessRepository
+	roles  map[string][]string
+	grants []*domain.AccessGrant
+}
+
+func (r *memoryAccessRepo) RolePermissions(ctx context.Context, role string) ([]string, error) {
+	return r.roles[role], nil
+}
+
+func (r *memoryAccessRepo) GrantValues(ctx context.Context, userID uuid.UUID, scope string) ([]string, error) {
+	var values []string
+	for _, g := range r.grants {
+		if g.UserID == userID && g.Scope == scope {
+			values = append(values, g.Value)
+		}
+	}
+	return values, nil
+}
+
+func (r *memoryAccessRepo) CreateGrant(ctx context.Context, grant *domain.AccessGrant) error {
+	r.grants = append(r.grants, grant)
+	return nil
+}
+
+type memoryGroupRepo struct {
+	domain.GroupRepository
+	groups map[uuid.UUID]*domain.Group
+}
+
+func (r *memoryGroupRepo) Group(ctx context.Context, id uuid.UUID) (*domain.Group, error) {
+	group, ok := r.groups[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return group, nil
+}
+
+type memoryDisciplineRepo struct {
+	domain.DisciplineRepository
+	disciplines map[int]*domain.Discipline
+}
+
+func (r *memoryDisciplineRepo) Discipline(ctx context.Context, id int) (*domain.Discipline, error) {
+	discipline, ok := r.disciplines[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return discipline, nil
+}
+
+func TestCheckScope(t *testing.T) {
+	teacherID := uuid.New()
+	groupID := uuid.New()
+	repo := &memoryAccessRepo{
+		roles: domain.DefaultRolePermissions,
+		grants: []*domain.AccessGrant{
+			{UserID: teacherID, Scope: domain.ScopeDiscipline, Value: "7"},
+			{UserID: teacherID, Scope: domain.ScopeGroup, Value: groupID.String()},
+		},
+	}
+	ai := NewAccessInteractor(repo, nil, nil)
+
+	tests := []struct {
+		name   string
+		userID uuid.UUID
+		role   string
+		scope  string
+		value  string
+		want   bool
+	}{
+		{name: "granted discipline", userID: teacherID, role: domain.RoleTeacher, scope: domain.ScopeDiscipline, value: "7", want: true},
+		{name: "granted group", userID: teacherID, role: domain.RoleTeacher, scope: domain.ScopeGroup, value: groupID.String(), want: true},
+		{name: "other discipline", userID: teacherID, role: domain.RoleTeacher, scope: domain.ScopeDiscipline, value: "8"},
+		{name: "grant of another scope", userID: teacherID, role: domain.RoleTeacher, scope: domain.ScopeGroup, value: "7"},
+		{name: "other teacher", userID: uuid.New(), role: domain.RoleTeacher, scope: domain.ScopeDiscipline, value: "7"},
+		{name: "admin sees everything", userID: uuid.New(), role: domain.RoleAdmin, scope: domain.ScopeDiscipline, value: "8", want: true},
+		{name: "unknown role", userID: teacherID, role: "Guest", scope: domain.ScopeDiscipline, value: "8"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ai.CheckScope(context.Background(), tt.userID, tt.role, tt.scope, tt.value)
+			if tt.want {
+				if err != nil {
+					t.Fatalf("CheckScope() error = %v, want nil", err)
+				}
+				return
+			}
+			var derr *domain.Error
+			if !errors.Is(err, ErrForbidden) || !errors.As(err, &derr) {
+				t.Fatalf("CheckScope() error = %v, want %v", err, ErrForbidden)
+			}
+			// Причина уходит клиенту в detail
+			if want := tt.scope + " " + tt.value; !strings.Contains(derr.Message, want) {
+				t.Fatalf("message = %q, want it to mention %q", derr.Message, want)
+			}
+		})
+	}
+}
+
+func TestCreateGrant(t *testing.T) {
+	groupID := uuid.New()
+	groups := &memoryGroupRepo{groups: map[uuid.UUID]*domain.Group{groupID: {ID: groupID, Name: "ВКБ-21"}}}
+	disciplines := &memoryDisciplineRepo{disciplines: map[int]*domain.Discipline{7: {ID: 7}}}
+
+	tests := []struct {
+		name      string
+		scope     string
+		value     string
+		wantValue string
+		wantErr   error
+	}{
+		{name: "discipline", scope: domain.ScopeDiscipline, value: "7", wantValue: "7"},
+		{name: "discipline is normalized", scope: domain.ScopeDiscipline, value: "007", wantValue: "7"},
+		{name: "unknown discipline", scope: domain.ScopeDiscipline, value: "8", wantErr: ErrUnknownDiscipline},
+		{name: "discipline is not a number", scope: domain.ScopeDiscipline, value: "Math", wantErr: ErrUnknownDiscipline},
+		{name: "group", scope: domain.ScopeGroup, value: strings.ToUpper(groupID.String()), wantValue: groupID.String()},
+		{name: "unknown group", scope: domain.ScopeGroup, value: uuid.NewString(), wantErr: ErrUnknownGroup},
+		{name: "group by name", scope: domain.ScopeGroup, value: "ВКБ-21", wantErr: ErrUnknownGroup},
+		{name: "unknown scope", scope: "faculty", value: "1", wantErr: ErrInvalidScope},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &memoryAccessRepo{}
+			ai := NewAccessInteractor(repo, groups, disciplines)
+			userID := uuid.New()
+
+			grant, err := ai.CreateGrant(context.Background(), userID, tt.scope, tt.value)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("CreateGrant() error = %v, want %v", err, tt.wantErr)
+				}
+				if len(repo.grants) != 0 {
+					t.Fatalf("grant stored after error: %+v", repo.grants[0])
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateGrant() error = %v", err)
+			}
+			if grant.Value != tt.wantValue || len(repo.grants) != 1 {
+				t.Fatalf("grant value = %q (stored %d), want %q", grant.Value, len(repo.grants), tt.wantValue)
+			}
+			if err := ai.CheckScope(context.Background(), userID, domain.RoleTeacher, tt.scope, tt.wantValue); err != nil {
+				t.Fatalf("CheckScope() after grant: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/immxrtalbeast/plandstu/internal/domain"
+	"github.com/immxrtalbeast/plandstu/internal/logging"
+	"github.com/immxrtalbeast/plandstu/internal/parser"
+	"gorm.io/gorm"
+)
+
+var ErrDisciplineNotFound = domain.NotFound("discipline_not_found", "discipline not found")
+
+func (ci *CatalogInteractor) Discipline(ctx context.Context, id int) (_ *domain.Discipline, err error) {
+	const op = "uc.catalog.discipline"
+	defer logging.OnError(ctx, op, &err)
+	discipline, err := ci.disciplineRepo.Discipline(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDisciplineNotFound
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return discipline, nil
+}
+
+func (ci *CatalogInteractor) Disciplines(ctx context.Context, filter domain.DisciplineFilter) (_ []*domain.Discipline, err error) {
+	const op = "uc.catalog.disciplines"
+	defer logging.OnError(ctx, op, &err)
+	disciplines, err := ci.disciplineRepo.Disciplines(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return disciplines, nil
+}
+
+// importDisciplines переносит факультеты, направления и дисциплины из ответов
+// парсера (через кэш) в таблицы. Направления, которые не удалось загрузить,
+// пропускаются: их связи с дисциплинами остаются прежними.
+func (ci *CatalogInteractor) importDisciplines(ctx context.Context) (_ *domain.CatalogSnapshot, failed []string, err error) {
+	faculties, err := ci.Faculties(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	now := time.Now()
+	snapshot := &domain.CatalogSnapshot{}
+	seenDirections := make(map[string]bool)
+	seenDisciplines := make(map[int]bool)
+	for _, f := range faculties {
+		if f.ID == "" {
+			continue
+		}
+		snapshot.Faculties = append(snapshot.Faculties, domain.Faculty{ID: string(f.ID), Name: f.Name, UpdatedAt: now})
+		directions := f.Directions
+		if len(directions) == 0 {
+			faculty, err := ci.Faculty(ctx, string(f.ID))
+			if err != nil {
+				failed = append(failed, parser.FacultyResource(string(f.ID)).Path)
+				continue
+			}
+			directions = faculty.Directions
+		}
+		for _, d := range directions {
+			directionID := string(d.ID)
+			if directionID == "" || seenDirections[directionID] {
+				continue
+			}
+			seenDirections[directionID] = true
+			snapshot.Directions = append(snapshot.Directions, domain.Direction{
+				ID:        directionID,
+				FacultyID: string(f.ID),
+				Name:      d.Name,
+				Link:      d.Link,
+				UpdatedAt: now,
+			})
+			// Дисциплины у парсера запрашиваются по ссылке направления
+			key := d.Link
+			if key == "" {
+				key = directionID
+			}
+			r := parser.DisciplinesResource(key)
+			disciplines, err := parser.DecodeDisciplines(ci.Resource(ctx, r))
+			if err != nil {
+				failed = append(failed, r.Path)
+				continue
+			}
+			snapshot.LoadedDirections = append(snapshot.LoadedDirections, directionID)
+			for _, dd := range disciplines {
+				id, err := strconv.Atoi(string(dd.ID))
+				if err != nil {
+					continue
+				}
+				snapshot.Links = append(snapshot.Links, domain.DirectionDiscipline{DirectionID: directionID, DisciplineID: id})
+				if seenDisciplines[id] {
+					continue
+				}
+				seenDisciplines[id] = true
+				snapshot.Disciplines = append(snapshot.Disciplines, domain.Discipline{ID: id, Name: dd.Name, Link: dd.Link, UpdatedAt: now})
+			}
+		}
+	}
+	if err := ci.disciplineRepo.SaveCatalog(ctx, snapshot); err != nil {
+		return nil, failed, err
+	}
+	logging.FromContext(ctx).Info("disciplines imported",
+		slog.Int("faculties", len(snapshot.Faculties)),
+		slog.Int("directions", len(snapshot.Directions)),
+		slog.Int("disciplines", len(snapshot.Disciplines)),
+		slog.Int("failed", len(failed)))
+	return snapshot, failed, nil
+}
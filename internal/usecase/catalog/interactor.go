@@ -0,0 +1,260 @@
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/immxrtalbeast/plandstu/internal/domain"
+	"github.com/immxrtalbeast/plandstu/internal/logging"
+	"github.com/immxrtalbeast/plandstu/internal/parser"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// refreshTimeout ограничивает фоновое обновление одной записи.
+const refreshTimeout = time.Minute
+
+// Source - источник справочников (сервис-парсер).
+type Source interface {
+	Fetch(ctx context.Context, r parser.Resource) (json.RawMessage, error)
+}
+
+type entry struct {
+	payload   json.RawMessage
+	fetchedAt time.Time
+}
+
+// fetchCall - загрузка ресурса, которую ждут все параллельные запросы этого ресурса.
+type fetchCall struct {
+	done  chan struct{}
+	entry *entry
+	err   error
+}
+
+// CatalogInteractor отдаёт справочники парсера через кэш: LRU в памяти поверх
+// таблицы catalog_cache. Запись старше ttl отдаётся сразу, а обновляется в фоне,
+// поэтому при недоступном парсере приложение работает на последних сохранённых данных.
+type CatalogInteractor struct {
+	source         Source
+	repo           domain.CatalogRepository
+	disciplineRepo domain.DisciplineRepository
+	ttl            time.Duration
+	cache          *lru
+
+	mu       sync.Mutex
+	inflight map[string]*fetchCall
+}
+
+func NewCatalogInteractor(source Source, repo domain.CatalogRepository, disciplineRepo domain.DisciplineRepository, ttl time.Duration, cacheSize int) *CatalogInteractor {
+	return &CatalogInteractor{
+		source:         source,
+		repo:           repo,
+		disciplineRepo: disciplineRepo,
+		ttl:            ttl,
+		cache:          newLRU(cacheSize),
+		inflight:       make(map[string]*fetchCall),
+	}
+}
+
+// Resource возвращает ответ парсера на ресурс r.
+func (ci *CatalogInteractor) Resource(ctx context.Context, r parser.Resource) (_ json.RawMessage, err error) {
+	const op = "uc.catalog.resource"
+	defer logging.OnError(ctx, op, &err)
+	if e := ci.lookup(ctx, r.Path); e != nil {
+		if time.Since(e.fetchedAt) >= ci.ttl {
+			ci.refreshAsync(ctx, r)
+		}
+		return e.payload, nil
+	}
+	e, err := ci.refresh(ctx, r)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return e.payload, nil
+}
+
+func (ci *CatalogInteractor) Faculties(ctx context.Context) ([]parser.Faculty, error) {
+	return parser.Decode[[]parser.Faculty](ci.Resource(ctx, parser.FacultiesResource()))
+}
+
+func (ci *CatalogInteractor) Faculty(ctx context.Context, id string) (*parser.Faculty, error) {
+	faculty, err := parser.Decode[parser.Faculty](ci.Resource(ctx, parser.FacultyResource(id)))
+	if err != nil {
+		return nil, err
+	}
+	return &faculty, nil
+}
+
+// Resync синхронно обновляет все сохранённые записи и список факультетов,
+// затем заново импортирует дисциплины. Записи, которые не удалось обновить, остаются в кэше.
+func (ci *CatalogInteractor) Resync(ctx context.Context) (_ *domain.CatalogSyncResult, err error) {
+	const op = "uc.catalog.resync"
+	defer logging.OnError(ctx, op, &err)
+	resources, err := ci.resources(ctx, false)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	result := &domain.CatalogSyncResult{Failed: []string{}}
+	for _, r := range resources {
+		if _, err := ci.refresh(ctx, r); err != nil {
+			logging.FromContext(ctx).Warn("catalog resync failed", slog.String("key", r.Path), logging.Err(err))
+			result.Failed = append(result.Failed, r.Path)
+			continue
+		}
+		result.Refreshed++
+	}
+	snapshot, failed, err := ci.importDisciplines(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	result.Failed = append(result.Failed, failed...)
+	result.Disciplines = len(snapshot.Disciplines)
+	logging.FromContext(ctx).Info("catalog resynced", slog.Int("refreshed", result.Refreshed), slog.Int("failed", len(result.Failed)))
+	return result, nil
+}
+
+// RunRefresh раз в interval обновляет устаревшие записи. Блокируется до отмены ctx.
+func (ci *CatalogInteractor) RunRefresh(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		ci.refreshStale(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (ci *CatalogInteractor) refreshStale(ctx context.Context) {
+	log := logging.FromContext(ctx)
+	resources, err := ci.resources(ctx, true)
+	if err != nil {
+		log.Warn("catalog refresh: failed to list entries", logging.Err(err))
+		return
+	}
+	refreshed, failed := 0, 0
+	for _, r := range resources {
+		if ctx.Err() != nil {
+			return
+		}
+		rctx, cancel := context.WithTimeout(ctx, refreshTimeout)
+		_, err := ci.refresh(rctx, r)
+		cancel()
+		if err != nil {
+			failed++
+			continue
+		}
+		refreshed++
+	}
+	if refreshed > 0 || failed > 0 {
+		log.Info("catalog refreshed", slog.Int("refreshed", refreshed), slog.Int("failed", failed))
+	}
+	if _, _, err := ci.importDisciplines(ctx); err != nil {
+		log.Warn("catalog refresh: failed to import disciplines", logging.Err(err))
+	}
+}
+
+// resources - сохранённые ресурсы плюс список факультетов, который нужен всегда.
+// При staleOnly возвращаются только записи старше ttl.
+func (ci *CatalogInteractor) resources(ctx context.Context, staleOnly bool) ([]parser.Resource, error) {
+	entries, err := ci.repo.CatalogEntries(ctx)
+	if err != nil {
+		return nil, err
+	}
+	faculties := parser.FacultiesResource()
+	resources := make([]parser.Resource, 0, len(entries)+1)
+	seen := false
+	for _, e := range entries {
+		seen = seen || e.Key == faculties.Path
+		if staleOnly && time.Since(e.FetchedAt) < ci.ttl {
+			continue
+		}
+		resources = append(resources, parser.Resource{Kind: e.Kind, Path: e.Key})
+	}
+	if !seen {
+		resources = append(resources, faculties)
+	}
+	return resources, nil
+}
+
+// lookup ищет запись в памяти, затем в базе. Ошибка базы не мешает сходить в парсер.
+func (ci *CatalogInteractor) lookup(ctx context.Context, key string) *entry {
+	if e, ok := ci.cache.get(key); ok {
+		return e
+	}
+	stored, err := ci.repo.CatalogEntry(ctx, key)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			logging.FromContext(ctx).Warn("catalog cache read failed", slog.String("key", key), logging.Err(err))
+		}
+		return nil
+	}
+	e := &entry{payload: json.RawMessage(stored.Payload), fetchedAt: stored.FetchedAt}
+	ci.cache.put(key, e)
+	return e
+}
+
+func (ci *CatalogInteractor) refreshAsync(ctx context.Context, r parser.Resource) {
+	ci.mu.Lock()
+	_, busy := ci.inflight[r.Path]
+	ci.mu.Unlock()
+	if busy {
+		return
+	}
+	// Обновление не должно обрываться вместе с запросом, который его запустил
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), refreshTimeout)
+	go func() {
+		defer cancel()
+		if _, err := ci.refresh(ctx, r); err != nil {
+			logging.FromContext(ctx).Warn("catalog refresh failed, serving stale data", slog.String("key", r.Path), logging.Err(err))
+		}
+	}()
+}
+
+// refresh загружает ресурс у парсера и сохраняет его. Параллельные загрузки
+// одного ресурса объединяются в одну.
+func (ci *CatalogInteractor) refresh(ctx context.Context, r parser.Resource) (*entry, error) {
+	ci.mu.Lock()
+	if call, ok := ci.inflight[r.Path]; ok {
+		ci.mu.Unlock()
+		select {
+		case <-call.done:
+			return call.entry, call.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	call := &fetchCall{done: make(chan struct{})}
+	ci.inflight[r.Path] = call
+	ci.mu.Unlock()
+
+	call.entry, call.err = ci.fetch(ctx, r)
+
+	ci.mu.Lock()
+	delete(ci.inflight, r.Path)
+	ci.mu.Unlock()
+	close(call.done)
+	return call.entry, call.err
+}
+
+func (ci *CatalogInteractor) fetch(ctx context.Context, r parser.Resource) (*entry, error) {
+	payload, err := ci.source.Fetch(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	e := &entry{payload: payload, fetchedAt: time.Now()}
+	stored := &domain.CatalogEntry{Key: r.Path, Kind: r.Kind, Payload: datatypes.JSON(payload), FetchedAt: e.fetchedAt}
+	if err := ci.repo.SaveCatalogEntry(ctx, stored); err != nil {
+		// Без базы кэш остаётся только в памяти
+		logging.FromContext(ctx).Warn("catalog cache write failed", slog.String("key", r.Path), logging.Err(err))
+	}
+	ci.cache.put(r.Path, e)
+	return e, nil
+}
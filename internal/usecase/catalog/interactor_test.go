@@ -0,0 +1,133 @@
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/immxrtalbeast/plandstu/internal/domain"
+	"github.com/immxrtalbeast/plandstu/internal/parser"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// fakeSource отвечает заданным payload и сообщает о каждой загрузке в fetched.
+type fakeSource struct {
+	payload string
+	err     error
+	calls   atomic.Int32
+	fetched chan struct{}
+}
+
+func (s *fakeSource) Fetch(ctx context.Context, r parser.Resource) (json.RawMessage, error) {
+	s.calls.Add(1)
+	defer func() {
+		select {
+		case s.fetched <- struct{}{}:
+		default:
+		}
+	}()
+	if s.err != nil {
+		return nil, s.err
+	}
+	return json.RawMessage(s.payload), nil
+}
+
+// memoryCatalogRepo - catalog_cache в памяти.
+type memoryCatalogRepo struct {
+	domain.CatalogRepository
+	mu      sync.Mutex
+	entries map[string]domain.CatalogEntry
+}
+
+func (r *memoryCatalogRepo) CatalogEntry(ctx context.Context, key string) (*domain.CatalogEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e, ok := r.entries[key]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &e, nil
+}
+
+func (r *memoryCatalogRepo) SaveCatalogEntry(ctx context.Context, entry *domain.CatalogEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries[entry.Key] = *entry
+	return nil
+}
+
+func (r *memoryCatalogRepo) stored(key string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return string(r.entries[key].Payload)
+}
+
+func TestResource(t *testing.T) {
+	const ttl = time.Hour
+	resource := parser.FacultiesResource()
+	errParser := errors.New("parser is down")
+
+	tests := []struct {
+		name       string
+		storedAge  time.Duration // возраст записи в базе, 0 - записи нет
+		sourceErr  error
+		want       string
+		wantErr    bool
+		wantCalls  int32
+		wantStored string // запись в базе после фонового обновления
+	}{
+		{name: "miss fetches synchronously", want: `["new"]`, wantCalls: 1, wantStored: `["new"]`},
+		{name: "miss with parser down", sourceErr: errParser, wantErr: true, wantCalls: 1},
+		{name: "fresh entry is served from cache", storedAge: ttl / 2, want: `["old"]`, wantStored: `["old"]`},
+		{name: "stale entry is served and refreshed", storedAge: 2 * ttl, want: `["old"]`, wantCalls: 1, wantStored: `["new"]`},
+		{name: "stale entry survives parser outage", storedAge: 2 * ttl, sourceErr: errParser, want: `["old"]`, wantCalls: 1, wantStored: `["old"]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := &fakeSource{payload: `["new"]`, err: tt.sourceErr, fetched: make(chan struct{}, 1)}
+			repo := &memoryCatalogRepo{entries: map[string]domain.CatalogEntry{}}
+			if tt.storedAge > 0 {
+				repo.entries[resource.Path] = domain.CatalogEntry{
+					Key: resource.Path, Kind: resource.Kind,
+					Payload: datatypes.JSON(`["old"]`), FetchedAt: time.Now().Add(-tt.storedAge),
+				}
+			}
+			ci := NewCatalogInteractor(source, repo, nil, ttl, 8)
+
+			got, err := ci.Resource(context.Background(), resource)
+			if tt.wantErr {
+				if !errors.Is(err, errParser) {
+					t.Fatalf("Resource() error = %v, want %v", err, errParser)
+				}
+			} else if err != nil || string(got) != tt.want {
+				t.Fatalf("Resource() = %s, %v, want %s", got, err, tt.want)
+			}
+			// Фоновое обновление дожидаемся по сигналу источника
+			if tt.wantCalls > 0 {
+				select {
+				case <-source.fetched:
+				case <-time.After(time.Second):
+					t.Fatal("refresh did not happen")
+				}
+			}
+			if calls := source.calls.Load(); calls != tt.wantCalls {
+				t.Fatalf("source calls = %d, want %d", calls, tt.wantCalls)
+			}
+			if tt.wantStored != "" {
+				// Источник сигналит до записи в базу, поэтому ждём её
+				deadline := time.Now().Add(time.Second)
+				for repo.stored(resource.Path) != tt.wantStored && time.Now().Before(deadline) {
+					time.Sleep(time.Millisecond)
+				}
+				if got := repo.stored(resource.Path); got != tt.wantStored {
+					t.Fatalf("stored payload = %s, want %s", got, tt.wantStored)
+				}
+			}
+		})
+	}
+}
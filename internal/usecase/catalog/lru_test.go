@@ -0,0 +1,75 @@
+package catalog
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestLRU(t *testing.T) {
+	type op struct {
+		put string // ключ для put, иначе get
+		get string
+	}
+	tests := []struct {
+		name string
+		size int
+		ops  []op
+		want []string // ключи, оставшиеся в кэше
+	}{
+		{
+			name: "within size",
+			size: 3,
+			ops:  []op{{put: "a"}, {put: "b"}, {put: "c"}},
+			want: []string{"a", "b", "c"},
+		},
+		{
+			name: "evicts least recently put",
+			size: 2,
+			ops:  []op{{put: "a"}, {put: "b"}, {put: "c"}},
+			want: []string{"b", "c"},
+		},
+		{
+			name: "get refreshes recency",
+			size: 2,
+			ops:  []op{{put: "a"}, {put: "b"}, {get: "a"}, {put: "c"}},
+			want: []string{"a", "c"},
+		},
+		{
+			name: "update refreshes recency",
+			size: 2,
+			ops:  []op{{put: "a"}, {put: "b"}, {put: "a"}, {put: "c"}},
+			want: []string{"a", "c"},
+		},
+		{
+			name: "miss does not change order",
+			size: 2,
+			ops:  []op{{put: "a"}, {put: "b"}, {get: "x"}, {put: "c"}},
+			want: []string{"b", "c"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newLRU(tt.size)
+			for _, o := range tt.ops {
+				if o.put != "" {
+					c.put(o.put, &entry{payload: json.RawMessage(`"` + o.put + `"`)})
+				} else {
+					c.get(o.get)
+				}
+			}
+			var got []string
+			for _, key := range []string{"a", "b", "c", "x"} {
+				if e, ok := c.get(key); ok {
+					if string(e.payload) != `"`+key+`"` {
+						t.Fatalf("get(%q) payload = %s", key, e.payload)
+					}
+					got = append(got, key)
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("cached keys = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
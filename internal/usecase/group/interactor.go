@@ -0,0 +1,117 @@
+package group
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/immxrtalbeast/plandstu/internal/domain"
+	"github.com/immxrtalbeast/plandstu/internal/logging"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNotFound       = domain.NotFound("group_not_found", "group not found")
+	ErrAlreadyExists  = domain.Conflict("group_exists", "group already exists")
+	ErrInvalidGroup   = domain.Validation("invalid_group", "invalid group")
+	ErrInvalidCurator = domain.Validation("invalid_curator", "curator must be a teacher")
+)
+
+type GroupInteractor struct {
+	groupRepo domain.GroupRepository
+	userRepo  domain.UserRepository
+}
+
+func NewGroupInteractor(groupRepo domain.GroupRepository, userRepo domain.UserRepository) *GroupInteractor {
+	return &GroupInteractor{groupRepo: groupRepo, userRepo: userRepo}
+}
+
+func (gi *GroupInteractor) Groups(ctx context.Context, filter domain.GroupFilter) (_ []*domain.Group, err error) {
+	const op = "uc.group.list"
+	defer logging.OnError(ctx, op, &err)
+	groups, err := gi.groupRepo.Groups(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return groups, nil
+}
+
+func (gi *GroupInteractor) Group(ctx context.Context, id uuid.UUID) (_ *domain.Group, err error) {
+	const op = "uc.group.get"
+	defer logging.OnError(ctx, op, &err)
+	group, err := gi.groupRepo.Group(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, translate(err))
+	}
+	return group, nil
+}
+
+func (gi *GroupInteractor) CreateGroup(ctx context.Context, group domain.Group) (_ *domain.Group, err error) {
+	const op = "uc.group.create"
+	defer logging.OnError(ctx, op, &err)
+	if err := gi.validate(ctx, &group); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	group.ID = uuid.Nil
+	if err := gi.groupRepo.CreateGroup(ctx, &group); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, translate(err))
+	}
+	return &group, nil
+}
+
+func (gi *GroupInteractor) UpdateGroup(ctx context.Context, group domain.Group) (_ *domain.Group, err error) {
+	const op = "uc.group.update"
+	defer logging.OnError(ctx, op, &err)
+	if err := gi.validate(ctx, &group); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := gi.groupRepo.UpdateGroup(ctx, &group); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, translate(err))
+	}
+	return &group, nil
+}
+
+func (gi *GroupInteractor) DeleteGroup(ctx context.Context, id uuid.UUID) (err error) {
+	const op = "uc.group.delete"
+	defer logging.OnError(ctx, op, &err)
+	if err := gi.groupRepo.DeleteGroup(ctx, id); err != nil {
+		return fmt.Errorf("%s: %w", op, translate(err))
+	}
+	return nil
+}
+
+func (gi *GroupInteractor) validate(ctx context.Context, group *domain.Group) error {
+	group.Name = strings.TrimSpace(group.Name)
+	if group.Name == "" {
+		return ErrInvalidGroup.WithFields(map[string]string{"name": "required"})
+	}
+	if group.Year < 0 {
+		return ErrInvalidGroup.WithFields(map[string]string{"year": "must be positive"})
+	}
+	if group.CuratorID != nil {
+		curator, err := gi.userRepo.User(ctx, *group.CuratorID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidCurator
+			}
+			return err
+		}
+		if curator.Role != domain.RoleTeacher && curator.Role != domain.RoleAdmin {
+			return ErrInvalidCurator
+		}
+	}
+	return nil
+}
+
+func translate(err error) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return ErrNotFound
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return ErrAlreadyExists
+	default:
+		return err
+	}
+}
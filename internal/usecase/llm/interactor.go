@@ -2,37 +2,181 @@ package llm
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/immxrtalbeast/plandstu/internal/domain"
+	"github.com/immxrtalbeast/plandstu/internal/logging"
 )
 
+const (
+	defaultMessagesLimit = 50
+	maxMessagesLimit     = 200
+)
+
+var (
+	ErrThreadNotFound         = domain.NotFound("chat_thread_not_found", "chat thread not found")
+	ErrThreadArchived         = domain.Conflict("chat_thread_archived", "chat thread is archived")
+	ErrBlockWithoutDiscipline = domain.Validation("roadmap_block_requires_discipline", "roadmap block requires a discipline")
+)
+
+// Conversations - история чата на стороне LLM-сервиса.
+type Conversations interface {
+	ClearHistory(ctx context.Context, userID uuid.UUID, threadID uuid.UUID) (json.RawMessage, error)
+}
+
+// LLMInteractor хранит историю чата в базе: LLM-сервис присылает её целиком
+// после каждого ответа, а чтение, поиск и выгрузка идут только из базы.
 type LLMInteractor struct {
-	userRepo domain.LLMRepository
+	llmRepo        domain.LLMRepository
+	disciplineRepo domain.DisciplineRepository
+	conversations  Conversations
 }
 
-func NewLLMInteractor(userRepo domain.LLMRepository) *LLMInteractor {
-	return &LLMInteractor{userRepo: userRepo}
+func NewLLMInteractor(llmRepo domain.LLMRepository, disciplineRepo domain.DisciplineRepository, conversations Conversations) *LLMInteractor {
+	return &LLMInteractor{llmRepo: llmRepo, disciplineRepo: disciplineRepo, conversations: conversations}
 }
 
-func (li *LLMInteractor) SaveHistory(ctx context.Context, req domain.SaveHistoryRequest, userID uuid.UUID) (uuid.UUID, error) {
+// SaveHistory добавляет в тред сообщения, которых ещё нет в базе. Без threadID
+// история пишется в тред по умолчанию. Возвращает число новых сообщений.
+func (li *LLMInteractor) SaveHistory(ctx context.Context, req domain.SaveHistoryRequest, userID uuid.UUID, threadID uuid.UUID) (_ int64, err error) {
 	const op = "uc.llm.save"
-	messagesJSON, err := json.Marshal(req.History)
+	defer logging.OnError(ctx, op, &err)
+	var thread *domain.ChatThread
+	if threadID == uuid.Nil {
+		thread, err = li.defaultThread(ctx, userID)
+	} else {
+		thread, err = li.Thread(ctx, userID, threadID)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	messages := make([]domain.ChatMessage, 0, len(req.History))
+	sentAt := newSentAtClock(thread.CreatedAt)
+	for _, m := range req.History {
+		metadata := map[string]any{}
+		if len(m.AdditionalKwargs) > 0 {
+			metadata["additional_kwargs"] = m.AdditionalKwargs
+		}
+		if len(m.ResponseMetadata) > 0 {
+			metadata["response_metadata"] = m.ResponseMetadata
+		}
+		if m.Example {
+			metadata["example"] = true
+		}
+		metadataJSON, err := json.Marshal(metadata)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+		hash := sha256.Sum256([]byte(m.Content))
+		messages = append(messages, domain.ChatMessage{
+			UserID:      userID,
+			ThreadID:    thread.ID,
+			Role:        m.Type,
+			Content:     m.Content,
+			Metadata:    metadataJSON,
+			ContentHash: hex.EncodeToString(hash[:]),
+			SentAt:      sentAt.next(m.Timestamp),
+		})
+	}
+	saved, err := li.llmRepo.SaveMessages(ctx, thread.ID, messages)
 	if err != nil {
-		return uuid.Nil, fmt.Errorf("%s: %w", op, err)
+		return 0, fmt.Errorf("%s: %w", op, err)
 	}
+	return saved, nil
+}
 
-	history := domain.History{
-		UserID:        userID,
-		CreatedAt:     time.Now(),
-		MessagesJSONB: messagesJSON,
+func (li *LLMInteractor) ChatHistory(ctx context.Context, userID uuid.UUID, filter domain.ChatFilter) (_ []*domain.ChatMessage, _ int64, err error) {
+	const op = "uc.llm.history"
+	defer logging.OnError(ctx, op, &err)
+	filter.Limit = clampLimit(filter.Limit)
+	messages, total, err := li.llmRepo.Messages(ctx, userID, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
 	}
-	id, err := li.userRepo.SaveHistory(ctx, &history)
+	return messages, total, nil
+}
+
+func (li *LLMInteractor) ExportHistory(ctx context.Context, userID uuid.UUID, threadID uuid.UUID) (_ []*domain.ChatMessage, err error) {
+	const op = "uc.llm.export"
+	defer logging.OnError(ctx, op, &err)
+	messages, err := li.llmRepo.AllMessages(ctx, userID, threadID)
 	if err != nil {
-		return uuid.Nil, fmt.Errorf("%s: %w", op, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return messages, nil
+}
+
+// ClearHistory удаляет историю из базы, затем очищает контекст в LLM-сервисе,
+// чтобы модель не помнила удалённые сообщения. uuid.Nil - все треды.
+func (li *LLMInteractor) ClearHistory(ctx context.Context, userID uuid.UUID, threadID uuid.UUID) (err error) {
+	const op = "uc.llm.clear"
+	defer logging.OnError(ctx, op, &err)
+	threadIDs := []uuid.UUID{threadID}
+	if threadID == uuid.Nil {
+		threadIDs, err = li.llmRepo.ThreadIDs(ctx, userID)
+	} else {
+		_, err = li.Thread(ctx, userID, threadID)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := li.llmRepo.DeleteMessages(ctx, userID, threadID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	for _, id := range threadIDs {
+		li.forgetRemote(ctx, userID, id)
+	}
+	return nil
+}
+
+// forgetRemote очищает тред в LLM-сервисе. Ошибка только логируется: история
+// в базе уже удалена, а если сервис пришлёт её снова, уникальный индекс с
+// учётом удалённых строк не даст сообщениям вернуться.
+func (li *LLMInteractor) forgetRemote(ctx context.Context, userID uuid.UUID, threadID uuid.UUID) {
+	if _, err := li.conversations.ClearHistory(ctx, userID, threadID); err != nil {
+		logging.FromContext(ctx).Warn("llm history clear failed",
+			slog.String("user_id", userID.String()), slog.String("thread_id", threadID.String()), logging.Err(err))
+	}
+}
+
+// sentAtClock подставляет время сообщениям, которые LLM-сервис прислал без
+// timestamp. Время приёма не подходит: история присылается целиком после
+// каждого ответа, и каждая повторная выгрузка дала бы новые строки. Поэтому
+// такое сообщение получает время предыдущего сообщения (или создания треда)
+// плюс столько микросекунд, сколько сообщений без времени идёт подряд. При
+// повторной выгрузке значения те же, а одинаковые сообщения не схлопываются
+// по уникальному индексу (thread_id, sent_at, role, content_hash).
+type sentAtClock struct {
+	last time.Time
+	gap  time.Duration
+}
+
+func newSentAtClock(start time.Time) *sentAtClock {
+	return &sentAtClock{last: start.UTC().Truncate(time.Microsecond)}
+}
+
+func (c *sentAtClock) next(ts time.Time) time.Time {
+	if !ts.IsZero() {
+		c.last, c.gap = ts.UTC().Truncate(time.Microsecond), 0
+		return ts.UTC()
+	}
+	// Postgres хранит время с точностью до микросекунды
+	c.gap += time.Microsecond
+	return c.last.Add(c.gap)
+}
+
+func clampLimit(limit int) int {
+	if limit <= 0 {
+		return defaultMessagesLimit
+	}
+	if limit > maxMessagesLimit {
+		return maxMessagesLimit
 	}
-	return id, nil
+	return limit
 }
@@ -0,0 +1,184 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/immxrtalbeast/plandstu/internal/domain"
+)
+
+func TestSaveHistory(t *testing.T) {
+	userID := uuid.New()
+	thread := &domain.ChatThread{ID: uuid.New(), UserID: userID, CreatedAt: time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)}
+
+	tests := []struct {
+		name    string
+		history string
+		want    []time.Time // sent_at сохранённых сообщений по порядку
+	}{
+		{
+			name: "timestamps are kept",
+			history: `[
+				{"type":"human","content":"hi","timestamp":"2026-01-02T10:00:00Z"},
+				{"type":"ai","content":"hello","timestamp":"2026-01-02T10:00:01Z"}
+			]`,
+			want: []time.Time{
+				time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC),
+				time.Date(2026, 1, 2, 10, 0, 1, 0, time.UTC),
+			},
+		},
+		{
+			name: "identical messages without timestamps are not collapsed",
+			history: `[
+				{"type":"human","content":"ok"},
+				{"type":"ai","content":"sure"},
+				{"type":"human","content":"ok"},
+				{"type":"ai","content":"sure"}
+			]`,
+			want: []time.Time{
+				thread.CreatedAt.Add(time.Microsecond),
+				thread.CreatedAt.Add(2 * time.Microsecond),
+				thread.CreatedAt.Add(3 * time.Microsecond),
+				thread.CreatedAt.Add(4 * time.Microsecond),
+			},
+		},
+		{
+			name: "missing timestamp follows previous message",
+			history: `[
+				{"type":"human","content":"hi","timestamp":"2026-01-02T10:00:00.5000007Z"},
+				{"type":"ai","content":"hello"},
+				{"type":"human","content":"bye","timestamp":"2026-01-02T10:01:00Z"},
+				{"type":"ai","content":"bye"}
+			]`,
+			want: []time.Time{
+				time.Date(2026, 1, 2, 10, 0, 0, 500000700, time.UTC),
+				time.Date(2026, 1, 2, 10, 0, 0, 500001000, time.UTC),
+				time.Date(2026, 1, 2, 10, 1, 0, 0, time.UTC),
+				time.Date(2026, 1, 2, 10, 1, 0, 1000, time.UTC),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req domain.SaveHistoryRequest
+			if err := json.Unmarshal([]byte(`{"history":`+tt.history+`}`), &req); err != nil {
+				t.Fatal(err)
+			}
+			repo := &memoryLLMRepo{threads: map[uuid.UUID]*domain.ChatThread{thread.ID: thread}}
+			li := NewLLMInteractor(repo, nil, nil)
+
+			saved, err := li.SaveHistory(context.Background(), req, userID, thread.ID)
+			if err != nil {
+				t.Fatalf("SaveHistory() error = %v", err)
+			}
+			if saved != int64(len(tt.want)) {
+				t.Fatalf("saved = %d, want %d", saved, len(tt.want))
+			}
+			for i, m := range repo.messages {
+				if !m.SentAt.Equal(tt.want[i]) {
+					t.Errorf("message %d sent_at = %s, want %s", i, m.SentAt.Format(time.RFC3339Nano), tt.want[i].Format(time.RFC3339Nano))
+				}
+			}
+
+			// LLM-сервис присылает историю целиком после каждого ответа
+			again, err := li.SaveHistory(context.Background(), req, userID, thread.ID)
+			if err != nil {
+				t.Fatalf("second SaveHistory() error = %v", err)
+			}
+			if again != 0 {
+				t.Fatalf("resend saved %d messages, want 0", again)
+			}
+		})
+	}
+}
+
+func TestClearHistory(t *testing.T) {
+	userID := uuid.New()
+	first := &domain.ChatThread{ID: uuid.New(), UserID: userID}
+	second := &domain.ChatThread{ID: uuid.New(), UserID: userID}
+	var req domain.SaveHistoryRequest
+	if err := json.Unmarshal([]byte(`{"history":[{"type":"human","content":"hi","timestamp":"2026-01-02T10:00:00Z"}]}`), &req); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		threadID  uuid.UUID
+		remoteErr error
+		wantLive  int
+		wantClear int
+	}{
+		{name: "one thread", threadID: first.ID, wantLive: 1, wantClear: 1},
+		{name: "all threads", threadID: uuid.Nil, wantLive: 0, wantClear: 2},
+		{name: "llm unavailable", threadID: first.ID, remoteErr: errors.New("llm is down"), wantLive: 1, wantClear: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &memoryLLMRepo{threads: map[uuid.UUID]*domain.ChatThread{first.ID: first, second.ID: second}}
+			conversations := &fakeConversations{err: tt.remoteErr}
+			li := NewLLMInteractor(repo, nil, conversations)
+			for _, thread := range []*domain.ChatThread{first, second} {
+				if _, err := li.SaveHistory(context.Background(), req, userID, thread.ID); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := li.ClearHistory(context.Background(), userID, tt.threadID); err != nil {
+				t.Fatalf("ClearHistory() error = %v", err)
+			}
+			if got := repo.live(); got != tt.wantLive {
+				t.Fatalf("live messages = %d, want %d", got, tt.wantLive)
+			}
+			if len(conversations.cleared) != tt.wantClear {
+				t.Fatalf("remote clears = %v, want %d", conversations.cleared, tt.wantClear)
+			}
+
+			// Сервис, не успевший очистить историю, присылает её снова
+			if _, err := li.SaveHistory(context.Background(), req, userID, first.ID); err != nil {
+				t.Fatal(err)
+			}
+			if got := repo.live(); got != tt.wantLive {
+				t.Fatalf("live messages after resend = %d, want %d", got, tt.wantLive)
+			}
+		})
+	}
+}
+
+func TestDeleteThread(t *testing.T) {
+	userID := uuid.New()
+	threadID := uuid.New()
+
+	tests := []struct {
+		name      string
+		userID    uuid.UUID
+		remoteErr error
+		wantErr   error
+		wantClear int
+	}{
+		{name: "deleted", userID: userID, wantClear: 1},
+		{name: "llm unavailable", userID: userID, remoteErr: errors.New("llm is down"), wantClear: 1},
+		{name: "foreign thread", userID: uuid.New(), wantErr: ErrThreadNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &memoryLLMRepo{threads: map[uuid.UUID]*domain.ChatThread{threadID: {ID: threadID, UserID: userID}}}
+			conversations := &fakeConversations{err: tt.remoteErr}
+			li := NewLLMInteractor(repo, nil, conversations)
+
+			err := li.DeleteThread(context.Background(), tt.userID, threadID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("DeleteThread() error = %v, want %v", err, tt.wantErr)
+			}
+			if len(conversations.cleared) != tt.wantClear {
+				t.Fatalf("remote clears = %v, want %d", conversations.cleared, tt.wantClear)
+			}
+			if _, ok := repo.threads[threadID]; ok == (tt.wantErr == nil) {
+				t.Fatalf("thread present = %v after DeleteThread() = %v", ok, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/immxrtalbeast/plandstu/internal/domain"
+	"gorm.io/gorm"
+)
+
+// memoryLLMRepo - LLMRepository в памяти. Как и в Postgres, сообщение
+// определяется (thread_id, sent_at, role, content_hash), удалённые тоже учитываются.
+type memoryLLMRepo struct {
+	domain.LLMRepository
+
+	threads  map[uuid.UUID]*domain.ChatThread
+	messages []domain.ChatMessage
+}
+
+type messageKey struct {
+	threadID uuid.UUID
+	sentAt   time.Time
+	role     string
+	hash     string
+}
+
+func (r *memoryLLMRepo) SaveMessages(ctx context.Context, threadID uuid.UUID, messages []domain.ChatMessage) (int64, error) {
+	seen := make(map[messageKey]bool)
+	for _, m := range r.messages {
+		seen[messageKey{m.ThreadID, m.SentAt.Round(time.Microsecond), m.Role, m.ContentHash}] = true
+	}
+	var saved int64
+	for _, m := range messages {
+		key := messageKey{m.ThreadID, m.SentAt.Round(time.Microsecond), m.Role, m.ContentHash}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		r.messages = append(r.messages, m)
+		saved++
+	}
+	return saved, nil
+}
+
+func (r *memoryLLMRepo) Thread(ctx context.Context, userID uuid.UUID, threadID uuid.UUID) (*domain.ChatThread, error) {
+	thread, ok := r.threads[threadID]
+	if !ok || thread.UserID != userID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *thread
+	return &copied, nil
+}
+
+func (r *memoryLLMRepo) DeleteMessages(ctx context.Context, userID uuid.UUID, threadID uuid.UUID) error {
+	for i := range r.messages {
+		m := &r.messages[i]
+		if m.UserID == userID && (threadID == uuid.Nil || m.ThreadID == threadID) {
+			m.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+		}
+	}
+	return nil
+}
+
+func (r *memoryLLMRepo) ThreadIDs(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	for id, thread := range r.threads {
+		if thread.UserID == userID {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (r *memoryLLMRepo) DeleteThread(ctx context.Context, userID uuid.UUID, threadID uuid.UUID) error {
+	thread, ok := r.threads[threadID]
+	if !ok || thread.UserID != userID {
+		return gorm.ErrRecordNotFound
+	}
+	delete(r.threads, threadID)
+	return nil
+}
+
+// live - сообщения, которые не удалены.
+func (r *memoryLLMRepo) live() int {
+	n := 0
+	for _, m := range r.messages {
+		if !m.DeletedAt.Valid {
+			n++
+		}
+	}
+	return n
+}
+
+// fakeConversations запоминает очищенные треды и может падать, как недоступный LLM-сервис.
+type fakeConversations struct {
+	err     error
+	cleared []uuid.UUID
+}
+
+func (f *fakeConversations) ClearHistory(ctx context.Context, userID uuid.UUID, threadID uuid.UUID) (json.RawMessage, error) {
+	f.cleared = append(f.cleared, threadID)
+	return nil, f.err
+}
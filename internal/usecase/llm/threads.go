@@ -0,0 +1,153 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/immxrtalbeast/plandstu/internal/domain"
+	"github.com/immxrtalbeast/plandstu/internal/logging"
+	"gorm.io/gorm"
+)
+
+// ChatThread возвращает тред, в который пойдёт новое сообщение. В архивный тред писать нельзя.
+func (li *LLMInteractor) ChatThread(ctx context.Context, userID uuid.UUID, threadID uuid.UUID) (_ *domain.ChatThread, err error) {
+	const op = "uc.llm.chat_thread"
+	defer logging.OnError(ctx, op, &err)
+	if threadID == uuid.Nil {
+		thread, err := li.defaultThread(ctx, userID)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		return thread, nil
+	}
+	thread, err := li.Thread(ctx, userID, threadID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if thread.ArchivedAt != nil {
+		return nil, fmt.Errorf("%s: %w", op, ErrThreadArchived)
+	}
+	return thread, nil
+}
+
+func (li *LLMInteractor) Threads(ctx context.Context, userID uuid.UUID, filter domain.ChatThreadFilter) (_ []*domain.ChatThread, _ int64, err error) {
+	const op = "uc.llm.threads"
+	defer logging.OnError(ctx, op, &err)
+	filter.Limit = clampLimit(filter.Limit)
+	threads, total, err := li.llmRepo.Threads(ctx, userID, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return threads, total, nil
+}
+
+func (li *LLMInteractor) Thread(ctx context.Context, userID uuid.UUID, threadID uuid.UUID) (_ *domain.ChatThread, err error) {
+	const op = "uc.llm.thread"
+	defer logging.OnError(ctx, op, &err)
+	thread, err := li.llmRepo.Thread(ctx, userID, threadID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrThreadNotFound
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return thread, nil
+}
+
+func (li *LLMInteractor) CreateThread(ctx context.Context, userID uuid.UUID, title string, disciplineID *int, roadmapBlock string) (_ *domain.ChatThread, err error) {
+	const op = "uc.llm.create_thread"
+	defer logging.OnError(ctx, op, &err)
+	roadmapBlock = strings.TrimSpace(roadmapBlock)
+	if disciplineID == nil && roadmapBlock != "" {
+		return nil, fmt.Errorf("%s: %w", op, ErrBlockWithoutDiscipline)
+	}
+	if disciplineID != nil {
+		if _, err := li.disciplineRepo.Discipline(ctx, *disciplineID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, fmt.Errorf("%s: %w", op, domain.ErrUnknownDiscipline)
+			}
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	thread := &domain.ChatThread{
+		UserID:       userID,
+		Title:        threadTitle(title),
+		DisciplineID: disciplineID,
+		RoadmapBlock: roadmapBlock,
+	}
+	if err := li.llmRepo.CreateThread(ctx, thread); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return thread, nil
+}
+
+func (li *LLMInteractor) UpdateThread(ctx context.Context, userID uuid.UUID, threadID uuid.UUID, update domain.ChatThreadUpdate) (_ *domain.ChatThread, err error) {
+	const op = "uc.llm.update_thread"
+	defer logging.OnError(ctx, op, &err)
+	thread, err := li.Thread(ctx, userID, threadID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if update.Title != nil {
+		thread.Title = threadTitle(*update.Title)
+	}
+	if update.Archived != nil {
+		switch {
+		case *update.Archived && thread.ArchivedAt == nil:
+			now := time.Now()
+			thread.ArchivedAt = &now
+		case !*update.Archived:
+			thread.ArchivedAt = nil
+		}
+	}
+	if err := li.llmRepo.UpdateThread(ctx, thread); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrThreadNotFound
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return thread, nil
+}
+
+// DeleteThread удаляет тред с сообщениями, затем очищает его в LLM-сервисе.
+// История, присланная сервисом для удалённого треда, уже не сохранится.
+func (li *LLMInteractor) DeleteThread(ctx context.Context, userID uuid.UUID, threadID uuid.UUID) (err error) {
+	const op = "uc.llm.delete_thread"
+	defer logging.OnError(ctx, op, &err)
+	if err := li.llmRepo.DeleteThread(ctx, userID, threadID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrThreadNotFound
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	li.forgetRemote(ctx, userID, threadID)
+	return nil
+}
+
+// defaultThread - последний активный тред пользователя, при отсутствии создаётся новый.
+func (li *LLMInteractor) defaultThread(ctx context.Context, userID uuid.UUID) (*domain.ChatThread, error) {
+	thread, err := li.llmRepo.LatestThread(ctx, userID)
+	if err == nil {
+		return thread, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	thread = &domain.ChatThread{UserID: userID, Title: domain.DefaultThreadTitle}
+	if err := li.llmRepo.CreateThread(ctx, thread); err != nil {
+		return nil, err
+	}
+	return thread, nil
+}
+
+func threadTitle(title string) string {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return domain.DefaultThreadTitle
+	}
+	return title
+}
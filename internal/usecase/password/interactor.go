@@ -0,0 +1,201 @@
+package password
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/immxrtalbeast/plandstu/internal/domain"
+	"github.com/immxrtalbeast/plandstu/internal/lib"
+	"github.com/immxrtalbeast/plandstu/internal/logging"
+	"github.com/immxrtalbeast/plandstu/internal/notify"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// resetCooldown - не чаще одного письма со сбросом пароля на пользователя,
+// чтобы сбросом нельзя было заваливать чужую почту.
+const resetCooldown = 15 * time.Minute
+
+var (
+	ErrNotFound          = domain.NotFound("user_not_found", "user not found")
+	ErrWrongPassword     = domain.Validation("wrong_password", "current password is incorrect")
+	ErrSamePassword      = domain.Validation("same_password", "new password must differ from the current one")
+	ErrInvalidResetToken = domain.Validation("invalid_reset_token", "reset token is invalid, used or expired")
+)
+
+// Notifier доставляет письмо пользователю.
+type Notifier interface {
+	Send(ctx context.Context, msg notify.Message) error
+}
+
+type PasswordInteractor struct {
+	userRepo    domain.UserRepository
+	sessionRepo domain.SessionRepository
+	loginRepo   domain.LoginRepository
+	resetRepo   domain.PasswordResetRepository
+	notifier    Notifier
+	resetTTL    time.Duration
+	resetURL    string
+}
+
+// NewPasswordInteractor создаёт интерактор. В письмо уходит resetURL с токеном в параметре token.
+func NewPasswordInteractor(userRepo domain.UserRepository, sessionRepo domain.SessionRepository, loginRepo domain.LoginRepository, resetRepo domain.PasswordResetRepository, notifier Notifier, resetTTL time.Duration, resetURL string) *PasswordInteractor {
+	return &PasswordInteractor{
+		userRepo:    userRepo,
+		sessionRepo: sessionRepo,
+		loginRepo:   loginRepo,
+		resetRepo:   resetRepo,
+		notifier:    notifier,
+		resetTTL:    resetTTL,
+		resetURL:    resetURL,
+	}
+}
+
+// ChangePassword меняет пароль по текущему паролю и отзывает все сессии и выданные токены.
+func (pi *PasswordInteractor) ChangePassword(ctx context.Context, userID uuid.UUID, oldPass string, newPass string) (err error) {
+	const op = "uc.password.change"
+	defer logging.OnError(ctx, op, &err)
+	user, err := pi.userRepo.User(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%s: %w", op, ErrNotFound)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := bcrypt.CompareHashAndPassword(user.PassHash, []byte(oldPass)); err != nil {
+		return fmt.Errorf("%s: %w", op, ErrWrongPassword)
+	}
+	if oldPass == newPass {
+		return fmt.Errorf("%s: %w", op, ErrSamePassword)
+	}
+	if err := pi.setPassword(ctx, userID, newPass); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// RequestReset отправляет письмо со ссылкой сброса. login может быть логином
+// или почтой. Если пользователь не найден или у него нет почты, ничего не
+// происходит: ответ не должен выдавать, существует ли учётная запись.
+func (pi *PasswordInteractor) RequestReset(ctx context.Context, login string) (err error) {
+	const op = "uc.password.request_reset"
+	defer logging.OnError(ctx, op, &err)
+	user, err := pi.userByLoginOrEmail(ctx, login)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	log := logging.FromContext(ctx).With(slog.String("user_id", user.ID.String()))
+	if user.Email == nil || user.Disabled {
+		log.Info("password reset skipped: no email or user disabled")
+		return nil
+	}
+	recent, err := pi.resetRepo.ResetRequestedSince(ctx, user.ID, time.Now().Add(-resetCooldown))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if recent {
+		return nil
+	}
+
+	token, hash, err := lib.NewOpaqueToken()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	expiresAt := time.Now().Add(pi.resetTTL)
+	if err := pi.resetRepo.CreateResetToken(ctx, &domain.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: hash,
+		ExpiresAt: expiresAt,
+	}); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	msg, err := pi.resetMessage(*user.Email, token, expiresAt)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	// Отправка может занять секунды, а время ответа не должно выдавать наличие учётной записи
+	go func() {
+		ctx := context.WithoutCancel(ctx)
+		if err := pi.notifier.Send(ctx, msg); err != nil {
+			log.Error("failed to send password reset", logging.Err(err))
+		}
+	}()
+	return nil
+}
+
+// Reset задаёт новый пароль по токену из письма и снимает блокировку после неудачных входов.
+func (pi *PasswordInteractor) Reset(ctx context.Context, token string, newPass string) (err error) {
+	const op = "uc.password.reset"
+	defer logging.OnError(ctx, op, &err)
+	reset, err := pi.resetRepo.UseResetToken(ctx, lib.HashToken(token))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%s: %w", op, ErrInvalidResetToken)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	user, err := pi.userRepo.User(ctx, reset.UserID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := pi.setPassword(ctx, user.ID, newPass); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := pi.loginRepo.LockUser(ctx, user.ID, nil); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := pi.loginRepo.ResetFailures(ctx, domain.LoginThrottleLogin, strings.ToLower(user.Login)); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// setPassword сохраняет пароль, отзывает все сессии, выданные access-токены и оставшиеся токены сброса.
+func (pi *PasswordInteractor) setPassword(ctx context.Context, userID uuid.UUID, newPass string) error {
+	passHash, err := bcrypt.GenerateFromPassword([]byte(newPass), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	if err := pi.userRepo.UpdatePassword(ctx, userID, passHash); err != nil {
+		return err
+	}
+	if err := pi.sessionRepo.RevokeUserSessions(ctx, userID); err != nil {
+		return err
+	}
+	return pi.resetRepo.DeleteResetTokens(ctx, userID)
+}
+
+func (pi *PasswordInteractor) userByLoginOrEmail(ctx context.Context, login string) (*domain.User, error) {
+	user, err := pi.userRepo.UserByLogin(ctx, login)
+	if !errors.Is(err, gorm.ErrRecordNotFound) || !strings.Contains(login, "@") {
+		return user, err
+	}
+	return pi.userRepo.UserByEmail(ctx, strings.ToLower(strings.TrimSpace(login)))
+}
+
+func (pi *PasswordInteractor) resetMessage(to string, token string, expiresAt time.Time) (notify.Message, error) {
+	link, err := url.Parse(pi.resetURL)
+	if err != nil {
+		return notify.Message{}, err
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+	return notify.Message{
+		To:      to,
+		Subject: "Сброс пароля",
+		Body: fmt.Sprintf("Чтобы задать новый пароль, перейдите по ссылке:\n%s\n\n"+
+			"Ссылка одноразовая и действует до %s UTC.\n"+
+			"Если вы не запрашивали сброс пароля, просто проигнорируйте это письмо.\n",
+			link.String(), expiresAt.UTC().Format("02.01.2006 15:04")),
+	}, nil
+}
@@ -0,0 +1,257 @@
+package password
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/immxrtalbeast/plandstu/internal/domain"
+	"github.com/immxrtalbeast/plandstu/internal/notify"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const resetURL = "https://plandstu.example/reset"
+
+type passwordFixture struct {
+	users    *memoryUserRepo
+	sessions *memorySessionRepo
+	logins   *memoryLoginRepo
+	resets   *memoryResetRepo
+	notifier *fakeNotifier
+	pi       *PasswordInteractor
+}
+
+func newPasswordFixture(t *testing.T, users ...*domain.User) *passwordFixture {
+	t.Helper()
+	f := &passwordFixture{
+		users:    &memoryUserRepo{users: make(map[uuid.UUID]*domain.User)},
+		sessions: &memorySessionRepo{revoked: make(map[uuid.UUID]bool)},
+		resets:   &memoryResetRepo{},
+		notifier: &fakeNotifier{sent: make(chan notify.Message, 10)},
+	}
+	for _, user := range users {
+		f.users.users[user.ID] = user
+	}
+	f.logins = &memoryLoginRepo{users: f.users, throttles: make(map[string]bool)}
+	f.pi = NewPasswordInteractor(f.users, f.sessions, f.logins, f.resets, f.notifier, time.Hour, resetURL)
+	return f
+}
+
+// mail ждёт письмо, которое RequestReset отправляет в фоне.
+func (f *passwordFixture) mail(t *testing.T) notify.Message {
+	t.Helper()
+	select {
+	case msg := <-f.notifier.sent:
+		return msg
+	case <-time.After(time.Second):
+		t.Fatal("reset mail was not sent")
+		return notify.Message{}
+	}
+}
+
+// noMail проверяет, что письмо не отправлено.
+func (f *passwordFixture) noMail(t *testing.T) {
+	t.Helper()
+	select {
+	case msg := <-f.notifier.sent:
+		t.Fatalf("unexpected mail to %s", msg.To)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func newTestUser(t *testing.T, login string, pass string, email string) *domain.User {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte(pass), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &domain.User{ID: uuid.New(), Login: login, PassHash: hash}
+	if email != "" {
+		user.Email = &email
+	}
+	return user
+}
+
+func checkPassword(t *testing.T, user *domain.User, pass string) {
+	t.Helper()
+	if err := bcrypt.CompareHashAndPassword(user.PassHash, []byte(pass)); err != nil {
+		t.Fatalf("password of %s is not %q", user.Login, pass)
+	}
+}
+
+func TestChangePassword(t *testing.T) {
+	tests := []struct {
+		name    string
+		unknown bool
+		oldPass string
+		newPass string
+		wantErr error
+	}{
+		{
+			name:    "ok",
+			oldPass: "old-pass",
+			newPass: "new-pass",
+		},
+		{
+			name:    "wrong current password",
+			oldPass: "nope",
+			newPass: "new-pass",
+			wantErr: ErrWrongPassword,
+		},
+		{
+			name:    "same password",
+			oldPass: "old-pass",
+			newPass: "old-pass",
+			wantErr: ErrSamePassword,
+		},
+		{
+			name:    "unknown user",
+			unknown: true,
+			oldPass: "old-pass",
+			newPass: "new-pass",
+			wantErr: ErrNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := newTestUser(t, "ivan", "old-pass", "ivan@example.com")
+			f := newPasswordFixture(t, user)
+			f.resets.tokens = []*domain.PasswordResetToken{
+				{UserID: user.ID, TokenHash: "pending", ExpiresAt: time.Now().Add(time.Hour)},
+			}
+			userID := user.ID
+			if tt.unknown {
+				userID = uuid.New()
+			}
+
+			err := f.pi.ChangePassword(context.Background(), userID, tt.oldPass, tt.newPass)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ChangePassword() error = %v, want %v", err, tt.wantErr)
+				}
+				checkPassword(t, user, "old-pass")
+				if f.sessions.revoked[user.ID] || len(f.resets.tokens) != 1 {
+					t.Fatal("sessions or reset tokens changed after failed change")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ChangePassword() error = %v", err)
+			}
+			checkPassword(t, user, tt.newPass)
+			if !f.sessions.revoked[user.ID] {
+				t.Fatal("sessions were not revoked")
+			}
+			if len(f.resets.tokens) != 0 {
+				t.Fatal("pending reset tokens were not deleted")
+			}
+		})
+	}
+}
+
+func TestRequestReset(t *testing.T) {
+	tests := []struct {
+		name     string
+		login    string
+		noEmail  bool
+		disabled bool
+		wantMail bool
+	}{
+		{name: "by login", login: "Ivan", wantMail: true},
+		{name: "by email", login: " Ivan@Example.com ", wantMail: true},
+		{name: "unknown login", login: "petr"},
+		{name: "unknown email", login: "petr@example.com"},
+		{name: "no email", login: "ivan", noEmail: true},
+		{name: "disabled user", login: "ivan", disabled: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			email := "ivan@example.com"
+			if tt.noEmail {
+				email = ""
+			}
+			user := newTestUser(t, "ivan", "old-pass", email)
+			user.Disabled = tt.disabled
+			f := newPasswordFixture(t, user)
+
+			if err := f.pi.RequestReset(context.Background(), tt.login); err != nil {
+				t.Fatalf("RequestReset() error = %v", err)
+			}
+			if !tt.wantMail {
+				f.noMail(t)
+				if len(f.resets.tokens) != 0 {
+					t.Fatal("reset token created without mail")
+				}
+				return
+			}
+			msg := f.mail(t)
+			if msg.To != email {
+				t.Fatalf("mail sent to %q, want %q", msg.To, email)
+			}
+			if resetToken(t, msg) == "" {
+				t.Fatalf("no token in reset link: %q", msg.Body)
+			}
+
+			// Повторный запрос в пределах resetCooldown письма не шлёт
+			if err := f.pi.RequestReset(context.Background(), tt.login); err != nil {
+				t.Fatalf("second RequestReset() error = %v", err)
+			}
+			f.noMail(t)
+		})
+	}
+}
+
+func TestReset(t *testing.T) {
+	user := newTestUser(t, "Ivan", "old-pass", "ivan@example.com")
+	lockedUntil := time.Now().Add(time.Hour)
+	user.LockedUntil = &lockedUntil
+	f := newPasswordFixture(t, user)
+	f.logins.throttles[domain.LoginThrottleLogin+":ivan"] = true
+
+	if err := f.pi.RequestReset(context.Background(), "ivan"); err != nil {
+		t.Fatalf("RequestReset() error = %v", err)
+	}
+	token := resetToken(t, f.mail(t))
+
+	if err := f.pi.Reset(context.Background(), "nope", "new-pass"); !errors.Is(err, ErrInvalidResetToken) {
+		t.Fatalf("Reset() with unknown token error = %v, want %v", err, ErrInvalidResetToken)
+	}
+	if err := f.pi.Reset(context.Background(), token, "new-pass"); err != nil {
+		t.Fatalf("Reset() error = %v", err)
+	}
+	checkPassword(t, user, "new-pass")
+	if user.LockedUntil != nil {
+		t.Fatal("lock was not cleared")
+	}
+	if f.logins.throttles[domain.LoginThrottleLogin+":ivan"] {
+		t.Fatal("login failures were not reset")
+	}
+	if !f.sessions.revoked[user.ID] {
+		t.Fatal("sessions were not revoked")
+	}
+
+	// Токен одноразовый
+	if err := f.pi.Reset(context.Background(), token, "other-pass"); !errors.Is(err, ErrInvalidResetToken) {
+		t.Fatalf("Reset() with used token error = %v, want %v", err, ErrInvalidResetToken)
+	}
+	checkPassword(t, user, "new-pass")
+}
+
+func TestResetExpiredToken(t *testing.T) {
+	user := newTestUser(t, "ivan", "old-pass", "ivan@example.com")
+	f := newPasswordFixture(t, user)
+	if err := f.pi.RequestReset(context.Background(), "ivan"); err != nil {
+		t.Fatalf("RequestReset() error = %v", err)
+	}
+	token := resetToken(t, f.mail(t))
+	for _, reset := range f.resets.tokens {
+		reset.ExpiresAt = time.Now().Add(-time.Minute)
+	}
+
+	if err := f.pi.Reset(context.Background(), token, "new-pass"); !errors.Is(err, ErrInvalidResetToken) {
+		t.Fatalf("Reset() error = %v, want %v", err, ErrInvalidResetToken)
+	}
+	checkPassword(t, user, "old-pass")
+}
@@ -0,0 +1,150 @@
+package password
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/immxrtalbeast/plandstu/internal/domain"
+	"github.com/immxrtalbeast/plandstu/internal/notify"
+	"gorm.io/gorm"
+)
+
+// Репозитории в памяти для тестов интерактора. Реализованы только методы,
+// которые вызывают тесты, остальные паникуют через встроенный nil-интерфейс.
+
+type memoryUserRepo struct {
+	domain.UserRepository
+	users map[uuid.UUID]*domain.User
+}
+
+func (r *memoryUserRepo) User(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return &domain.User{}, gorm.ErrRecordNotFound
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (r *memoryUserRepo) UserByLogin(ctx context.Context, login string) (*domain.User, error) {
+	for _, user := range r.users {
+		if strings.EqualFold(user.Login, login) {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return &domain.User{}, gorm.ErrRecordNotFound
+}
+
+func (r *memoryUserRepo) UserByEmail(ctx context.Context, email string) (*domain.User, error) {
+	for _, user := range r.users {
+		if user.Email != nil && *user.Email == email {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return &domain.User{}, gorm.ErrRecordNotFound
+}
+
+func (r *memoryUserRepo) UpdatePassword(ctx context.Context, id uuid.UUID, passHash []byte) error {
+	r.users[id].PassHash = passHash
+	return nil
+}
+
+// memorySessionRepo запоминает, чьи сессии отозваны.
+type memorySessionRepo struct {
+	domain.SessionRepository
+	revoked map[uuid.UUID]bool
+}
+
+func (r *memorySessionRepo) RevokeUserSessions(ctx context.Context, userID uuid.UUID) error {
+	r.revoked[userID] = true
+	return nil
+}
+
+type memoryLoginRepo struct {
+	domain.LoginRepository
+	users     *memoryUserRepo
+	throttles map[string]bool
+}
+
+func (r *memoryLoginRepo) LockUser(ctx context.Context, userID uuid.UUID, until *time.Time) error {
+	r.users.users[userID].LockedUntil = until
+	return nil
+}
+
+func (r *memoryLoginRepo) ResetFailures(ctx context.Context, kind string, key string) error {
+	delete(r.throttles, kind+":"+key)
+	return nil
+}
+
+type memoryResetRepo struct {
+	domain.PasswordResetRepository
+	tokens []*domain.PasswordResetToken
+}
+
+func (r *memoryResetRepo) CreateResetToken(ctx context.Context, token *domain.PasswordResetToken) error {
+	r.DeleteResetTokens(ctx, token.UserID)
+	token.CreatedAt = time.Now()
+	r.tokens = append(r.tokens, token)
+	return nil
+}
+
+func (r *memoryResetRepo) ResetRequestedSince(ctx context.Context, userID uuid.UUID, since time.Time) (bool, error) {
+	for _, token := range r.tokens {
+		if token.UserID == userID && token.CreatedAt.After(since) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *memoryResetRepo) UseResetToken(ctx context.Context, tokenHash string) (*domain.PasswordResetToken, error) {
+	now := time.Now()
+	for _, token := range r.tokens {
+		if token.TokenHash == tokenHash && token.UsedAt == nil && token.ExpiresAt.After(now) {
+			token.UsedAt = &now
+			copied := *token
+			return &copied, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *memoryResetRepo) DeleteResetTokens(ctx context.Context, userID uuid.UUID) error {
+	kept := r.tokens[:0]
+	for _, token := range r.tokens {
+		if token.UserID != userID {
+			kept = append(kept, token)
+		}
+	}
+	r.tokens = kept
+	return nil
+}
+
+// fakeNotifier складывает письма в канал: RequestReset отправляет их в фоне.
+type fakeNotifier struct {
+	sent chan notify.Message
+}
+
+func (n *fakeNotifier) Send(ctx context.Context, msg notify.Message) error {
+	n.sent <- msg
+	return nil
+}
+
+// resetToken достаёт токен из ссылки в письме.
+func resetToken(t *testing.T, msg notify.Message) string {
+	t.Helper()
+	for _, line := range strings.Split(msg.Body, "\n") {
+		link, err := url.Parse(line)
+		if err == nil && link.Scheme != "" {
+			return link.Query().Get("token")
+		}
+	}
+	t.Fatalf("no reset link in message: %q", msg.Body)
+	return ""
+}
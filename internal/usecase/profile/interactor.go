@@ -0,0 +1,176 @@
+package profile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/immxrtalbeast/plandstu/internal/domain"
+	"github.com/immxrtalbeast/plandstu/internal/logging"
+	"github.com/immxrtalbeast/plandstu/internal/parser"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNotFound             = domain.NotFound("user_not_found", "user not found")
+	ErrUnknownFaculty       = domain.Validation("unknown_faculty", "unknown faculty")
+	ErrUnknownDirection     = domain.Validation("unknown_direction", "unknown direction")
+	ErrUnknownGroup         = domain.Validation("unknown_group", "unknown group")
+	ErrCatalogueUnavailable = domain.Upstream("parser_unavailable", "faculty catalogue is unavailable")
+	ErrEmailTaken           = domain.Conflict("email_taken", "email is already used by another account")
+)
+
+// FacultyDirectory - источник справочника факультетов и направлений (сервис-парсер).
+type FacultyDirectory interface {
+	Faculties(ctx context.Context) ([]parser.Faculty, error)
+	Faculty(ctx context.Context, id string) (*parser.Faculty, error)
+}
+
+type ProfileInteractor struct {
+	userRepo  domain.UserRepository
+	groupRepo domain.GroupRepository
+	faculties FacultyDirectory
+}
+
+func NewProfileInteractor(userRepo domain.UserRepository, groupRepo domain.GroupRepository, faculties FacultyDirectory) *ProfileInteractor {
+	return &ProfileInteractor{userRepo: userRepo, groupRepo: groupRepo, faculties: faculties}
+}
+
+func (pi *ProfileInteractor) Profile(ctx context.Context, userID uuid.UUID) (_ *domain.User, err error) {
+	const op = "uc.profile.get"
+	defer logging.OnError(ctx, op, &err)
+	user, err := pi.userRepo.User(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%s: %w", op, ErrNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return user, nil
+}
+
+// UpdateProfile проверяет факультет и направление по справочнику парсера
+// и при смене группы переносит в неё отчёты студента.
+func (pi *ProfileInteractor) UpdateProfile(ctx context.Context, userID uuid.UUID, update domain.ProfileUpdate) (_ *domain.User, err error) {
+	const op = "uc.profile.update"
+	defer logging.OnError(ctx, op, &err)
+	user, err := pi.Profile(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if update.Faculty != nil || update.Direction != nil {
+		faculty := user.Faculty
+		if update.Faculty != nil {
+			faculty = strings.TrimSpace(*update.Faculty)
+		}
+		direction := user.Direction
+		if update.Direction != nil {
+			direction = strings.TrimSpace(*update.Direction)
+		} else if update.Faculty != nil && faculty != user.Faculty {
+			// Направление прежнего факультета к новому не относится
+			direction = ""
+		}
+		user.Faculty, user.Direction, err = pi.resolve(ctx, faculty, direction)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	groupChanged := false
+	if update.GroupID != nil {
+		groupID, group, err := pi.group(ctx, *update.GroupID)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		groupChanged = !sameGroup(groupID, user.GroupID)
+		user.GroupID = groupID
+		user.Group = group
+	}
+
+	if update.Email != nil {
+		// Почта нужна для сброса пароля, храним в нижнем регистре, чтобы поиск не зависел от написания
+		user.Email = nil
+		if email := strings.ToLower(strings.TrimSpace(*update.Email)); email != "" {
+			user.Email = &email
+		}
+	}
+
+	if err := pi.userRepo.UpdateProfile(ctx, user, groupChanged); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, fmt.Errorf("%s: %w", op, ErrEmailTaken)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return user, nil
+}
+
+func (pi *ProfileInteractor) group(ctx context.Context, groupID uuid.UUID) (*uuid.UUID, string, error) {
+	if groupID == uuid.Nil {
+		return nil, "", nil
+	}
+	group, err := pi.groupRepo.Group(ctx, groupID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", ErrUnknownGroup
+		}
+		return nil, "", err
+	}
+	return &group.ID, group.Name, nil
+}
+
+func sameGroup(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// resolve находит факультет и направление по названию или ID и возвращает их каноничные названия.
+func (pi *ProfileInteractor) resolve(ctx context.Context, facultyName string, directionName string) (string, string, error) {
+	if facultyName == "" {
+		if directionName != "" {
+			return "", "", fmt.Errorf("%w: direction requires faculty", ErrUnknownDirection)
+		}
+		return "", "", nil
+	}
+	faculties, err := pi.faculties.Faculties(ctx)
+	if err != nil {
+		return "", "", fmt.Errorf("%w: %v", ErrCatalogueUnavailable, err)
+	}
+	var faculty *parser.Faculty
+	for i := range faculties {
+		if matches(faculties[i].ID, faculties[i].Name, facultyName) {
+			faculty = &faculties[i]
+			break
+		}
+	}
+	if faculty == nil {
+		return "", "", fmt.Errorf("%w: %q", ErrUnknownFaculty, facultyName)
+	}
+	if directionName == "" {
+		return faculty.Name, "", nil
+	}
+
+	directions := faculty.Directions
+	if len(directions) == 0 {
+		// В списке факультетов направления могут не приходить, берём подробную карточку
+		full, err := pi.faculties.Faculty(ctx, string(faculty.ID))
+		if err != nil {
+			return "", "", fmt.Errorf("%w: %v", ErrCatalogueUnavailable, err)
+		}
+		directions = full.Directions
+	}
+	for _, direction := range directions {
+		if matches(direction.ID, direction.Name, directionName) {
+			return faculty.Name, direction.Name, nil
+		}
+	}
+	return "", "", fmt.Errorf("%w: %q", ErrUnknownDirection, directionName)
+}
+
+func matches(id parser.ID, name string, value string) bool {
+	return string(id) == value || strings.EqualFold(strings.TrimSpace(name), value)
+}
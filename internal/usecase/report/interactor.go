@@ -7,28 +7,40 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/immxrtalbeast/plandstu/internal/domain"
+	"github.com/immxrtalbeast/plandstu/internal/logging"
 	"gorm.io/datatypes"
 	"gorm.io/gorm"
 )
 
 type ReportInteractor struct {
-	reportRepo domain.ReportRepository
+	reportRepo     domain.ReportRepository
+	disciplineRepo domain.DisciplineRepository
 }
 
-func NewReportInteractor(reportRepo domain.ReportRepository) *ReportInteractor {
-	return &ReportInteractor{reportRepo: reportRepo}
+func NewReportInteractor(reportRepo domain.ReportRepository, disciplineRepo domain.DisciplineRepository) *ReportInteractor {
+	return &ReportInteractor{reportRepo: reportRepo, disciplineRepo: disciplineRepo}
 }
 
-func (ri *ReportInteractor) CreateReport(ctx context.Context, discplineID int, resultsJSONB datatypes.JSON, userID uuid.UUID, disciplineTitle string, group string) error {
+// CreateReport сохраняет отчёт, название дисциплины берётся из справочника.
+func (ri *ReportInteractor) CreateReport(ctx context.Context, discplineID int, resultsJSONB datatypes.JSON, userID uuid.UUID, groupID *uuid.UUID, group string) (err error) {
 	const op = "uc.report.create"
+	defer logging.OnError(ctx, op, &err)
+	discipline, err := ri.disciplineRepo.Discipline(ctx, discplineID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%s: %w", op, domain.ErrUnknownDiscipline)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
 	existingReport, err := ri.reportRepo.ReportByUserAndDisciplineIDs(ctx, discplineID, userID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			report := domain.Report{
-				DisciplineTitle: disciplineTitle,
+				DisciplineTitle: discipline.Name,
 				DisciplineID:    discplineID,
 				DetailsJSONB:    resultsJSONB,
 				UserID:          userID,
+				GroupID:         groupID,
 				Group:           group,
 			}
 			if err := ri.reportRepo.CreateReport(ctx, report); err != nil {
@@ -39,6 +51,9 @@ func (ri *ReportInteractor) CreateReport(ctx context.Context, discplineID int, r
 		}
 	} else {
 		existingReport.DetailsJSONB = resultsJSONB
+		existingReport.DisciplineTitle = discipline.Name
+		existingReport.GroupID = groupID
+		existingReport.Group = group
 		if err := ri.reportRepo.UpdateReport(ctx, *existingReport); err != nil {
 			return fmt.Errorf("%s: %w", op, err)
 		}
@@ -47,8 +62,9 @@ func (ri *ReportInteractor) CreateReport(ctx context.Context, discplineID int, r
 	return nil
 }
 
-func (ri *ReportInteractor) Report(ctx context.Context, reportID uuid.UUID) (*domain.Report, error) {
+func (ri *ReportInteractor) Report(ctx context.Context, reportID uuid.UUID) (_ *domain.Report, err error) {
 	const op = "uc.report.get"
+	defer logging.OnError(ctx, op, &err)
 	report, err := ri.reportRepo.Report(ctx, reportID)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
@@ -56,8 +72,9 @@ func (ri *ReportInteractor) Report(ctx context.Context, reportID uuid.UUID) (*do
 	return report, nil
 }
 
-func (ri *ReportInteractor) ReportsByDisciplineID(ctx context.Context, disciplineID int) ([]*domain.Report, error) {
+func (ri *ReportInteractor) ReportsByDisciplineID(ctx context.Context, disciplineID int) (_ []*domain.Report, err error) {
 	const op = "uc.report.all.by.DisciplineID"
+	defer logging.OnError(ctx, op, &err)
 	reports, err := ri.reportRepo.ReportsByDisciplineID(ctx, disciplineID)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
@@ -65,8 +82,9 @@ func (ri *ReportInteractor) ReportsByDisciplineID(ctx context.Context, disciplin
 	return reports, nil
 }
 
-func (ri *ReportInteractor) ReportDisciplines(ctx context.Context) ([]domain.DisciplineResponse, error) {
+func (ri *ReportInteractor) ReportDisciplines(ctx context.Context) (_ []domain.DisciplineResponse, err error) {
 	const op = "uc.report.disciplines"
+	defer logging.OnError(ctx, op, &err)
 	disciplines, err := ri.reportRepo.ReportDisciplines(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
@@ -74,20 +92,39 @@ func (ri *ReportInteractor) ReportDisciplines(ctx context.Context) ([]domain.Dis
 	return disciplines, nil
 }
 
-func (ri *ReportInteractor) ReportGroups(ctx context.Context, disciplineName string) ([]string, error) {
+func (ri *ReportInteractor) ReportGroups(ctx context.Context, disciplineID int) (_ []*domain.Group, err error) {
 	const op = "uc.report.groups"
-	groups, err := ri.reportRepo.ReportGroups(ctx, disciplineName)
+	defer logging.OnError(ctx, op, &err)
+	if err := ri.checkDiscipline(ctx, disciplineID); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	groups, err := ri.reportRepo.ReportGroups(ctx, disciplineID)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 	return groups, nil
 }
 
-func (ri *ReportInteractor) ReportsByGroupAndDiscipline(ctx context.Context, disciplineName, group string) ([]*domain.Report, *domain.TimelineStat, error) {
+func (ri *ReportInteractor) ReportsByGroupAndDiscipline(ctx context.Context, disciplineID int, groupID uuid.UUID) (_ []*domain.Report, _ *domain.TimelineStat, err error) {
 	const op = "uc.report.reportsByGroup"
-	reports, stats, err := ri.reportRepo.ReportsByGroupAndDiscipline(ctx, disciplineName, group)
+	defer logging.OnError(ctx, op, &err)
+	if err := ri.checkDiscipline(ctx, disciplineID); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	reports, stats, err := ri.reportRepo.ReportsByGroupAndDiscipline(ctx, disciplineID, groupID)
 	if err != nil {
 		return nil, nil, fmt.Errorf("%s: %w", op, err)
 	}
 	return reports, stats, nil
 }
+
+// checkDiscipline проверяет, что дисциплина есть в справочнике.
+func (ri *ReportInteractor) checkDiscipline(ctx context.Context, disciplineID int) error {
+	if _, err := ri.disciplineRepo.Discipline(ctx, disciplineID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErrUnknownDiscipline
+		}
+		return err
+	}
+	return nil
+}
@@ -7,30 +7,40 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/immxrtalbeast/plandstu/internal/domain"
+	"github.com/immxrtalbeast/plandstu/internal/logging"
 	"gorm.io/gorm"
 )
 
 type RoadmapInteractor struct {
-	roadmapRepo domain.RoadmapRepository
-	testsRepo   domain.TestRepository
+	roadmapRepo    domain.RoadmapRepository
+	testsRepo      domain.TestRepository
+	disciplineRepo domain.DisciplineRepository
 }
 
-func NewRoadmapInteractor(roadmapRepo domain.RoadmapRepository, testsRepo domain.TestRepository) *RoadmapInteractor {
-	return &RoadmapInteractor{roadmapRepo: roadmapRepo, testsRepo: testsRepo}
+func NewRoadmapInteractor(roadmapRepo domain.RoadmapRepository, testsRepo domain.TestRepository, disciplineRepo domain.DisciplineRepository) *RoadmapInteractor {
+	return &RoadmapInteractor{roadmapRepo: roadmapRepo, testsRepo: testsRepo, disciplineRepo: disciplineRepo}
 }
 
-func (ri *RoadmapInteractor) History(ctx context.Context, userID uuid.UUID, disciplineID int) (*domain.RoadmapHistory, error) {
+func (ri *RoadmapInteractor) History(ctx context.Context, userID uuid.UUID, disciplineID int) (_ *domain.RoadmapHistory, err error) {
 	const op = "uc.roadmap.history"
+	defer logging.OnError(ctx, op, &err)
 	history, err := ri.roadmapRepo.History(ctx, userID, disciplineID)
 	return history, err
 
 }
 
-func (ri *RoadmapInteractor) CreateHistory(ctx context.Context, userID uuid.UUID, discplineID int) (*domain.RoadmapHistory, error) {
+func (ri *RoadmapInteractor) CreateHistory(ctx context.Context, userID uuid.UUID, discplineID int) (_ *domain.RoadmapHistory, err error) {
 	const op = "uc.roadmap.create_history"
-	_, err := ri.roadmapRepo.History(ctx, userID, discplineID)
+	defer logging.OnError(ctx, op, &err)
+	_, err = ri.roadmapRepo.History(ctx, userID, discplineID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if _, err := ri.disciplineRepo.Discipline(ctx, discplineID); err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return nil, fmt.Errorf("%s: %w", op, domain.ErrUnknownDiscipline)
+				}
+				return nil, fmt.Errorf("%s: %w", op, err)
+			}
 			history := domain.RoadmapHistory{
 				UserID:       userID,
 				DisciplineID: discplineID,
@@ -44,8 +54,9 @@ func (ri *RoadmapInteractor) CreateHistory(ctx context.Context, userID uuid.UUID
 	}
 }
 
-func (ri *RoadmapInteractor) Report(ctx context.Context, userID uuid.UUID, disciplineID int) ([]*domain.TestResult, error) {
+func (ri *RoadmapInteractor) Report(ctx context.Context, userID uuid.UUID, disciplineID int) (_ []*domain.TestResult, err error) {
 	const op = "uc.roadmap.report"
+	defer logging.OnError(ctx, op, &err)
 	history, err := ri.roadmapRepo.History(ctx, userID, disciplineID)
 	if err != nil {
 		return nil, fmt.Errorf("%s:%w", op, err)
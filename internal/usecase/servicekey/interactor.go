@@ -0,0 +1,216 @@
+package servicekey
+
+import (
+	"context"
+	"crypto/hmac"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/immxrtalbeast/plandstu/internal/domain"
+	"github.com/immxrtalbeast/plandstu/internal/lib"
+	"github.com/immxrtalbeast/plandstu/internal/logging"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidSignature = domain.Unauthorized("invalid_signature", "request signature is invalid")
+	ErrStaleRequest     = domain.Unauthorized("stale_request", "request timestamp is outside the allowed window")
+	ErrReplayedRequest  = domain.Unauthorized("replayed_request", "request nonce has already been used")
+	ErrScopeRequired    = domain.Forbidden("service_scope_required", "service key lacks the required scope")
+	ErrUnknownScope     = domain.Validation("unknown_service_scope", "unknown service scope")
+	ErrNotFound         = domain.NotFound("service_key_not_found", "service key not found")
+)
+
+// SecretBox шифрует секреты ключей для хранения в базе, см. lib.SecretBox.
+type SecretBox interface {
+	Seal(plaintext string, aad string) (string, error)
+	Open(sealed string, aad string) (string, error)
+	// Current сообщает, что значение зашифровано активным ключом.
+	Current(sealed string) bool
+}
+
+// ServiceKeyInteractor проверяет подписанные запросы внутренних сервисов.
+// Запрос принимается, если подпись верна, timestamp отличается от текущего
+// времени не больше чем на maxSkew, а nonce ещё не встречался.
+type ServiceKeyInteractor struct {
+	keyRepo   domain.ServiceKeyRepository
+	auditRepo domain.AuditRepository
+	secrets   SecretBox
+	maxSkew   time.Duration
+}
+
+func NewServiceKeyInteractor(keyRepo domain.ServiceKeyRepository, auditRepo domain.AuditRepository, secrets SecretBox, maxSkew time.Duration) *ServiceKeyInteractor {
+	return &ServiceKeyInteractor{keyRepo: keyRepo, auditRepo: auditRepo, secrets: secrets, maxSkew: maxSkew}
+}
+
+func (si *ServiceKeyInteractor) Authenticate(ctx context.Context, req domain.SignedRequest, scope string) (_ *domain.ServiceKey, err error) {
+	const op = "uc.service_key.authenticate"
+	defer logging.OnError(ctx, op, &err)
+	key, err := si.keyRepo.ServiceKey(ctx, req.KeyID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidSignature
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if key.RevokedAt != nil {
+		return nil, ErrInvalidSignature
+	}
+	signedAt := time.Unix(req.Timestamp, 0)
+	if skew := time.Since(signedAt).Abs(); skew > si.maxSkew {
+		return nil, ErrStaleRequest
+	}
+	secret, err := si.secrets.Open(key.Secret, key.ID.String())
+	if err != nil {
+		return nil, fmt.Errorf("%s: open secret of key %s: %w", op, key.ID, err)
+	}
+	expected := lib.SignRequest(secret, req.Method, req.Path, req.Timestamp, req.Nonce, req.Body)
+	if !hmac.Equal([]byte(expected), []byte(strings.ToLower(req.Signature))) {
+		return nil, ErrInvalidSignature
+	}
+	if !slices.Contains(key.Scopes, scope) {
+		return nil, ErrScopeRequired
+	}
+	// nonce проверяется после подписи, чтобы чужие запросы не могли занять его заранее
+	fresh, err := si.keyRepo.UseNonce(ctx, &domain.ServiceNonce{
+		KeyID:     key.ID,
+		Nonce:     req.Nonce,
+		ExpiresAt: signedAt.Add(si.maxSkew),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if !fresh {
+		return nil, ErrReplayedRequest
+	}
+	if err := si.keyRepo.TouchServiceKey(ctx, key.ID, time.Now()); err != nil {
+		logging.FromContext(ctx).Warn("service key last use update failed", slog.String("key_id", key.ID.String()), logging.Err(err))
+	}
+	return key, nil
+}
+
+func (si *ServiceKeyInteractor) CreateServiceKey(ctx context.Context, actorID uuid.UUID, service string, scopes []string) (_ *domain.ServiceKey, _ string, err error) {
+	const op = "uc.service_key.create"
+	defer logging.OnError(ctx, op, &err)
+	for _, scope := range scopes {
+		if !slices.Contains(domain.ServiceScopes, scope) {
+			return nil, "", fmt.Errorf("%s: %w: %q", op, ErrUnknownScope, scope)
+		}
+	}
+	secret, err := lib.NewServiceSecret()
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", op, err)
+	}
+	// ID нужен до записи: шифротекст привязан к ключу
+	key := &domain.ServiceKey{
+		ID:      uuid.New(),
+		Service: service,
+		Scopes:  scopes,
+	}
+	if key.Secret, err = si.secrets.Seal(secret, key.ID.String()); err != nil {
+		return nil, "", fmt.Errorf("%s: %w", op, err)
+	}
+	if actorID != uuid.Nil {
+		key.CreatedBy = &actorID
+	}
+	if err := si.keyRepo.CreateServiceKey(ctx, key); err != nil {
+		return nil, "", fmt.Errorf("%s: %w", op, err)
+	}
+	if err := si.audit(ctx, actorID, domain.AuditServiceKeyCreated, key.ID, map[string]any{"service": service, "scopes": scopes}); err != nil {
+		return nil, "", fmt.Errorf("%s: %w", op, err)
+	}
+	return key, secret, nil
+}
+
+func (si *ServiceKeyInteractor) ServiceKeys(ctx context.Context) (_ []*domain.ServiceKey, err error) {
+	const op = "uc.service_key.list"
+	defer logging.OnError(ctx, op, &err)
+	keys, err := si.keyRepo.ServiceKeys(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return keys, nil
+}
+
+func (si *ServiceKeyInteractor) RevokeServiceKey(ctx context.Context, actorID uuid.UUID, id uuid.UUID) (err error) {
+	const op = "uc.service_key.revoke"
+	defer logging.OnError(ctx, op, &err)
+	if err := si.keyRepo.RevokeServiceKey(ctx, id, time.Now()); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := si.audit(ctx, actorID, domain.AuditServiceKeyRevoked, id, nil); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// ResealSecrets шифрует активным ключом секреты, сохранённые открытым текстом
+// или зашифрованные прежним ключом. Возвращает число перешифрованных ключей.
+func (si *ServiceKeyInteractor) ResealSecrets(ctx context.Context) (_ int, err error) {
+	const op = "uc.service_key.reseal"
+	defer logging.OnError(ctx, op, &err)
+	keys, err := si.keyRepo.ServiceKeys(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	resealed := 0
+	for _, key := range keys {
+		if si.secrets.Current(key.Secret) {
+			continue
+		}
+		secret := key.Secret
+		if lib.IsSealed(secret) {
+			if secret, err = si.secrets.Open(key.Secret, key.ID.String()); err != nil {
+				return resealed, fmt.Errorf("%s: open secret of key %s: %w", op, key.ID, err)
+			}
+		}
+		sealed, err := si.secrets.Seal(secret, key.ID.String())
+		if err != nil {
+			return resealed, fmt.Errorf("%s: %w", op, err)
+		}
+		if err := si.keyRepo.UpdateServiceKeySecret(ctx, key.ID, sealed); err != nil {
+			return resealed, fmt.Errorf("%s: %w", op, err)
+		}
+		resealed++
+	}
+	return resealed, nil
+}
+
+// RunPurge раз в interval удаляет истёкшие nonce. Блокируется до отмены ctx.
+func (si *ServiceKeyInteractor) RunPurge(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if _, err := si.keyRepo.PurgeNonces(ctx, time.Now()); err != nil && ctx.Err() == nil {
+			logging.FromContext(ctx).Warn("service nonce purge failed", logging.Err(err))
+		}
+	}
+}
+
+func (si *ServiceKeyInteractor) audit(ctx context.Context, actorID uuid.UUID, action string, targetID uuid.UUID, details map[string]any) error {
+	detailsJSON, err := json.Marshal(details)
+	if err != nil {
+		return err
+	}
+	return si.auditRepo.WriteAudit(ctx, &domain.AuditLog{
+		ActorID:    actorID,
+		Action:     action,
+		TargetType: "service_key",
+		TargetID:   targetID.String(),
+		Details:    detailsJSON,
+	})
+}
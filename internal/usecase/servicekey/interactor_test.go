@@ -0,0 +1,282 @@
+package servicekey
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/immxrtalbeast/plandstu/internal/domain"
+	"github.com/immxrtalbeast/plandstu/internal/lib"
+	"gorm.io/gorm"
+)
+
+// memoryKeyRepo - ServiceKeyRepository в памяти, только то, что нужно Authenticate.
+type memoryKeyRepo struct {
+	domain.ServiceKeyRepository
+
+	mu     sync.Mutex
+	keys   map[uuid.UUID]*domain.ServiceKey
+	nonces map[string]bool
+}
+
+func (r *memoryKeyRepo) ServiceKey(ctx context.Context, id uuid.UUID) (*domain.ServiceKey, error) {
+	key, ok := r.keys[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return key, nil
+}
+
+func (r *memoryKeyRepo) ServiceKeys(ctx context.Context) ([]*domain.ServiceKey, error) {
+	keys := make([]*domain.ServiceKey, 0, len(r.keys))
+	for _, key := range r.keys {
+		copied := *key
+		keys = append(keys, &copied)
+	}
+	return keys, nil
+}
+
+func (r *memoryKeyRepo) UpdateServiceKeySecret(ctx context.Context, id uuid.UUID, secret string) error {
+	r.keys[id].Secret = secret
+	return nil
+}
+
+func (r *memoryKeyRepo) UseNonce(ctx context.Context, nonce *domain.ServiceNonce) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	k := nonce.KeyID.String() + ":" + nonce.Nonce
+	if r.nonces[k] {
+		return false, nil
+	}
+	r.nonces[k] = true
+	return true, nil
+}
+
+func (r *memoryKeyRepo) TouchServiceKey(ctx context.Context, id uuid.UUID, at time.Time) error {
+	return nil
+}
+
+// testSecretKey возвращает случайный ключ шифрования секретов в base64.
+func testSecretKey(t *testing.T) string {
+	t.Helper()
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(key)
+}
+
+func TestAuthenticate(t *testing.T) {
+	const (
+		secret  = "secret"
+		maxSkew = time.Minute
+		path    = "/api/v1/internal/chat/history?user_id=1"
+	)
+	body := []byte(`{"message":"hi"}`)
+	keyID := uuid.New()
+	revokedID := uuid.New()
+	revokedAt := time.Now().Add(-time.Hour)
+	box, err := lib.NewSecretBox(testSecretKey(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	seal := func(id uuid.UUID) string {
+		sealed, err := box.Seal(secret, id.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sealed
+	}
+
+	// signed возвращает корректно подписанный запрос, change может его испортить
+	signed := func(offset time.Duration, change func(*domain.SignedRequest)) domain.SignedRequest {
+		ts := time.Now().Add(offset).Unix()
+		nonce := uuid.NewString()
+		req := domain.SignedRequest{
+			KeyID:     keyID,
+			Timestamp: ts,
+			Nonce:     nonce,
+			Signature: lib.SignRequest(secret, "POST", path, ts, nonce, body),
+			Method:    "POST",
+			Path:      path,
+			Body:      body,
+		}
+		if change != nil {
+			change(&req)
+		}
+		return req
+	}
+
+	tests := []struct {
+		name    string
+		req     domain.SignedRequest
+		scope   string
+		replay  bool // запрос отправляется второй раз
+		wantErr error
+	}{
+		{
+			name:  "valid",
+			req:   signed(0, nil),
+			scope: domain.ScopeChatHistoryWrite,
+		},
+		{
+			name:  "signature case does not matter",
+			req:   signed(0, func(r *domain.SignedRequest) { r.Signature = strings.ToUpper(r.Signature) }),
+			scope: domain.ScopeChatHistoryWrite,
+		},
+		{
+			name:  "skew within window in the past",
+			req:   signed(-maxSkew+5*time.Second, nil),
+			scope: domain.ScopeChatHistoryWrite,
+		},
+		{
+			name:  "skew within window in the future",
+			req:   signed(maxSkew-5*time.Second, nil),
+			scope: domain.ScopeChatHistoryWrite,
+		},
+		{
+			name:    "too old",
+			req:     signed(-maxSkew-5*time.Second, nil),
+			scope:   domain.ScopeChatHistoryWrite,
+			wantErr: ErrStaleRequest,
+		},
+		{
+			name:    "too far in the future",
+			req:     signed(maxSkew+5*time.Second, nil),
+			scope:   domain.ScopeChatHistoryWrite,
+			wantErr: ErrStaleRequest,
+		},
+		{
+			name:    "replayed nonce",
+			req:     signed(0, nil),
+			scope:   domain.ScopeChatHistoryWrite,
+			replay:  true,
+			wantErr: ErrReplayedRequest,
+		},
+		{
+			name:    "tampered body",
+			req:     signed(0, func(r *domain.SignedRequest) { r.Body = []byte(`{"message":"bye"}`) }),
+			scope:   domain.ScopeChatHistoryWrite,
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:    "tampered path",
+			req:     signed(0, func(r *domain.SignedRequest) { r.Path = "/api/v1/internal/chat/history?user_id=2" }),
+			scope:   domain.ScopeChatHistoryWrite,
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name: "timestamp changed after signing",
+			req: signed(0, func(r *domain.SignedRequest) {
+				r.Timestamp--
+			}),
+			scope:   domain.ScopeChatHistoryWrite,
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:    "unknown key",
+			req:     signed(0, func(r *domain.SignedRequest) { r.KeyID = uuid.New() }),
+			scope:   domain.ScopeChatHistoryWrite,
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:    "revoked key",
+			req:     signed(0, func(r *domain.SignedRequest) { r.KeyID = revokedID }),
+			scope:   domain.ScopeChatHistoryWrite,
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:    "missing scope",
+			req:     signed(0, nil),
+			scope:   "reports:write",
+			wantErr: ErrScopeRequired,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &memoryKeyRepo{
+				keys: map[uuid.UUID]*domain.ServiceKey{
+					keyID:     {ID: keyID, Secret: seal(keyID), Scopes: []string{domain.ScopeChatHistoryWrite}},
+					revokedID: {ID: revokedID, Secret: seal(revokedID), Scopes: []string{domain.ScopeChatHistoryWrite}, RevokedAt: &revokedAt},
+				},
+				nonces: make(map[string]bool),
+			}
+			si := NewServiceKeyInteractor(repo, nil, box, maxSkew)
+			if tt.replay {
+				if _, err := si.Authenticate(context.Background(), tt.req, tt.scope); err != nil {
+					t.Fatalf("first request: %v", err)
+				}
+			}
+			key, err := si.Authenticate(context.Background(), tt.req, tt.scope)
+			if tt.wantErr == nil {
+				if err != nil || key.ID != keyID {
+					t.Fatalf("Authenticate() = %v, %v, want key %s", key, err, keyID)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Authenticate() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResealSecrets(t *testing.T) {
+	oldKey, activeKey := testSecretKey(t), testSecretKey(t)
+	oldBox, err := lib.NewSecretBox(oldKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	box, err := lib.NewSecretBox(activeKey, oldKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacyID, oldID, currentID := uuid.New(), uuid.New(), uuid.New()
+	sealedOld, err := oldBox.Seal("old-secret", oldID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealedCurrent, err := box.Seal("current-secret", currentID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := &memoryKeyRepo{keys: map[uuid.UUID]*domain.ServiceKey{
+		legacyID:  {ID: legacyID, Secret: "legacy-secret"},
+		oldID:     {ID: oldID, Secret: sealedOld},
+		currentID: {ID: currentID, Secret: sealedCurrent},
+	}}
+	si := NewServiceKeyInteractor(repo, nil, box, time.Minute)
+
+	resealed, err := si.ResealSecrets(context.Background())
+	if err != nil {
+		t.Fatalf("ResealSecrets() error = %v", err)
+	}
+	if resealed != 2 {
+		t.Fatalf("resealed = %d, want 2", resealed)
+	}
+	if repo.keys[currentID].Secret != sealedCurrent {
+		t.Fatal("secret sealed with the active key was rewritten")
+	}
+	want := map[uuid.UUID]string{legacyID: "legacy-secret", oldID: "old-secret", currentID: "current-secret"}
+	for id, plaintext := range want {
+		sealed := repo.keys[id].Secret
+		if !box.Current(sealed) {
+			t.Fatalf("key %s is not sealed with the active key: %q", id, sealed)
+		}
+		got, err := box.Open(sealed, id.String())
+		if err != nil || got != plaintext {
+			t.Fatalf("Open(%s) = %q, %v, want %q", id, got, err, plaintext)
+		}
+	}
+
+	// Повторный запуск ничего не меняет
+	if resealed, err := si.ResealSecrets(context.Background()); err != nil || resealed != 0 {
+		t.Fatalf("second ResealSecrets() = %d, %v, want 0", resealed, err)
+	}
+}
@@ -3,49 +3,63 @@ package teachertest
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/rand"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/immxrtalbeast/plandstu/internal/domain"
+	"github.com/immxrtalbeast/plandstu/internal/logging"
 	"gorm.io/datatypes"
+	"gorm.io/gorm"
 )
 
 type TeacherTestInteractor struct {
 	teacherTestRepo domain.TeacherTestRepository
+	disciplineRepo  domain.DisciplineRepository
 }
 
-func NewTeacherTestInteractor(teacherTestRepo domain.TeacherTestRepository) *TeacherTestInteractor {
-	return &TeacherTestInteractor{teacherTestRepo: teacherTestRepo}
+func NewTeacherTestInteractor(teacherTestRepo domain.TeacherTestRepository, disciplineRepo domain.DisciplineRepository) *TeacherTestInteractor {
+	return &TeacherTestInteractor{teacherTestRepo: teacherTestRepo, disciplineRepo: disciplineRepo}
 }
 
-func (ti *TeacherTestInteractor) TeacherTests(ctx context.Context, disciplineID int) ([]*domain.TeacherTest, error) {
+func (ti *TeacherTestInteractor) TeacherTests(ctx context.Context, disciplineID int) (_ []*domain.TeacherTest, err error) {
 	const op = "uc.teacher_test.get"
+	defer logging.OnError(ctx, op, &err)
 	tests, err := ti.teacherTestRepo.TeacherTests(ctx, disciplineID)
 	return tests, err
 }
-func (ti *TeacherTestInteractor) TeacherTestByID(ctx context.Context, testID uuid.UUID) (*domain.TeacherTest, error) {
+func (ti *TeacherTestInteractor) TeacherTestByID(ctx context.Context, testID uuid.UUID) (_ *domain.TeacherTest, err error) {
 	const op = "uc.teacher_test.get_id"
+	defer logging.OnError(ctx, op, &err)
 	test, err := ti.teacherTestRepo.TeacherTestByID(ctx, testID)
 	return test, err
 }
-func (ti *TeacherTestInteractor) CreateTeacherTest(ctx context.Context, detailsData datatypes.JSON, answers datatypes.JSON, disciplineID int) error {
+func (ti *TeacherTestInteractor) CreateTeacherTest(ctx context.Context, detailsData datatypes.JSON, answers datatypes.JSON, disciplineID int) (err error) {
 	const op = "uc.teacher_test.create"
+	defer logging.OnError(ctx, op, &err)
+	if _, err := ti.disciplineRepo.Discipline(ctx, disciplineID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%s: %w", op, domain.ErrUnknownDiscipline)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
 	test := domain.TeacherTest{
 		DisciplineID: disciplineID,
 		DetailsJSONB: detailsData,
 		Answers:      answers,
 	}
-	err := ti.teacherTestRepo.CreateTeacherTest(ctx, test)
+	err = ti.teacherTestRepo.CreateTeacherTest(ctx, test)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 	return nil
 }
 
-func (ti *TeacherTestInteractor) TeacherTestForUser(ctx context.Context, disciplineID int) (*domain.TestResponse, error) {
+func (ti *TeacherTestInteractor) TeacherTestForUser(ctx context.Context, disciplineID int) (_ *domain.TestResponse, err error) {
 	const op = "uc.teacher_test.for_user"
+	defer logging.OnError(ctx, op, &err)
 	tests, err := ti.teacherTestRepo.TeacherTestWithoutAnswers(ctx, disciplineID)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
@@ -73,8 +87,9 @@ func (ti *TeacherTestInteractor) TeacherTestForUser(ctx context.Context, discipl
 
 // UpdateTeacherTest(ctx context.Context, testID uuid.UUID, detailsData datatypes.JSON, answers datatypes.JSON) error
 // DeleteTeacherTest(ctx context.Context, testID uuid.UUID) error
-func (ti *TeacherTestInteractor) UpdateTeacherTest(ctx context.Context, testID uuid.UUID, detailsData datatypes.JSON, answers datatypes.JSON) error {
+func (ti *TeacherTestInteractor) UpdateTeacherTest(ctx context.Context, testID uuid.UUID, detailsData datatypes.JSON, answers datatypes.JSON) (err error) {
 	const op = "uc.teacher_test.update"
+	defer logging.OnError(ctx, op, &err)
 	existingTest, err := ti.teacherTestRepo.TeacherTestByID(ctx, testID)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
@@ -87,8 +102,9 @@ func (ti *TeacherTestInteractor) UpdateTeacherTest(ctx context.Context, testID u
 	}
 	return nil
 }
-func (ti *TeacherTestInteractor) DeleteTeacherTest(ctx context.Context, testID uuid.UUID) error {
+func (ti *TeacherTestInteractor) DeleteTeacherTest(ctx context.Context, testID uuid.UUID) (err error) {
 	const op = "uc.teacher_test.delele"
+	defer logging.OnError(ctx, op, &err)
 	if err := ti.teacherTestRepo.DeleteTeacherTest(ctx, testID); err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
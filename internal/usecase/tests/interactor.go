@@ -4,42 +4,62 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"math"
-	"net/http"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/immxrtalbeast/plandstu/internal/domain"
+	"github.com/immxrtalbeast/plandstu/internal/logging"
+	"github.com/immxrtalbeast/plandstu/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"gorm.io/datatypes"
 )
 
+// AnswerKeys - источник ключей ответов к сгенерированным тестам (LLM-сервис).
+type AnswerKeys interface {
+	CorrectAnswers(ctx context.Context, testID uuid.UUID) ([]string, error)
+}
+
+// ErrInvalidAnswersCount - число ответов не совпадает с числом вопросов теста.
+var ErrInvalidAnswersCount = domain.Validation("invalid_answers_count", "number of answers does not match number of questions")
+
 type TestInteractor struct {
 	testRepo    domain.TestRepository
-	llmURL      string
+	answerKeys  AnswerKeys
 	roadmapRepo domain.RoadmapRepository
 }
 
-func NewTestInteractor(testRepo domain.TestRepository, llmURL string, roadmapRepo domain.RoadmapRepository) *TestInteractor {
-	return &TestInteractor{testRepo: testRepo, llmURL: llmURL, roadmapRepo: roadmapRepo}
+func NewTestInteractor(testRepo domain.TestRepository, answerKeys AnswerKeys, roadmapRepo domain.RoadmapRepository) *TestInteractor {
+	return &TestInteractor{testRepo: testRepo, answerKeys: answerKeys, roadmapRepo: roadmapRepo}
 }
 
-func (ti *TestInteractor) CreateTest(ctx context.Context, generatedTestID uuid.UUID, detailsData datatypes.JSON, roadmapHistoryID uuid.UUID, isFirst bool) (*domain.RoadmapTest, error) {
+func (ti *TestInteractor) CreateTest(ctx context.Context, generatedTestID uuid.UUID, detailsData datatypes.JSON, answers []string, roadmapHistoryID uuid.UUID, isFirst bool) (_ *domain.RoadmapTest, err error) {
 	const op = "uc.tests.create"
+	defer logging.OnError(ctx, op, &err)
+	ctx, span := tracing.Start(ctx, op, trace.WithAttributes(attribute.String("test.id", generatedTestID.String())))
+	defer span.End()
+	answersJSON, err := json.Marshal(answers)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to marshal answers: %w", op, err)
+	}
 	test := domain.RoadmapTest{
 		ID:               generatedTestID,
 		DetailsJSONB:     detailsData,
+		AnswersJSONB:     datatypes.JSON(answersJSON),
 		RoadmapHistoryID: roadmapHistoryID,
 	}
 	if isFirst {
 		test.IsFirst = true
 	}
 	history, err := ti.testRepo.CreateTest(ctx, test)
+	tracing.RecordError(span, err)
 	return history, err
 }
 
-func (ti *TestInteractor) Answers(ctx context.Context, testID uuid.UUID, answers []string) ([]byte, error) { //map[string]float64
+func (ti *TestInteractor) Answers(ctx context.Context, testID uuid.UUID, answers []string) (_ []byte, err error) { //map[string]float64
 	const op = "uc.tests.answers"
+	defer logging.OnError(ctx, op, &err)
 	test, err := ti.testRepo.Test(ctx, testID)
 	if err != nil {
 		return nil, fmt.Errorf("%s:%w", op, err)
@@ -49,7 +69,7 @@ func (ti *TestInteractor) Answers(ctx context.Context, testID uuid.UUID, answers
 		return nil, fmt.Errorf("%s: failed to parse test details: %w", op, err)
 	}
 
-	correctAnswers, err := ti.GetCorrectAnswers(ctx, testID)
+	correctAnswers, err := ti.correctAnswers(ctx, test)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
@@ -58,8 +78,11 @@ func (ti *TestInteractor) Answers(ctx context.Context, testID uuid.UUID, answers
 		totalQuestions += len(topic.Questions)
 	}
 
-	if len(answers) != totalQuestions || len(correctAnswers) != totalQuestions {
-		return nil, fmt.Errorf("%s: invalid answers count. Answers count %d, Answers given: %d", op, totalQuestions, len(answers))
+	if len(correctAnswers) != totalQuestions {
+		return nil, fmt.Errorf("%s: answer key has %d answers, test has %d questions", op, len(correctAnswers), totalQuestions)
+	}
+	if len(answers) != totalQuestions {
+		return nil, fmt.Errorf("%s: %w", op, ErrInvalidAnswersCount)
 	}
 
 	results := make(map[string]float64)
@@ -113,36 +136,64 @@ func (ti *TestInteractor) Answers(ctx context.Context, testID uuid.UUID, answers
 	return updatedJSON, nil
 }
 
-func (ti *TestInteractor) GetCorrectAnswers(ctx context.Context, testID uuid.UUID) ([]string, error) {
-	type CorrectAnswersResponse struct {
-		Answers []string `json:"answers"`
+// GetCorrectAnswers возвращает ключ ответов, сохранённый вместе с тестом.
+func (ti *TestInteractor) GetCorrectAnswers(ctx context.Context, testID uuid.UUID) (_ []string, err error) {
+	const op = "uc.tests.correct_answers"
+	defer logging.OnError(ctx, op, &err)
+	test, err := ti.testRepo.Test(ctx, testID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	answers, err := ti.correctAnswers(ctx, test)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return answers, nil
+}
+
+// correctAnswers читает ключ из теста. Для тестов, созданных до хранения ключа
+// в БД, ключ один раз запрашивается у LLM-сервиса и сохраняется.
+func (ti *TestInteractor) correctAnswers(ctx context.Context, test *domain.RoadmapTest) ([]string, error) {
+	var answers []string
+	if len(test.AnswersJSONB) > 0 {
+		if err := json.Unmarshal(test.AnswersJSONB, &answers); err != nil {
+			return nil, fmt.Errorf("failed to parse stored answers: %w", err)
+		}
 	}
-	client := &http.Client{
-		Timeout: 20 * time.Second,
+	if len(answers) > 0 {
+		return answers, nil
 	}
-	url := fmt.Sprintf(ti.llmURL+"test-exmpl-answers/%s", testID)
-	req, err := http.NewRequest("GET", url, nil)
+
+	answers, err := ti.FetchCorrectAnswers(ctx, test.ID)
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Set("User-Agent", "Parser/1.0")
-	resp, err := client.Do(req)
+	answersJSON, err := json.Marshal(answers)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to marshal answers: %w", err)
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		errorBody, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("Error code while getting answers: %s", errorBody)
+	test.AnswersJSONB = datatypes.JSON(answersJSON)
+	if _, err := ti.testRepo.UpdateTest(ctx, *test); err != nil {
+		return nil, fmt.Errorf("failed to store answers: %w", err)
 	}
-	var response CorrectAnswersResponse
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	return answers, nil
+}
+
+// FetchCorrectAnswers запрашивает ключ ответов у LLM-сервиса. Используется только
+// при создании теста, проверка ответов идёт по сохранённому ключу.
+func (ti *TestInteractor) FetchCorrectAnswers(ctx context.Context, testID uuid.UUID) (_ []string, err error) {
+	const op = "uc.tests.fetch_correct_answers"
+	defer logging.OnError(ctx, op, &err)
+	answers, err := ti.answerKeys.CorrectAnswers(ctx, testID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
-	return response.Answers, nil
+	return answers, nil
 }
 
-func (ti *TestInteractor) SaveAnswersToHistory(ctx context.Context, historyID uuid.UUID, results map[string]float64) error {
+func (ti *TestInteractor) SaveAnswersToHistory(ctx context.Context, historyID uuid.UUID, results map[string]float64) (err error) {
+	const op = "uc.tests.save_answers_to_history"
+	defer logging.OnError(ctx, op, &err)
 	// 1. Получаем историю
 	history, err := ti.roadmapRepo.HistoryByID(ctx, historyID)
 	if err != nil {
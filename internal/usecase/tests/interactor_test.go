@@ -0,0 +1,158 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"maps"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/immxrtalbeast/plandstu/internal/domain"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// memoryTestRepo - TestRepository в памяти, только то, что нужно Answers.
+type memoryTestRepo struct {
+	domain.TestRepository
+	tests map[uuid.UUID]domain.RoadmapTest
+}
+
+func (r *memoryTestRepo) Test(ctx context.Context, testID uuid.UUID) (*domain.RoadmapTest, error) {
+	test, ok := r.tests[testID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &test, nil
+}
+
+func (r *memoryTestRepo) UpdateTest(ctx context.Context, test domain.RoadmapTest) (*domain.RoadmapTest, error) {
+	r.tests[test.ID] = test
+	return &test, nil
+}
+
+// fakeAnswerKeys отдаёт заранее заданный ключ и считает обращения.
+type fakeAnswerKeys struct {
+	answers []string
+	calls   int
+}
+
+func (f *fakeAnswerKeys) CorrectAnswers(ctx context.Context, testID uuid.UUID) ([]string, error) {
+	f.calls++
+	return f.answers, nil
+}
+
+func TestAnswers(t *testing.T) {
+	details := datatypes.JSON(`{"test":[
+		{"title":"Go","questions":[{"text":"q1"},{"text":"q2"}]},
+		{"title":"SQL","questions":[{"text":"q3"}]}
+	]}`)
+	key := func(answers ...string) datatypes.JSON {
+		b, err := json.Marshal(answers)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+
+	tests := []struct {
+		name         string
+		storedKey    datatypes.JSON
+		remoteKey    []string
+		answers      []string
+		want         map[string]float64
+		wantErr      error
+		wantInternal bool // ошибка не должна быть ошибкой клиента
+		wantCalls    int
+	}{
+		{
+			name:      "grades against stored key",
+			storedKey: key("a", "b", "c"),
+			remoteKey: []string{"x", "x", "x"},
+			answers:   []string{"a", "x", "c"},
+			want:      map[string]float64{"Go": 50, "SQL": 100},
+		},
+		{
+			name:      "all wrong",
+			storedKey: key("a", "b", "c"),
+			answers:   []string{"b", "a", "a"},
+			want:      map[string]float64{"Go": 0, "SQL": 0},
+		},
+		{
+			name:      "legacy test fetches key once",
+			remoteKey: []string{"a", "b", "c"},
+			answers:   []string{"a", "b", "x"},
+			want:      map[string]float64{"Go": 100, "SQL": 0},
+			wantCalls: 1,
+		},
+		{
+			name:      "too few answers",
+			storedKey: key("a", "b", "c"),
+			answers:   []string{"a", "b"},
+			wantErr:   ErrInvalidAnswersCount,
+		},
+		{
+			name:      "too many answers",
+			storedKey: key("a", "b", "c"),
+			answers:   []string{"a", "b", "c", "d"},
+			wantErr:   ErrInvalidAnswersCount,
+		},
+		{
+			name:         "broken stored key",
+			storedKey:    key("a", "b"),
+			answers:      []string{"a", "b", "c"},
+			wantInternal: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testID := uuid.New()
+			repo := &memoryTestRepo{tests: map[uuid.UUID]domain.RoadmapTest{
+				testID: {ID: testID, DetailsJSONB: details, AnswersJSONB: tt.storedKey},
+			}}
+			keys := &fakeAnswerKeys{answers: tt.remoteKey}
+			ti := NewTestInteractor(repo, keys, nil)
+
+			got, err := ti.Answers(context.Background(), testID, tt.answers)
+			if keys.calls != tt.wantCalls {
+				t.Fatalf("CorrectAnswers calls = %d, want %d", keys.calls, tt.wantCalls)
+			}
+			if tt.wantErr != nil || tt.wantInternal {
+				if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Answers() error = %v, want %v", err, tt.wantErr)
+				}
+				var derr *domain.Error
+				if tt.wantInternal && (err == nil || errors.As(err, &derr)) {
+					t.Fatalf("Answers() error = %v, want internal error", err)
+				}
+				if repo.tests[testID].Status == "passed" {
+					t.Fatal("test marked as passed after failed grading")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Answers() error = %v", err)
+			}
+
+			var blocks domain.BlocksData
+			if err := json.Unmarshal(got, &blocks); err != nil {
+				t.Fatal(err)
+			}
+			results := make(map[string]float64)
+			for _, b := range blocks.Blocks {
+				results[b.Name] = b.Value
+			}
+			if !maps.Equal(results, tt.want) {
+				t.Fatalf("results = %v, want %v", results, tt.want)
+			}
+			stored := repo.tests[testID]
+			if stored.Status != "passed" || string(stored.ResultsJSONB) != string(got) {
+				t.Fatalf("stored test = %s %s, want passed with results", stored.Status, stored.ResultsJSONB)
+			}
+			if len(stored.AnswersJSONB) == 0 {
+				t.Fatal("answer key was not stored")
+			}
+		})
+	}
+}
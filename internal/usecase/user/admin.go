@@ -0,0 +1,249 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/immxrtalbeast/plandstu/internal/domain"
+	"github.com/immxrtalbeast/plandstu/internal/logging"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultUsersLimit = 50
+	maxUsersLimit     = 200
+)
+
+func (ui *UserInteractor) Users(ctx context.Context, filter domain.UserFilter) (_ []*domain.User, _ int64, err error) {
+	const op = "uc.user.list"
+	defer logging.OnError(ctx, op, &err)
+	filter.Limit = clampLimit(filter.Limit)
+	users, total, err := ui.userRepo.Users(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return users, total, nil
+}
+
+func (ui *UserInteractor) AdminCreateUser(ctx context.Context, actorID uuid.UUID, login string, pass string, role string, groupID *uuid.UUID) (_ uuid.UUID, err error) {
+	const op = "uc.user.admin_create"
+	defer logging.OnError(ctx, op, &err)
+	if err := ui.checkRole(ctx, role); err != nil {
+		return uuid.Nil, fmt.Errorf("%s: %w", op, err)
+	}
+	group, err := ui.group(ctx, groupID)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%s: %w", op, err)
+	}
+	passHash, err := bcrypt.GenerateFromPassword([]byte(pass), bcrypt.DefaultCost)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%s: %w", op, err)
+	}
+	user := domain.User{
+		Login:    login,
+		PassHash: passHash,
+		Role:     role,
+		GroupID:  groupID,
+		Group:    group,
+	}
+	id, err := ui.userRepo.CreateUser(ctx, &user)
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return uuid.Nil, fmt.Errorf("%s: %w", op, ErrLoginTaken)
+	}
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := ui.audit(ctx, actorID, domain.AuditUserCreated, id, map[string]any{"login": login, "role": role}); err != nil {
+		return uuid.Nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return id, nil
+}
+
+// ChangeRole меняет роль и отзывает все сессии и выданные токены: роль записана
+// в access-токене, поэтому старые токены со старой ролью больше не принимаются.
+func (ui *UserInteractor) ChangeRole(ctx context.Context, actorID uuid.UUID, userID uuid.UUID, role string) (err error) {
+	const op = "uc.user.change_role"
+	defer logging.OnError(ctx, op, &err)
+	if actorID == userID {
+		return fmt.Errorf("%s: %w", op, ErrSelfAction)
+	}
+	if err := ui.checkRole(ctx, role); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	user, err := ui.User(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := ui.userRepo.UpdateRole(ctx, userID, role); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := ui.sessionRepo.RevokeUserSessions(ctx, userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := ui.audit(ctx, actorID, domain.AuditUserRoleChanged, userID, map[string]any{"from": user.Role, "to": role}); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (ui *UserInteractor) SetDisabled(ctx context.Context, actorID uuid.UUID, userID uuid.UUID, disabled bool) (err error) {
+	const op = "uc.user.set_disabled"
+	defer logging.OnError(ctx, op, &err)
+	if actorID == userID {
+		return fmt.Errorf("%s: %w", op, ErrSelfAction)
+	}
+	if err := ui.userRepo.SetDisabled(ctx, userID, disabled); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%s: %w", op, ErrNotFound)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	action := domain.AuditUserEnabled
+	if disabled {
+		action = domain.AuditUserDisabled
+		if err := ui.sessionRepo.RevokeUserSessions(ctx, userID); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	if err := ui.audit(ctx, actorID, action, userID, nil); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (ui *UserInteractor) DeleteUser(ctx context.Context, actorID uuid.UUID, userID uuid.UUID) (err error) {
+	const op = "uc.user.delete"
+	defer logging.OnError(ctx, op, &err)
+	if actorID == userID {
+		return fmt.Errorf("%s: %w", op, ErrSelfAction)
+	}
+	user, err := ui.User(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := ui.sessionRepo.RevokeUserSessions(ctx, userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := ui.userRepo.DeleteUser(ctx, userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := ui.audit(ctx, actorID, domain.AuditUserDeleted, userID, map[string]any{"login": user.Login}); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// ResetPassword задаёт новый пароль. Если newPass пуст, генерируется временный пароль,
+// который возвращается администратору один раз и нигде не сохраняется.
+func (ui *UserInteractor) ResetPassword(ctx context.Context, actorID uuid.UUID, userID uuid.UUID, newPass string) (_ string, err error) {
+	const op = "uc.user.reset_password"
+	defer logging.OnError(ctx, op, &err)
+	generated := newPass == ""
+	if generated {
+		var err error
+		newPass, err = temporaryPassword()
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	passHash, err := bcrypt.GenerateFromPassword([]byte(newPass), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	if err := ui.userRepo.UpdatePassword(ctx, userID, passHash); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", fmt.Errorf("%s: %w", op, ErrNotFound)
+		}
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	if err := ui.sessionRepo.RevokeUserSessions(ctx, userID); err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	if err := ui.audit(ctx, actorID, domain.AuditUserPasswordReset, userID, map[string]any{"generated": generated}); err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	if !generated {
+		return "", nil
+	}
+	return newPass, nil
+}
+
+// UnlockUser снимает блокировку после неудачных входов и обнуляет счётчик по логину.
+func (ui *UserInteractor) UnlockUser(ctx context.Context, actorID uuid.UUID, userID uuid.UUID) (err error) {
+	const op = "uc.user.unlock"
+	defer logging.OnError(ctx, op, &err)
+	user, err := ui.User(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := ui.loginRepo.LockUser(ctx, userID, nil); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := ui.loginRepo.ResetFailures(ctx, domain.LoginThrottleLogin, throttleKey(user.Login)); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := ui.audit(ctx, actorID, domain.AuditUserUnlocked, userID, map[string]any{"locked_until": user.LockedUntil}); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (ui *UserInteractor) AuditLogs(ctx context.Context, filter domain.AuditFilter) (_ []*domain.AuditLog, _ int64, err error) {
+	const op = "uc.user.audit_logs"
+	defer logging.OnError(ctx, op, &err)
+	filter.Limit = clampLimit(filter.Limit)
+	logs, total, err := ui.auditRepo.AuditLogs(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return logs, total, nil
+}
+
+func (ui *UserInteractor) checkRole(ctx context.Context, role string) error {
+	exists, err := ui.accessRepo.RoleExists(ctx, role)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("%w: %q", ErrUnknownRole, role)
+	}
+	return nil
+}
+
+func (ui *UserInteractor) audit(ctx context.Context, actorID uuid.UUID, action string, targetID uuid.UUID, details map[string]any) error {
+	detailsJSON, err := json.Marshal(details)
+	if err != nil {
+		return err
+	}
+	return ui.auditRepo.WriteAudit(ctx, &domain.AuditLog{
+		ActorID:    actorID,
+		Action:     action,
+		TargetType: "user",
+		TargetID:   targetID.String(),
+		Details:    detailsJSON,
+	})
+}
+
+func clampLimit(limit int) int {
+	if limit <= 0 {
+		return defaultUsersLimit
+	}
+	if limit > maxUsersLimit {
+		return maxUsersLimit
+	}
+	return limit
+}
+
+func temporaryPassword() (string, error) {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -9,31 +9,62 @@ import (
 	"github.com/google/uuid"
 	"github.com/immxrtalbeast/plandstu/internal/domain"
 	"github.com/immxrtalbeast/plandstu/internal/lib"
+	"github.com/immxrtalbeast/plandstu/internal/logging"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
 var (
-	ErrInvalidCredentials = errors.New("invalid credentials")
-	ErrNotFound           = errors.New("user not found")
+	ErrInvalidCredentials  = domain.Unauthorized("invalid_credentials", "invalid login or password")
+	ErrNotFound            = domain.NotFound("user_not_found", "user not found")
+	ErrInvalidRefreshToken = domain.Unauthorized("invalid_refresh_token", "refresh token is invalid or expired")
+	ErrUserDisabled        = domain.Forbidden("user_disabled", "user is disabled")
+	ErrUnknownRole         = domain.Validation("unknown_role", "unknown role")
+	ErrSelfAction          = domain.Forbidden("self_action", "action is not allowed on own account")
+	ErrUnknownGroup        = domain.Validation("unknown_group", "unknown group")
+	ErrLoginTaken          = domain.Conflict("login_taken", "login is already taken")
+	ErrRefreshRotated      = domain.Conflict("refresh_token_rotated", "refresh token was just rotated by a parallel request, retry with the new one")
 )
 
+// refreshReuseGrace - сколько после ротации старый refresh-токен считается
+// гонкой параллельных обновлений (например, из двух вкладок), а не кражей.
+const refreshReuseGrace = 30 * time.Second
+
 type UserInteractor struct {
-	userRepo  domain.UserRepository
-	tokenTTL  time.Duration
-	appSecret string
+	userRepo    domain.UserRepository
+	sessionRepo domain.SessionRepository
+	accessRepo  domain.AccessRepository
+	auditRepo   domain.AuditRepository
+	groupRepo   domain.GroupRepository
+	loginRepo   domain.LoginRepository
+	tokenTTL    time.Duration
+	refreshTTL  time.Duration
+	loginPolicy LoginPolicy
+	keys        *lib.KeySet
 }
 
-func NewUserInteractor(userRepo domain.UserRepository, tokenTTL time.Duration, appSecret string) *UserInteractor {
+func NewUserInteractor(userRepo domain.UserRepository, sessionRepo domain.SessionRepository, accessRepo domain.AccessRepository, auditRepo domain.AuditRepository, groupRepo domain.GroupRepository, loginRepo domain.LoginRepository, tokenTTL time.Duration, refreshTTL time.Duration, loginPolicy LoginPolicy, keys *lib.KeySet) *UserInteractor {
 	return &UserInteractor{
-		userRepo:  userRepo,
-		tokenTTL:  tokenTTL,
-		appSecret: appSecret,
+		userRepo:    userRepo,
+		sessionRepo: sessionRepo,
+		accessRepo:  accessRepo,
+		auditRepo:   auditRepo,
+		groupRepo:   groupRepo,
+		loginRepo:   loginRepo,
+		tokenTTL:    tokenTTL,
+		refreshTTL:  refreshTTL,
+		loginPolicy: loginPolicy,
+		keys:        keys,
 	}
 }
 
-func (ui *UserInteractor) CreateUser(ctx context.Context, login string, pass string, group string) (uuid.UUID, error) {
+func (ui *UserInteractor) CreateUser(ctx context.Context, login string, pass string, groupID *uuid.UUID) (_ uuid.UUID, err error) {
 	const op = "uc.user.create"
+	defer logging.OnError(ctx, op, &err)
+	group, err := ui.group(ctx, groupID)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%s: %w", op, err)
+	}
 	passHash, err := bcrypt.GenerateFromPassword([]byte(pass), bcrypt.DefaultCost)
 	if err != nil {
 		return uuid.Nil, fmt.Errorf("%s: %w", op, err)
@@ -41,30 +72,223 @@ func (ui *UserInteractor) CreateUser(ctx context.Context, login string, pass str
 	user := domain.User{
 		Login:    login,
 		PassHash: passHash,
+		GroupID:  groupID,
 		Group:    group,
 	}
 	id, err := ui.userRepo.CreateUser(ctx, &user)
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return uuid.Nil, fmt.Errorf("%s: %w", op, ErrLoginTaken)
+	}
 	if err != nil {
 		return uuid.Nil, fmt.Errorf("%s: %w", op, err)
 	}
 	return id, nil
 }
 
-func (ui *UserInteractor) Login(ctx context.Context, login string, passhash string) (string, error) {
+// group проверяет, что группа существует, и возвращает её название.
+func (ui *UserInteractor) group(ctx context.Context, groupID *uuid.UUID) (string, error) {
+	if groupID == nil {
+		return "", nil
+	}
+	group, err := ui.groupRepo.Group(ctx, *groupID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", ErrUnknownGroup
+		}
+		return "", err
+	}
+	return group.Name, nil
+}
+
+// Login проверяет пароль и выдаёт пару токенов. Неудачные попытки считаются
+// по логину и IP: после нескольких ошибок вход откладывается, а при переборе
+// учётная запись временно блокируется. Все попытки пишутся в журнал входов.
+func (ui *UserInteractor) Login(ctx context.Context, login string, passhash string, client domain.ClientInfo) (_ *domain.TokenPair, err error) {
 	const op = "uc.user.login"
+	defer logging.OnError(ctx, op, &err)
+	key := throttleKey(login)
+	wait, err := ui.loginWait(ctx, key, client.IP)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if wait > 0 {
+		ui.loginEvent(ctx, nil, login, domain.LoginReasonThrottled, client)
+		return nil, fmt.Errorf("%s: %w", op, ErrLoginThrottled.WithRetryAfter(wait))
+	}
 	user, err := ui.userRepo.UserByLogin(ctx, login)
 	if err != nil {
-		return "", fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(passhash))
+		if err := ui.loginFailed(ctx, nil, login, key, client); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+	}
+	// Во время блокировки даже верный пароль не принимается, а ответ такой же,
+	// как при неверном пароле: иначе по нему видно, что логин существует
+	passErr := bcrypt.CompareHashAndPassword(user.PassHash, []byte(passhash))
+	if user.LockedUntil != nil && user.LockedUntil.After(time.Now()) {
+		ui.loginEvent(ctx, &user.ID, login, domain.LoginReasonLocked, client)
+		return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+	}
+	if err := passErr; err != nil {
+		if err := ui.loginFailed(ctx, user, login, key, client); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+	}
+	if user.Disabled {
+		ui.loginEvent(ctx, &user.ID, login, domain.LoginReasonDisabled, client)
+		return nil, fmt.Errorf("%s: %w", op, ErrUserDisabled)
+	}
+	if err := ui.loginRepo.ResetFailures(ctx, domain.LoginThrottleLogin, key); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	refreshToken, session, err := ui.newSession(user.ID, client)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := ui.sessionRepo.CreateSession(ctx, session); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	pair, err := ui.tokenPair(user, refreshToken, session.ExpiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	ui.loginEvent(ctx, &user.ID, login, "", client)
+	return pair, nil
+}
+
+// Refresh обменивает refresh-токен на новую пару токенов. Старый refresh-токен
+// отзывается. Повторное использование отозванного токена считается кражей,
+// и тогда отзываются все сессии пользователя. Исключение - токен, заменённый
+// меньше refreshReuseGrace назад: это параллельный запрос, он получает
+// ErrRefreshRotated и может повторить обмен с новым токеном.
+func (ui *UserInteractor) Refresh(ctx context.Context, refreshToken string, client domain.ClientInfo) (_ *domain.TokenPair, err error) {
+	const op = "uc.user.refresh"
+	defer logging.OnError(ctx, op, &err)
+	session, err := ui.sessionRepo.SessionByHash(ctx, lib.HashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%s: %w", op, ErrInvalidRefreshToken)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if session.RevokedAt != nil {
+		if session.ReplacedBy != nil && time.Since(*session.RevokedAt) < refreshReuseGrace {
+			return nil, fmt.Errorf("%s: %w", op, ErrRefreshRotated)
+		}
+		if err := ui.sessionRepo.RevokeUserSessions(ctx, session.UserID); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		return nil, fmt.Errorf("%s: %w", op, ErrInvalidRefreshToken)
+	}
+	if time.Now().After(session.ExpiresAt) {
+		return nil, fmt.Errorf("%s: %w", op, ErrInvalidRefreshToken)
 	}
-	if err := bcrypt.CompareHashAndPassword(user.PassHash, []byte(passhash)); err != nil {
-		return "", fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+	user, err := ui.userRepo.User(ctx, session.UserID)
+	if err != nil || user.Disabled {
+		return nil, fmt.Errorf("%s: %w", op, ErrInvalidRefreshToken)
 	}
-	return lib.NewToken(user, ui.tokenTTL, ui.appSecret)
 
+	newToken, next, err := ui.newSession(user.ID, client)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := ui.sessionRepo.RotateSession(ctx, session.ID, next); err != nil {
+		// Сессию только что заменил параллельный запрос с тем же токеном
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%s: %w", op, ErrRefreshRotated)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	pair, err := ui.tokenPair(user, newToken, next.ExpiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return pair, nil
+}
+
+// Logout отзывает refresh-сессию и текущий access-токен.
+func (ui *UserInteractor) Logout(ctx context.Context, refreshToken string, accessJTI string, userID uuid.UUID, accessExp time.Time) (err error) {
+	const op = "uc.user.logout"
+	defer logging.OnError(ctx, op, &err)
+	if refreshToken != "" {
+		session, err := ui.sessionRepo.SessionByHash(ctx, lib.HashToken(refreshToken))
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if err == nil && session.UserID == userID {
+			if err := ui.sessionRepo.RevokeSession(ctx, session.ID); err != nil {
+				return fmt.Errorf("%s: %w", op, err)
+			}
+		}
+	}
+	if accessJTI != "" {
+		revoked := domain.RevokedToken{
+			JTI:       accessJTI,
+			UserID:    userID,
+			ExpiresAt: accessExp,
+		}
+		if err := ui.sessionRepo.RevokeToken(ctx, revoked); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	return nil
+}
+
+// TokenActive сообщает, принимается ли ещё access-токен: он не отозван при выходе,
+// пользователь существует и не отключён, а токен выпущен после последнего
+// отзыва всех его сессий (смена роли или пароля, отключение).
+func (ui *UserInteractor) TokenActive(ctx context.Context, userID uuid.UUID, jti string, issuedAt time.Time) (_ bool, err error) {
+	const op = "uc.user.token_active"
+	defer logging.OnError(ctx, op, &err)
+	revoked, err := ui.sessionRepo.TokenRevoked(ctx, jti)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	if revoked {
+		return false, nil
+	}
+	user, err := ui.userRepo.User(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	if user.Disabled {
+		return false, nil
+	}
+	// Граница обрезана до секунды, как и iat: токен, выпущенный в ту же секунду,
+	// что и отзыв, принимается, иначе вход сразу после смены пароля не работал бы
+	if user.TokensValidAfter != nil && issuedAt.Before(*user.TokensValidAfter) {
+		return false, nil
+	}
+	return true, nil
+}
+
+// RunPurge раз в interval удаляет истёкшие записи об отозванных токенах. Блокируется до отмены ctx.
+func (ui *UserInteractor) RunPurge(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if _, err := ui.sessionRepo.PurgeRevokedTokens(ctx, time.Now()); err != nil && ctx.Err() == nil {
+			logging.FromContext(ctx).Warn("revoked token purge failed", logging.Err(err))
+		}
+	}
 }
 
-func (ui *UserInteractor) User(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+func (ui *UserInteractor) User(ctx context.Context, id uuid.UUID) (_ *domain.User, err error) {
 	const op = "uc.user.get"
+	defer logging.OnError(ctx, op, &err)
 	user, err := ui.userRepo.User(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -75,3 +299,32 @@ func (ui *UserInteractor) User(ctx context.Context, id uuid.UUID) (*domain.User,
 	}
 	return user, nil
 }
+
+func (ui *UserInteractor) newSession(userID uuid.UUID, client domain.ClientInfo) (string, *domain.RefreshSession, error) {
+	token, hash, err := lib.NewOpaqueToken()
+	if err != nil {
+		return "", nil, err
+	}
+	session := &domain.RefreshSession{
+		ID:        uuid.New(),
+		UserID:    userID,
+		TokenHash: hash,
+		UserAgent: client.UserAgent,
+		IP:        client.IP,
+		ExpiresAt: time.Now().Add(ui.refreshTTL),
+	}
+	return token, session, nil
+}
+
+func (ui *UserInteractor) tokenPair(user *domain.User, refreshToken string, refreshExp time.Time) (*domain.TokenPair, error) {
+	accessToken, err := lib.NewToken(user, ui.tokenTTL, ui.keys)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.TokenPair{
+		AccessToken:      accessToken,
+		RefreshToken:     refreshToken,
+		AccessExpiresAt:  time.Now().Add(ui.tokenTTL),
+		RefreshExpiresAt: refreshExp,
+	}, nil
+}
@@ -0,0 +1,207 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/immxrtalbeast/plandstu/internal/domain"
+	"github.com/immxrtalbeast/plandstu/internal/lib"
+)
+
+func TestTokenActive(t *testing.T) {
+	revokedAt := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		user     *domain.User // nil - пользователь удалён
+		jti      string
+		issuedAt time.Time
+		want     bool
+	}{
+		{
+			name:     "never revoked",
+			user:     &domain.User{},
+			issuedAt: revokedAt.Add(-time.Hour),
+			want:     true,
+		},
+		{
+			name:     "issued before revocation",
+			user:     &domain.User{TokensValidAfter: &revokedAt},
+			issuedAt: revokedAt.Add(-time.Second),
+			want:     false,
+		},
+		{
+			name:     "issued in the same second as revocation",
+			user:     &domain.User{TokensValidAfter: &revokedAt},
+			issuedAt: revokedAt,
+			want:     true,
+		},
+		{
+			name:     "issued after revocation",
+			user:     &domain.User{TokensValidAfter: &revokedAt},
+			issuedAt: revokedAt.Add(time.Minute),
+			want:     true,
+		},
+		{
+			name:     "logged out",
+			user:     &domain.User{},
+			jti:      "revoked",
+			issuedAt: revokedAt,
+			want:     false,
+		},
+		{
+			name:     "disabled",
+			user:     &domain.User{Disabled: true},
+			issuedAt: revokedAt,
+			want:     false,
+		},
+		{
+			name:     "deleted",
+			issuedAt: revokedAt,
+			want:     false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userID := uuid.New()
+			users := newMemoryUserRepo()
+			if tt.user != nil {
+				tt.user.ID = userID
+				users = newMemoryUserRepo(tt.user)
+			}
+			sessions := newMemorySessionRepo(users)
+			sessions.revokedTokens["revoked"] = true
+			ui := &UserInteractor{userRepo: users, sessionRepo: sessions}
+
+			got, err := ui.TokenActive(context.Background(), userID, tt.jti, tt.issuedAt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("TokenActive() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	keys, err := lib.NewEphemeralKeySet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	// session описывает refresh-сессию, которую предъявляет клиент
+	type session struct {
+		revokedAgo time.Duration // 0 - не отозвана
+		replaced   bool
+		expired    bool
+	}
+	tests := []struct {
+		name           string
+		session        session
+		disabled       bool
+		unknownToken   bool
+		wantErr        error
+		wantRevokeAll  bool
+		wantNewSession bool
+	}{
+		{
+			name:           "rotates active session",
+			wantNewSession: true,
+		},
+		{
+			name:    "parallel refresh within grace",
+			session: session{revokedAgo: 2 * time.Second, replaced: true},
+			wantErr: ErrRefreshRotated,
+		},
+		{
+			name:          "reuse after grace is theft",
+			session:       session{revokedAgo: refreshReuseGrace + time.Second, replaced: true},
+			wantErr:       ErrInvalidRefreshToken,
+			wantRevokeAll: true,
+		},
+		{
+			name:          "reuse after logout is theft",
+			session:       session{revokedAgo: time.Second},
+			wantErr:       ErrInvalidRefreshToken,
+			wantRevokeAll: true,
+		},
+		{
+			name:    "expired",
+			session: session{expired: true},
+			wantErr: ErrInvalidRefreshToken,
+		},
+		{
+			name:     "disabled user",
+			disabled: true,
+			wantErr:  ErrInvalidRefreshToken,
+		},
+		{
+			name:         "unknown token",
+			unknownToken: true,
+			wantErr:      ErrInvalidRefreshToken,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := &domain.User{ID: uuid.New(), Login: "student", Role: domain.RoleUser, Disabled: tt.disabled}
+			users := newMemoryUserRepo(user)
+			sessions := newMemorySessionRepo(users)
+			ui := &UserInteractor{userRepo: users, sessionRepo: sessions, keys: keys, tokenTTL: time.Minute, refreshTTL: time.Hour}
+
+			token, presented, err := ui.newSession(user.ID, domain.ClientInfo{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.session.revokedAgo > 0 {
+				revokedAt := now.Add(-tt.session.revokedAgo)
+				presented.RevokedAt = &revokedAt
+				if tt.session.replaced {
+					successor := uuid.New()
+					presented.ReplacedBy = &successor
+				}
+			}
+			if tt.session.expired {
+				presented.ExpiresAt = now.Add(-time.Minute)
+			}
+			sessions.sessions[presented.ID] = presented
+			// Сессия на другом устройстве: её отзывает только реакция на кражу
+			_, other, err := ui.newSession(user.ID, domain.ClientInfo{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			sessions.sessions[other.ID] = other
+			if tt.unknownToken {
+				token = "unknown"
+			}
+
+			pair, err := ui.Refresh(context.Background(), token, domain.ClientInfo{})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Refresh() error = %v, want %v", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("Refresh() error = %v", err)
+			}
+
+			if revoked := other.RevokedAt != nil; revoked != tt.wantRevokeAll {
+				t.Fatalf("other device session revoked = %v, want %v", revoked, tt.wantRevokeAll)
+			}
+			if !tt.wantNewSession {
+				return
+			}
+			if presented.RevokedAt == nil || presented.ReplacedBy == nil {
+				t.Fatal("presented session was not rotated")
+			}
+			next, ok := sessions.sessions[*presented.ReplacedBy]
+			if !ok || next.TokenHash != lib.HashToken(pair.RefreshToken) {
+				t.Fatal("new refresh token does not match the successor session")
+			}
+			if _, err := keys.Parse(pair.AccessToken); err != nil {
+				t.Fatalf("access token: %v", err)
+			}
+		})
+	}
+}
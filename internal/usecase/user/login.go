@@ -0,0 +1,151 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/immxrtalbeast/plandstu/internal/domain"
+	"github.com/immxrtalbeast/plandstu/internal/logging"
+	"github.com/immxrtalbeast/plandstu/internal/metrics"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+var ErrLoginThrottled = domain.RateLimited("login_throttled", "too many failed login attempts, try again later")
+
+// dummyHash сравнивается с паролем для несуществующих логинов, чтобы по
+// времени ответа нельзя было понять, есть ли такая учётная запись.
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("plandstu-dummy-password"), bcrypt.DefaultCost)
+
+// LoginPolicy - защита входа от перебора. После FreeAttempts ошибок подряд
+// следующая попытка откладывается на BaseDelay, дальше задержка удваивается
+// с каждой ошибкой до MaxDelay. Для IP порог свой, за одним адресом может
+// быть много пользователей. После LockoutThreshold ошибок по логину учётная
+// запись блокируется на LockoutDuration. Снаружи блокировка неотличима от
+// неверного пароля, чтобы не выдавать существование логина.
+type LoginPolicy struct {
+	FreeAttempts     int
+	IPFreeAttempts   int
+	BaseDelay        time.Duration
+	MaxDelay         time.Duration
+	FailureWindow    time.Duration
+	LockoutThreshold int
+	LockoutDuration  time.Duration
+}
+
+// delay возвращает задержку после failures ошибок подряд.
+func (p LoginPolicy) delay(failures int, free int) time.Duration {
+	if failures < free {
+		return 0
+	}
+	d := p.BaseDelay
+	for i := free; i < failures && d < p.MaxDelay; i++ {
+		d *= 2
+	}
+	return min(d, p.MaxDelay)
+}
+
+func (ui *UserInteractor) LoginEvents(ctx context.Context, userID uuid.UUID, filter domain.LoginEventFilter) (_ []*domain.LoginEvent, _ int64, err error) {
+	const op = "uc.user.login_events"
+	defer logging.OnError(ctx, op, &err)
+	filter.Limit = clampLimit(filter.Limit)
+	events, total, err := ui.loginRepo.LoginEvents(ctx, userID, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return events, total, nil
+}
+
+// loginWait возвращает, сколько осталось ждать до следующей попытки входа
+// с этим логином и IP.
+func (ui *UserInteractor) loginWait(ctx context.Context, key string, ip string) (time.Duration, error) {
+	now := time.Now()
+	checks := []struct {
+		kind string
+		key  string
+		free int
+	}{
+		{domain.LoginThrottleLogin, key, ui.loginPolicy.FreeAttempts},
+		{domain.LoginThrottleIP, ip, ui.loginPolicy.IPFreeAttempts},
+	}
+	var wait time.Duration
+	for _, check := range checks {
+		if check.key == "" {
+			continue
+		}
+		throttle, err := ui.loginRepo.Throttle(ctx, check.kind, check.key)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		if throttle.LastFailureAt.Before(now.Add(-ui.loginPolicy.FailureWindow)) {
+			continue
+		}
+		next := throttle.LastFailureAt.Add(ui.loginPolicy.delay(throttle.Failures, check.free))
+		wait = max(wait, next.Sub(now))
+	}
+	return wait, nil
+}
+
+// loginFailed учитывает неверный пароль или логин и блокирует учётную запись,
+// если ошибок по логину набралось LockoutThreshold.
+func (ui *UserInteractor) loginFailed(ctx context.Context, user *domain.User, login string, key string, client domain.ClientInfo) error {
+	windowStart := time.Now().Add(-ui.loginPolicy.FailureWindow)
+	failures, err := ui.loginRepo.RecordFailure(ctx, domain.LoginThrottleLogin, key, windowStart)
+	if err != nil {
+		return err
+	}
+	if client.IP != "" {
+		if _, err := ui.loginRepo.RecordFailure(ctx, domain.LoginThrottleIP, client.IP, windowStart); err != nil {
+			return err
+		}
+	}
+	var userID *uuid.UUID
+	if user != nil {
+		userID = &user.ID
+		if failures >= ui.loginPolicy.LockoutThreshold {
+			until := time.Now().Add(ui.loginPolicy.LockoutDuration)
+			if err := ui.loginRepo.LockUser(ctx, user.ID, &until); err != nil {
+				return err
+			}
+			logging.FromContext(ctx).Warn("account locked after failed logins",
+				slog.String("user_id", user.ID.String()),
+				slog.Int("failures", failures),
+				slog.Time("locked_until", until))
+		}
+	}
+	ui.loginEvent(ctx, userID, login, domain.LoginReasonInvalidCredentials, client)
+	return nil
+}
+
+// loginEvent пишет событие в журнал входов. Пустая причина означает успешный вход.
+// Ошибка записи только логируется: журнал не должен мешать входу.
+func (ui *UserInteractor) loginEvent(ctx context.Context, userID *uuid.UUID, login string, reason string, client domain.ClientInfo) {
+	if reason != "" {
+		metrics.LoginFailed(reason)
+	}
+	event := domain.LoginEvent{
+		UserID:    userID,
+		Login:     login,
+		Success:   reason == "",
+		Reason:    reason,
+		IP:        client.IP,
+		UserAgent: client.UserAgent,
+	}
+	if err := ui.loginRepo.WriteLoginEvent(ctx, &event); err != nil {
+		logging.FromContext(ctx).Warn("failed to write login event", logging.Err(err))
+	}
+}
+
+// throttleKey - ключ счётчика по логину. Регистр не учитывается, чтобы
+// варианты написания не давали дополнительных попыток.
+func throttleKey(login string) string {
+	return strings.ToLower(login)
+}
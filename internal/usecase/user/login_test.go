@@ -0,0 +1,197 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/immxrtalbeast/plandstu/internal/domain"
+	"github.com/immxrtalbeast/plandstu/internal/lib"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var testPolicy = LoginPolicy{
+	FreeAttempts:     3,
+	IPFreeAttempts:   5,
+	BaseDelay:        time.Second,
+	MaxDelay:         10 * time.Second,
+	FailureWindow:    time.Hour,
+	LockoutThreshold: 6,
+	LockoutDuration:  30 * time.Minute,
+}
+
+func TestLoginPolicyDelay(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{failures: 0, want: 0},
+		{failures: 2, want: 0},
+		{failures: 3, want: time.Second},
+		{failures: 4, want: 2 * time.Second},
+		{failures: 5, want: 4 * time.Second},
+		{failures: 6, want: 8 * time.Second},
+		{failures: 7, want: 10 * time.Second},
+		{failures: 100, want: 10 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := testPolicy.delay(tt.failures, testPolicy.FreeAttempts); got != tt.want {
+			t.Errorf("delay(%d) = %s, want %s", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestLogin(t *testing.T) {
+	const password = "correct horse"
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := lib.NewEphemeralKeySet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// attempt - попытка входа; wait сдвигает время перед ней
+	type attempt struct {
+		wait       time.Duration
+		login      string
+		password   string
+		ip         string
+		wantErr    error // nil - успешный вход
+		wantRetry  time.Duration
+		wantReason string
+	}
+	wrong := func(login string) attempt {
+		return attempt{login: login, password: "wrong", ip: "10.0.0.1", wantErr: ErrInvalidCredentials, wantReason: domain.LoginReasonInvalidCredentials}
+	}
+	tests := []struct {
+		name       string
+		disabled   bool
+		attempts   []attempt
+		wantLocked bool
+	}{
+		{
+			name:     "success",
+			attempts: []attempt{{login: "student", password: password, ip: "10.0.0.1"}},
+		},
+		{
+			name: "free attempts are not delayed",
+			attempts: []attempt{
+				wrong("student"), wrong("student"),
+				{login: "student", password: password, ip: "10.0.0.1"},
+			},
+		},
+		{
+			name: "delay after free attempts applies to correct password",
+			attempts: []attempt{
+				wrong("student"), wrong("student"), wrong("student"),
+				{login: "student", password: password, ip: "10.0.0.1", wantErr: ErrLoginThrottled, wantRetry: time.Second, wantReason: domain.LoginReasonThrottled},
+			},
+		},
+		{
+			name: "login case does not give extra attempts",
+			attempts: []attempt{
+				wrong("student"), wrong("Student"), wrong("STUDENT"),
+				{login: "student", password: password, ip: "10.0.0.2", wantErr: ErrLoginThrottled, wantRetry: time.Second, wantReason: domain.LoginReasonThrottled},
+			},
+		},
+		{
+			name: "delay doubles",
+			attempts: []attempt{
+				wrong("student"), wrong("student"), wrong("student"),
+				{wait: time.Second, login: "student", password: "wrong", ip: "10.0.0.1", wantErr: ErrInvalidCredentials, wantReason: domain.LoginReasonInvalidCredentials},
+				{login: "student", password: password, ip: "10.0.0.1", wantErr: ErrLoginThrottled, wantRetry: 2 * time.Second, wantReason: domain.LoginReasonThrottled},
+			},
+		},
+		{
+			name: "success after delay resets counter",
+			attempts: []attempt{
+				wrong("student"), wrong("student"), wrong("student"),
+				{wait: time.Second, login: "student", password: password, ip: "10.0.0.1"},
+				wrong("student"),
+				{login: "student", password: password, ip: "10.0.0.1"},
+			},
+		},
+		{
+			name: "failures outside window are forgotten",
+			attempts: []attempt{
+				wrong("student"), wrong("student"), wrong("student"),
+				{wait: time.Hour + time.Minute, login: "student", password: "wrong", ip: "10.0.0.1", wantErr: ErrInvalidCredentials, wantReason: domain.LoginReasonInvalidCredentials},
+				{login: "student", password: password, ip: "10.0.0.1"},
+			},
+		},
+		{
+			name: "unknown login is throttled the same way",
+			attempts: []attempt{
+				wrong("ghost"), wrong("ghost"), wrong("ghost"),
+				{login: "ghost", password: "wrong", ip: "10.0.0.1", wantErr: ErrLoginThrottled, wantRetry: time.Second, wantReason: domain.LoginReasonThrottled},
+			},
+		},
+		{
+			name: "ip is throttled across logins",
+			attempts: []attempt{
+				wrong("a"), wrong("b"), wrong("c"), wrong("d"), wrong("e"),
+				{login: "student", password: password, ip: "10.0.0.1", wantErr: ErrLoginThrottled, wantRetry: time.Second, wantReason: domain.LoginReasonThrottled},
+				{login: "student", password: password, ip: "10.0.0.2"},
+			},
+		},
+		{
+			name: "lockout looks like a wrong password",
+			attempts: []attempt{
+				wrong("student"), wrong("student"), wrong("student"),
+				{wait: time.Minute, login: "student", password: "wrong", ip: "10.0.0.2", wantErr: ErrInvalidCredentials, wantReason: domain.LoginReasonInvalidCredentials},
+				{wait: time.Minute, login: "student", password: "wrong", ip: "10.0.0.3", wantErr: ErrInvalidCredentials, wantReason: domain.LoginReasonInvalidCredentials},
+				{wait: time.Minute, login: "student", password: "wrong", ip: "10.0.0.4", wantErr: ErrInvalidCredentials, wantReason: domain.LoginReasonInvalidCredentials},
+				{wait: time.Minute, login: "student", password: password, ip: "10.0.0.5", wantErr: ErrInvalidCredentials, wantReason: domain.LoginReasonLocked},
+			},
+			wantLocked: true,
+		},
+		{
+			name:     "disabled user",
+			disabled: true,
+			attempts: []attempt{
+				{login: "student", password: password, ip: "10.0.0.1", wantErr: ErrUserDisabled, wantReason: domain.LoginReasonDisabled},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := &domain.User{ID: uuid.New(), Login: "student", Role: domain.RoleUser, PassHash: hash, Disabled: tt.disabled}
+			users := newMemoryUserRepo(user)
+			sessions := newMemorySessionRepo(users)
+			logins := newMemoryLoginRepo(users)
+			ui := &UserInteractor{
+				userRepo: users, sessionRepo: sessions, loginRepo: logins,
+				keys: keys, tokenTTL: time.Minute, refreshTTL: time.Hour, loginPolicy: testPolicy,
+			}
+
+			for i, a := range tt.attempts {
+				logins.age(a.wait)
+				pair, err := ui.Login(context.Background(), a.login, a.password, domain.ClientInfo{IP: a.ip})
+				if a.wantErr == nil {
+					if err != nil || pair == nil || pair.AccessToken == "" {
+						t.Fatalf("attempt %d: Login() = %v, %v, want tokens", i, pair, err)
+					}
+				} else if !errors.Is(err, a.wantErr) {
+					t.Fatalf("attempt %d: Login() error = %v, want %v", i, err, a.wantErr)
+				}
+				if a.wantRetry > 0 {
+					var derr *domain.Error
+					// Время ожидания считается от последней ошибки, поэтому допускаем погрешность
+					if !errors.As(err, &derr) || derr.RetryAfter > a.wantRetry || derr.RetryAfter < a.wantRetry-time.Second/2 {
+						t.Fatalf("attempt %d: retry after = %v, want about %s", i, err, a.wantRetry)
+					}
+				}
+				event := logins.events[len(logins.events)-1]
+				if event.Reason != a.wantReason || event.Success != (a.wantErr == nil) || event.IP != a.ip {
+					t.Fatalf("attempt %d: login event = %+v, want reason %q", i, event, a.wantReason)
+				}
+			}
+			if locked := users.users[user.ID].LockedUntil != nil; locked != tt.wantLocked {
+				t.Fatalf("locked = %v, want %v", locked, tt.wantLocked)
+			}
+		})
+	}
+}
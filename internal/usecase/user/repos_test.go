@@ -0,0 +1,161 @@
+package user
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/immxrtalbeast/plandstu/internal/domain"
+	"gorm.io/gorm"
+)
+
+// Репозитории в памяти для тестов интерактора. Реализованы только методы,
+// которые вызывают тесты, остальные паникуют через встроенный nil-интерфейс.
+
+type memoryUserRepo struct {
+	domain.UserRepository
+	users map[uuid.UUID]*domain.User
+}
+
+func newMemoryUserRepo(users ...*domain.User) *memoryUserRepo {
+	r := &memoryUserRepo{users: make(map[uuid.UUID]*domain.User)}
+	for _, user := range users {
+		r.users[user.ID] = user
+	}
+	return r
+}
+
+func (r *memoryUserRepo) User(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return &domain.User{}, gorm.ErrRecordNotFound
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (r *memoryUserRepo) UserByLogin(ctx context.Context, login string) (*domain.User, error) {
+	for _, user := range r.users {
+		if strings.EqualFold(user.Login, login) {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return &domain.User{}, gorm.ErrRecordNotFound
+}
+
+type memorySessionRepo struct {
+	domain.SessionRepository
+	users         *memoryUserRepo
+	sessions      map[uuid.UUID]*domain.RefreshSession
+	revokedTokens map[string]bool
+}
+
+func newMemorySessionRepo(users *memoryUserRepo) *memorySessionRepo {
+	return &memorySessionRepo{
+		users:         users,
+		sessions:      make(map[uuid.UUID]*domain.RefreshSession),
+		revokedTokens: make(map[string]bool),
+	}
+}
+
+func (r *memorySessionRepo) TokenRevoked(ctx context.Context, jti string) (bool, error) {
+	return r.revokedTokens[jti], nil
+}
+
+// RevokeUserSessions повторяет psql-реализацию: граница действия токенов обрезается до секунды.
+func (r *memorySessionRepo) RevokeUserSessions(ctx context.Context, userID uuid.UUID) error {
+	now := time.Now()
+	for _, session := range r.sessions {
+		if session.UserID == userID && session.RevokedAt == nil {
+			session.RevokedAt = &now
+		}
+	}
+	if user, ok := r.users.users[userID]; ok {
+		validAfter := now.Truncate(time.Second)
+		user.TokensValidAfter = &validAfter
+	}
+	return nil
+}
+
+func (r *memorySessionRepo) CreateSession(ctx context.Context, session *domain.RefreshSession) error {
+	r.sessions[session.ID] = session
+	return nil
+}
+
+func (r *memorySessionRepo) SessionByHash(ctx context.Context, tokenHash string) (*domain.RefreshSession, error) {
+	for _, session := range r.sessions {
+		if session.TokenHash == tokenHash {
+			copied := *session
+			return &copied, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *memorySessionRepo) RotateSession(ctx context.Context, oldID uuid.UUID, next *domain.RefreshSession) error {
+	old, ok := r.sessions[oldID]
+	if !ok || old.RevokedAt != nil {
+		return gorm.ErrRecordNotFound
+	}
+	now := time.Now()
+	old.RevokedAt = &now
+	old.ReplacedBy = &next.ID
+	r.sessions[next.ID] = next
+	return nil
+}
+
+// memoryLoginRepo хранит счётчики неудачных входов и журнал, блокирует пользователей в users.
+type memoryLoginRepo struct {
+	domain.LoginRepository
+	users     *memoryUserRepo
+	throttles map[string]*domain.LoginThrottle
+	events    []domain.LoginEvent
+}
+
+func newMemoryLoginRepo(users *memoryUserRepo) *memoryLoginRepo {
+	return &memoryLoginRepo{users: users, throttles: make(map[string]*domain.LoginThrottle)}
+}
+
+func (r *memoryLoginRepo) RecordFailure(ctx context.Context, kind string, key string, windowStart time.Time) (int, error) {
+	throttle, ok := r.throttles[kind+":"+key]
+	if !ok || throttle.LastFailureAt.Before(windowStart) {
+		throttle = &domain.LoginThrottle{Kind: kind, Key: key}
+		r.throttles[kind+":"+key] = throttle
+	}
+	throttle.Failures++
+	throttle.LastFailureAt = time.Now()
+	return throttle.Failures, nil
+}
+
+func (r *memoryLoginRepo) Throttle(ctx context.Context, kind string, key string) (*domain.LoginThrottle, error) {
+	throttle, ok := r.throttles[kind+":"+key]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *throttle
+	return &copied, nil
+}
+
+func (r *memoryLoginRepo) ResetFailures(ctx context.Context, kind string, key string) error {
+	delete(r.throttles, kind+":"+key)
+	return nil
+}
+
+func (r *memoryLoginRepo) LockUser(ctx context.Context, userID uuid.UUID, until *time.Time) error {
+	r.users.users[userID].LockedUntil = until
+	return nil
+}
+
+func (r *memoryLoginRepo) WriteLoginEvent(ctx context.Context, event *domain.LoginEvent) error {
+	r.events = append(r.events, *event)
+	return nil
+}
+
+// age сдвигает последнюю ошибку в прошлое, как будто прошло d.
+func (r *memoryLoginRepo) age(d time.Duration) {
+	for _, throttle := range r.throttles {
+		throttle.LastFailureAt = throttle.LastFailureAt.Add(-d)
+	}
+}
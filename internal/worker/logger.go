@@ -0,0 +1,27 @@
+package worker
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+// asynqLogger направляет внутренние логи asynq в slog.
+type asynqLogger struct {
+	log *slog.Logger
+}
+
+func newAsynqLogger(log *slog.Logger) *asynqLogger {
+	return &asynqLogger{log: log.With(slog.String("component", "asynq"))}
+}
+
+func (l *asynqLogger) Debug(args ...interface{}) { l.log.Debug(fmt.Sprint(args...)) }
+func (l *asynqLogger) Info(args ...interface{})  { l.log.Info(fmt.Sprint(args...)) }
+func (l *asynqLogger) Warn(args ...interface{})  { l.log.Warn(fmt.Sprint(args...)) }
+func (l *asynqLogger) Error(args ...interface{}) { l.log.Error(fmt.Sprint(args...)) }
+
+// Fatal завершает процесс, как и стандартный логгер asynq.
+func (l *asynqLogger) Fatal(args ...interface{}) {
+	l.log.Error(fmt.Sprint(args...))
+	os.Exit(1)
+}
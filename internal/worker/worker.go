@@ -1,89 +1,112 @@
 package worker
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
+	"log/slog"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/hibiken/asynq"
 	"github.com/immxrtalbeast/plandstu/internal/domain"
+	"github.com/immxrtalbeast/plandstu/internal/llmclient"
+	"github.com/immxrtalbeast/plandstu/internal/logging"
+	"github.com/immxrtalbeast/plandstu/internal/metrics"
 	"github.com/immxrtalbeast/plandstu/internal/task"
+	"github.com/immxrtalbeast/plandstu/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"gorm.io/datatypes"
 )
 
 type Worker struct {
 	server  *asynq.Server
+	log     *slog.Logger
 	testINT domain.TestInteractor
+	llm     llmclient.Client
 }
 
-func NewWorker(redisAddr string, concurrency int, testINT domain.TestInteractor) *Worker {
+// shutdownTimeout - сколько ждать завершения задач при остановке,
+// незавершённые задачи вернутся в очередь и будут выполнены повторно.
+func NewWorker(redisAddr string, concurrency int, shutdownTimeout time.Duration, log *slog.Logger, testINT domain.TestInteractor, llm llmclient.Client) *Worker {
 	return &Worker{
 		server: asynq.NewServer(
 			asynq.RedisClientOpt{Addr: redisAddr},
 			asynq.Config{
-				Concurrency: concurrency,
+				Concurrency:     concurrency,
+				ShutdownTimeout: shutdownTimeout,
+				Logger:          newAsynqLogger(log),
 			},
 		),
+		log:     log,
 		testINT: testINT,
+		llm:     llm,
 	}
 }
 
+// Start запускает обработку задач и сразу возвращает управление.
 func (w *Worker) Start() error {
 	mux := asynq.NewServeMux()
 	w.registerHandlers(mux)
-	return w.server.Run(mux)
+	return w.server.Start(mux)
 }
+
+// Shutdown перестаёт брать новые задачи и ждёт завершения текущих.
+func (w *Worker) Shutdown() {
+	w.server.Shutdown()
+}
+
 func (w *Worker) handleGenerateTestTask(ctx context.Context, t *asynq.Task) error {
 	var payload task.GenerateTestPayload
 	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
 		return fmt.Errorf("invalid payload: %v", err)
 	}
+	// Продолжаем трассу HTTP запроса, который поставил задачу
+	ctx, span := tracing.Start(tracing.Extract(ctx, payload.TraceContext), "task.process "+t.Type(),
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("test.id", payload.TestID),
+			attribute.Int("discipline.id", payload.DisciplineID),
+		),
+	)
+	defer span.End()
 
-	client := &http.Client{Timeout: 60 * time.Minute}
-	reqBody := map[string]interface{}{
-		"test_id": payload.TestID,
-		"themes":  payload.Themes,
+	// Логгер задачи продолжает request ID запроса, который её поставил
+	taskID, _ := asynq.GetTaskID(ctx)
+	ctx = logging.WithLogger(ctx, w.log.With(
+		slog.String("task_id", taskID),
+		slog.String("user_id", payload.UserID),
+		slog.Int("discipline_id", payload.DisciplineID),
+	))
+	if payload.RequestID != "" {
+		ctx = logging.WithRequestID(ctx, payload.RequestID)
 	}
+	log := logging.FromContext(ctx)
+	log.Info("generating test", slog.String("test_id", payload.TestID))
 
-	jsonBody, err := json.Marshal(reqBody)
-	if err != nil {
-		return fmt.Errorf("failed to marshal request body: %w", err)
-	}
-	req, err := http.NewRequest("POST", payload.LLMServiceURL+"api/test-workflow", bytes.NewBuffer(jsonBody))
+	err := w.generateTest(ctx, payload)
+	tracing.RecordError(span, err)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		log.Error("test generation failed", slog.String("op", "worker.generate_test"), logging.Err(err))
 	}
-	req.Header.Set("User-Agent", "LLM/1.0")
+	return err
+}
 
-	resp, err := client.Do(req)
+func (w *Worker) generateTest(ctx context.Context, payload task.GenerateTestPayload) error {
+	testID, err := uuid.Parse(payload.TestID)
 	if err != nil {
-		return fmt.Errorf("llm request failed: %v", err)
-	}
-	defer func() {
-		if err := resp.Body.Close(); err != nil {
-			fmt.Printf("warning: failed to close response body: %v\n", err)
-		}
-	}()
-
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("llm returned status: %d", resp.StatusCode)
+		return fmt.Errorf("invalid test ID format: %w", err)
 	}
-
-	data, err := io.ReadAll(resp.Body)
+	data, err := w.llm.GenerateTest(ctx, llmclient.TestRequest{TestID: testID, Themes: payload.Themes})
 	if err != nil {
-		return fmt.Errorf("failed to read response: %v", err)
+		return fmt.Errorf("llm request failed: %w", err)
 	}
-
-	testID, err := uuid.Parse(payload.TestID)
+	answers, err := w.testINT.FetchCorrectAnswers(ctx, testID)
 	if err != nil {
-		return fmt.Errorf("invalid test ID format: %w", err)
+		return fmt.Errorf("failed to get answers: %v", err)
 	}
-	_, err = w.testINT.CreateTest(ctx, testID, datatypes.JSON(data), payload.HistoryID, false)
+	_, err = w.testINT.CreateTest(ctx, testID, datatypes.JSON(data), answers, payload.HistoryID, false)
 	if err != nil {
 		return fmt.Errorf("failed to save test: %v", err)
 	}
@@ -94,6 +117,7 @@ func (w *Worker) ProcessTask(ctx context.Context, t *asynq.Task) error {
 	return w.handleGenerateTestTask(ctx, t)
 }
 func (w *Worker) registerHandlers(mux *asynq.ServeMux) {
+	mux.Use(metrics.TaskMiddleware)
 	mux.Handle(
 		task.QueueGenerateTest,
 		asynq.HandlerFunc(w.ProcessTask),
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS teacher_tests;
+DROP TABLE IF EXISTS reports;
+DROP TABLE IF EXISTS roadmap_tests;
+DROP TABLE IF EXISTS roadmap_histories;
+DROP TABLE IF EXISTS histories;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,70 @@
+-- Схема, которую создавал gorm AutoMigrate до перехода на миграции.
+-- IF NOT EXISTS позволяет применить миграцию к уже существующей базе.
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS users (
+    id         uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    login      text NOT NULL CONSTRAINT uni_users_login UNIQUE,
+    pass_hash  bytea NOT NULL,
+    created_at timestamptz,
+    faculty    text,
+    role       text NOT NULL DEFAULT 'User',
+    direction  text,
+    "group"    text
+);
+
+CREATE TABLE IF NOT EXISTS histories (
+    id              uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id         uuid,
+    messages_json_b jsonb,
+    created_at      timestamptz,
+    CONSTRAINT fk_users_histories FOREIGN KEY (user_id)
+        REFERENCES users (id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_histories_user_id ON histories (user_id);
+
+CREATE TABLE IF NOT EXISTS roadmap_histories (
+    id            uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    discipline_id bigint NOT NULL,
+    user_id       uuid,
+    blocks_json_b jsonb,
+    created_at    timestamptz,
+    CONSTRAINT fk_users_roadmap_histories FOREIGN KEY (user_id)
+        REFERENCES users (id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_roadmap_histories_user_id ON roadmap_histories (user_id);
+
+CREATE TABLE IF NOT EXISTS roadmap_tests (
+    id                 uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    roadmap_history_id uuid,
+    status             varchar(50) DEFAULT 'pending',
+    details_json_b     jsonb,
+    results_json_b     jsonb,
+    is_first           boolean DEFAULT false,
+    created_at         timestamptz,
+    passed_at          timestamptz,
+    CONSTRAINT fk_roadmap_histories_tests FOREIGN KEY (roadmap_history_id)
+        REFERENCES roadmap_histories (id)
+);
+CREATE INDEX IF NOT EXISTS idx_roadmap_tests_roadmap_history_id ON roadmap_tests (roadmap_history_id);
+
+CREATE TABLE IF NOT EXISTS reports (
+    id               uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    discipline_title text NOT NULL,
+    discipline_id    bigint NOT NULL,
+    "group"          text NOT NULL,
+    user_id          uuid,
+    details_json_b   jsonb,
+    created_at       timestamptz,
+    CONSTRAINT fk_users_reports FOREIGN KEY (user_id)
+        REFERENCES users (id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_reports_user_id ON reports (user_id);
+
+CREATE TABLE IF NOT EXISTS teacher_tests (
+    id             uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    discipline_id  bigint NOT NULL,
+    details_json_b jsonb,
+    answers        jsonb,
+    created_at     timestamptz
+);
@@ -0,0 +1 @@
+ALTER TABLE roadmap_tests DROP COLUMN IF EXISTS answers_json_b;
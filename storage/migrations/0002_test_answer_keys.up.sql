@@ -0,0 +1 @@
+ALTER TABLE roadmap_tests ADD COLUMN IF NOT EXISTS answers_json_b jsonb;
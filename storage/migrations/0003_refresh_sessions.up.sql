@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS refresh_sessions (
+    id          uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id     uuid NOT NULL,
+    token_hash  varchar(64) NOT NULL,
+    user_agent  text,
+    ip          text,
+    expires_at  timestamptz NOT NULL,
+    revoked_at  timestamptz,
+    replaced_by uuid,
+    created_at  timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_refresh_sessions_token_hash ON refresh_sessions (token_hash);
+CREATE INDEX IF NOT EXISTS idx_refresh_sessions_user_id ON refresh_sessions (user_id);
+
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+    jti        varchar(64) PRIMARY KEY,
+    user_id    uuid,
+    expires_at timestamptz NOT NULL,
+    created_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_user_id ON revoked_tokens (user_id);
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_expires_at ON revoked_tokens (expires_at);
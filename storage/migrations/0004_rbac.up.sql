@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS roles (
+    name        varchar(50) PRIMARY KEY,
+    description text,
+    created_at  timestamptz
+);
+
+CREATE TABLE IF NOT EXISTS permissions (
+    name       varchar(100) PRIMARY KEY,
+    created_at timestamptz
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role_name       varchar(50),
+    permission_name varchar(100),
+    PRIMARY KEY (role_name, permission_name)
+);
+
+CREATE TABLE IF NOT EXISTS access_grants (
+    id         uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id    uuid NOT NULL,
+    scope      varchar(32) NOT NULL,
+    value      text NOT NULL,
+    created_at timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_access_grant ON access_grants (user_id, scope, value);
@@ -0,0 +1,15 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS disabled boolean NOT NULL DEFAULT false;
+
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id          uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    actor_id    uuid,
+    action      varchar(64) NOT NULL,
+    target_type varchar(32),
+    target_id   text,
+    details     jsonb,
+    created_at  timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_actor_id ON audit_logs (actor_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_action ON audit_logs (action);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_target_id ON audit_logs (target_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_created_at ON audit_logs (created_at);
@@ -0,0 +1,5 @@
+ALTER TABLE reports DROP CONSTRAINT IF EXISTS fk_reports_study_group;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS fk_users_study_group;
+ALTER TABLE reports DROP COLUMN IF EXISTS group_id;
+ALTER TABLE users DROP COLUMN IF EXISTS group_id;
+DROP TABLE IF EXISTS groups;
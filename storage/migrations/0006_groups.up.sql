@@ -0,0 +1,46 @@
+CREATE TABLE IF NOT EXISTS groups (
+    id         uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name       varchar(50) NOT NULL,
+    faculty    text,
+    direction  text,
+    year       bigint,
+    curator_id uuid,
+    created_at timestamptz,
+    CONSTRAINT fk_groups_curator FOREIGN KEY (curator_id)
+        REFERENCES users (id) ON UPDATE CASCADE ON DELETE SET NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_groups_name ON groups (name);
+CREATE INDEX IF NOT EXISTS idx_groups_curator_id ON groups (curator_id);
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS group_id uuid;
+ALTER TABLE reports ADD COLUMN IF NOT EXISTS group_id uuid;
+CREATE INDEX IF NOT EXISTS idx_users_group_id ON users (group_id);
+CREATE INDEX IF NOT EXISTS idx_reports_group_id ON reports (group_id);
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_users_study_group') THEN
+        ALTER TABLE users ADD CONSTRAINT fk_users_study_group FOREIGN KEY (group_id)
+            REFERENCES groups (id) ON UPDATE CASCADE ON DELETE SET NULL;
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_reports_study_group') THEN
+        ALTER TABLE reports ADD CONSTRAINT fk_reports_study_group FOREIGN KEY (group_id)
+            REFERENCES groups (id) ON UPDATE CASCADE ON DELETE SET NULL;
+    END IF;
+END $$;
+
+-- Переносим строковые названия групп в справочник и проставляем ссылки.
+-- Старые названия не ограничивались по длине, обрезаем до размера колонки
+INSERT INTO groups (name, created_at)
+SELECT DISTINCT LEFT(TRIM(g), 50), NOW() FROM (
+    SELECT "group" AS g FROM users WHERE group_id IS NULL
+    UNION SELECT "group" FROM reports WHERE group_id IS NULL
+) legacy
+WHERE g IS NOT NULL AND TRIM(g) <> ''
+ON CONFLICT (name) DO NOTHING;
+
+UPDATE users SET group_id = groups.id, "group" = groups.name
+FROM groups WHERE users.group_id IS NULL AND LEFT(TRIM(users."group"), 50) = groups.name;
+
+UPDATE reports SET group_id = groups.id, "group" = groups.name
+FROM groups WHERE reports.group_id IS NULL AND LEFT(TRIM(reports."group"), 50) = groups.name;
@@ -0,0 +1,8 @@
+-- Кэш ответов парсера: справочник работает, даже когда парсер недоступен
+CREATE TABLE IF NOT EXISTS catalog_cache (
+    key        text PRIMARY KEY,
+    kind       varchar(32) NOT NULL,
+    payload    jsonb NOT NULL,
+    fetched_at timestamptz NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_catalog_cache_kind ON catalog_cache (kind);
@@ -0,0 +1,10 @@
+ALTER TABLE teacher_tests DROP CONSTRAINT IF EXISTS fk_teacher_tests_discipline;
+ALTER TABLE roadmap_histories DROP CONSTRAINT IF EXISTS fk_roadmap_histories_discipline;
+ALTER TABLE reports DROP CONSTRAINT IF EXISTS fk_reports_discipline;
+DROP INDEX IF EXISTS idx_teacher_tests_discipline_id;
+DROP INDEX IF EXISTS idx_roadmap_histories_discipline_id;
+DROP INDEX IF EXISTS idx_reports_discipline_id;
+DROP TABLE IF EXISTS direction_disciplines;
+DROP TABLE IF EXISTS disciplines;
+DROP TABLE IF EXISTS directions;
+DROP TABLE IF EXISTS faculties;
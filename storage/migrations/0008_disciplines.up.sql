@@ -0,0 +1,67 @@
+-- Локальный справочник факультетов, направлений и дисциплин, заполняется из парсера
+CREATE TABLE IF NOT EXISTS faculties (
+    id         text PRIMARY KEY,
+    name       text NOT NULL,
+    updated_at timestamptz
+);
+
+CREATE TABLE IF NOT EXISTS directions (
+    id         text PRIMARY KEY,
+    faculty_id text NOT NULL,
+    name       text NOT NULL,
+    link       text,
+    updated_at timestamptz,
+    CONSTRAINT fk_directions_faculty FOREIGN KEY (faculty_id)
+        REFERENCES faculties (id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_directions_faculty_id ON directions (faculty_id);
+
+CREATE TABLE IF NOT EXISTS disciplines (
+    id         bigint PRIMARY KEY,
+    name       text NOT NULL,
+    link       text,
+    updated_at timestamptz
+);
+
+CREATE TABLE IF NOT EXISTS direction_disciplines (
+    direction_id  text NOT NULL,
+    discipline_id bigint NOT NULL,
+    PRIMARY KEY (direction_id, discipline_id),
+    CONSTRAINT fk_direction_disciplines_direction FOREIGN KEY (direction_id)
+        REFERENCES directions (id) ON DELETE CASCADE,
+    CONSTRAINT fk_direction_disciplines_discipline FOREIGN KEY (discipline_id)
+        REFERENCES disciplines (id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_direction_disciplines_discipline_id ON direction_disciplines (discipline_id);
+
+-- Дисциплины, на которые уже ссылаются данные, заводим с названием из отчётов.
+-- Настоящие названия придут при синхронизации с парсером.
+INSERT INTO disciplines (id, name, updated_at)
+SELECT discipline_id, COALESCE(MAX(title), ''), NOW() FROM (
+    SELECT discipline_id, NULLIF(TRIM(discipline_title), '') AS title FROM reports
+    UNION ALL SELECT discipline_id, NULL FROM roadmap_histories
+    UNION ALL SELECT discipline_id, NULL FROM teacher_tests
+) refs
+WHERE discipline_id IS NOT NULL
+GROUP BY discipline_id
+ON CONFLICT (id) DO NOTHING;
+
+CREATE INDEX IF NOT EXISTS idx_reports_discipline_id ON reports (discipline_id);
+CREATE INDEX IF NOT EXISTS idx_roadmap_histories_discipline_id ON roadmap_histories (discipline_id);
+CREATE INDEX IF NOT EXISTS idx_teacher_tests_discipline_id ON teacher_tests (discipline_id);
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_reports_discipline') THEN
+        ALTER TABLE reports ADD CONSTRAINT fk_reports_discipline FOREIGN KEY (discipline_id)
+            REFERENCES disciplines (id) ON UPDATE CASCADE ON DELETE RESTRICT;
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_roadmap_histories_discipline') THEN
+        ALTER TABLE roadmap_histories ADD CONSTRAINT fk_roadmap_histories_discipline FOREIGN KEY (discipline_id)
+            REFERENCES disciplines (id) ON UPDATE CASCADE ON DELETE RESTRICT;
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_teacher_tests_discipline') THEN
+        ALTER TABLE teacher_tests ADD CONSTRAINT fk_teacher_tests_discipline FOREIGN KEY (discipline_id)
+            REFERENCES disciplines (id) ON UPDATE CASCADE ON DELETE RESTRICT;
+    END IF;
+END $$;
@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS histories (
+    id              uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id         uuid,
+    messages_json_b jsonb,
+    created_at      timestamptz,
+    CONSTRAINT fk_users_histories FOREIGN KEY (user_id)
+        REFERENCES users (id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_histories_user_id ON histories (user_id);
+
+-- Собираем сообщения обратно в один массив на пользователя
+INSERT INTO histories (user_id, messages_json_b, created_at)
+SELECT user_id,
+       jsonb_agg(jsonb_build_object(
+           'content', content,
+           'type', role,
+           'additional_kwargs', COALESCE(metadata->'additional_kwargs', '{}'::jsonb),
+           'response_metadata', COALESCE(metadata->'response_metadata', '{}'::jsonb),
+           'example', COALESCE((metadata->>'example')::boolean, false),
+           'timestamp', sent_at) ORDER BY sent_at, created_at),
+       MAX(created_at)
+FROM chat_messages
+WHERE deleted_at IS NULL
+GROUP BY user_id
+ON CONFLICT (user_id) DO NOTHING;
+
+DROP TABLE IF EXISTS chat_messages;
@@ -0,0 +1,37 @@
+-- История чата построчно вместо одного перезаписываемого JSONB на пользователя
+CREATE TABLE IF NOT EXISTS chat_messages (
+    id           uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id      uuid NOT NULL,
+    role         varchar(32) NOT NULL,
+    content      text NOT NULL,
+    metadata     jsonb,
+    content_hash varchar(64) NOT NULL,
+    sent_at      timestamptz NOT NULL,
+    created_at   timestamptz,
+    deleted_at   timestamptz,
+    CONSTRAINT fk_users_chat_messages FOREIGN KEY (user_id)
+        REFERENCES users (id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+-- Включает удалённые строки: очищенные сообщения не должны вернуться при повторной выгрузке
+CREATE UNIQUE INDEX IF NOT EXISTS idx_chat_messages_identity
+    ON chat_messages (user_id, sent_at, role, content_hash);
+CREATE INDEX IF NOT EXISTS idx_chat_messages_deleted_at ON chat_messages (deleted_at);
+
+-- Переносим сохранённые истории (массив сообщений в messages_json_b)
+INSERT INTO chat_messages (user_id, role, content, metadata, content_hash, sent_at, created_at)
+SELECT h.user_id,
+       COALESCE(m->>'type', ''),
+       COALESCE(m->>'content', ''),
+       jsonb_strip_nulls(jsonb_build_object(
+           'additional_kwargs', NULLIF(m->'additional_kwargs', '{}'::jsonb),
+           'response_metadata', NULLIF(m->'response_metadata', '{}'::jsonb),
+           'example', CASE WHEN (m->>'example')::boolean THEN true END)),
+       encode(sha256(convert_to(COALESCE(m->>'content', ''), 'UTF8')), 'hex'),
+       COALESCE((m->>'timestamp')::timestamptz, h.created_at, NOW()),
+       NOW()
+FROM histories h
+CROSS JOIN LATERAL jsonb_array_elements(h.messages_json_b) m
+WHERE h.user_id IS NOT NULL AND jsonb_typeof(h.messages_json_b) = 'array'
+ON CONFLICT DO NOTHING;
+
+DROP TABLE IF EXISTS histories;
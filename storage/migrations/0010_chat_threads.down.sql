@@ -0,0 +1,14 @@
+-- Одинаковые сообщения из разных тредов схлопываются в одно
+DELETE FROM chat_messages a
+USING chat_messages b
+WHERE a.user_id = b.user_id AND a.sent_at = b.sent_at AND a.role = b.role
+    AND a.content_hash = b.content_hash AND a.ctid > b.ctid;
+
+DROP INDEX IF EXISTS idx_chat_messages_user_id;
+DROP INDEX IF EXISTS idx_chat_messages_identity;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_chat_messages_identity
+    ON chat_messages (user_id, sent_at, role, content_hash);
+
+ALTER TABLE chat_messages DROP CONSTRAINT IF EXISTS fk_chat_threads_messages;
+ALTER TABLE chat_messages DROP COLUMN IF EXISTS thread_id;
+DROP TABLE IF EXISTS chat_threads;
@@ -0,0 +1,46 @@
+CREATE TABLE IF NOT EXISTS chat_threads (
+    id              uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id         uuid NOT NULL,
+    title           varchar(200) NOT NULL,
+    discipline_id   bigint,
+    roadmap_block   text,
+    archived_at     timestamptz,
+    last_message_at timestamptz,
+    created_at      timestamptz,
+    updated_at      timestamptz,
+    CONSTRAINT fk_users_chat_threads FOREIGN KEY (user_id)
+        REFERENCES users (id) ON UPDATE CASCADE ON DELETE CASCADE,
+    CONSTRAINT fk_chat_threads_discipline FOREIGN KEY (discipline_id)
+        REFERENCES disciplines (id) ON UPDATE CASCADE ON DELETE SET NULL
+);
+CREATE INDEX IF NOT EXISTS idx_chat_threads_user_id ON chat_threads (user_id);
+CREATE INDEX IF NOT EXISTS idx_chat_threads_discipline_id ON chat_threads (discipline_id);
+
+ALTER TABLE chat_messages ADD COLUMN IF NOT EXISTS thread_id uuid;
+
+-- Существующая история каждого пользователя становится его первым тредом
+INSERT INTO chat_threads (user_id, title, last_message_at, created_at, updated_at)
+SELECT user_id, 'New chat', MAX(sent_at), MIN(created_at), NOW()
+FROM chat_messages
+WHERE thread_id IS NULL
+GROUP BY user_id;
+
+UPDATE chat_messages SET thread_id = chat_threads.id
+FROM chat_threads
+WHERE chat_messages.thread_id IS NULL AND chat_messages.user_id = chat_threads.user_id;
+
+ALTER TABLE chat_messages ALTER COLUMN thread_id SET NOT NULL;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_chat_threads_messages') THEN
+        ALTER TABLE chat_messages ADD CONSTRAINT fk_chat_threads_messages FOREIGN KEY (thread_id)
+            REFERENCES chat_threads (id) ON UPDATE CASCADE ON DELETE CASCADE;
+    END IF;
+END $$;
+
+-- Сообщения уникальны в пределах треда
+DROP INDEX IF EXISTS idx_chat_messages_identity;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_chat_messages_identity
+    ON chat_messages (thread_id, sent_at, role, content_hash);
+CREATE INDEX IF NOT EXISTS idx_chat_messages_user_id ON chat_messages (user_id, sent_at);
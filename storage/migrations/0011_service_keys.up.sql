@@ -0,0 +1,22 @@
+-- Ключи внутренних сервисов для подписанных (HMAC) запросов
+CREATE TABLE IF NOT EXISTS service_keys (
+    id           uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    service      varchar(64) NOT NULL,
+    secret       text NOT NULL,
+    scopes       jsonb NOT NULL DEFAULT '[]'::jsonb,
+    created_by   uuid,
+    created_at   timestamptz,
+    last_used_at timestamptz,
+    revoked_at   timestamptz
+);
+
+-- Использованные nonce, защита от повтора запроса
+CREATE TABLE IF NOT EXISTS service_nonces (
+    key_id     uuid NOT NULL,
+    nonce      varchar(64) NOT NULL,
+    expires_at timestamptz NOT NULL,
+    PRIMARY KEY (key_id, nonce),
+    CONSTRAINT fk_service_nonces_key FOREIGN KEY (key_id)
+        REFERENCES service_keys (id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_service_nonces_expires_at ON service_nonces (expires_at);
@@ -0,0 +1,26 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS locked_until timestamptz;
+
+-- Счётчики неудачных входов подряд по логину и IP
+CREATE TABLE IF NOT EXISTS login_throttles (
+    kind            varchar(16) NOT NULL,
+    key             varchar(255) NOT NULL,
+    failures        bigint NOT NULL DEFAULT 0,
+    last_failure_at timestamptz NOT NULL,
+    PRIMARY KEY (kind, key)
+);
+
+-- Журнал входов, виден самому пользователю
+CREATE TABLE IF NOT EXISTS login_events (
+    id         uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id    uuid,
+    login      varchar(255) NOT NULL,
+    success    boolean NOT NULL,
+    reason     varchar(32),
+    ip         text,
+    user_agent text,
+    created_at timestamptz,
+    CONSTRAINT fk_login_events_user FOREIGN KEY (user_id)
+        REFERENCES users (id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_login_events_user_id ON login_events (user_id);
+CREATE INDEX IF NOT EXISTS idx_login_events_created_at ON login_events (created_at);
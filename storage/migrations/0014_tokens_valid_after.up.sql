@@ -0,0 +1,2 @@
+-- Access-токены, выпущенные раньше этого момента, больше не принимаются
+ALTER TABLE users ADD COLUMN IF NOT EXISTS tokens_valid_after timestamptz;
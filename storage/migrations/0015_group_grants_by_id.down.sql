@@ -0,0 +1,2 @@
+UPDATE access_grants SET value = groups.name
+FROM groups WHERE access_grants.scope = 'group' AND access_grants.value = groups.id::text;
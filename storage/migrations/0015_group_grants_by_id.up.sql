@@ -0,0 +1,7 @@
+-- Гранты на группы хранят ID группы вместо названия, чтобы переименование их не теряло
+UPDATE access_grants SET value = groups.id::text
+FROM groups WHERE access_grants.scope = 'group' AND access_grants.value = groups.name;
+
+-- Гранты на группы, которых нет в справочнике, уже ничего не дают
+DELETE FROM access_grants
+WHERE scope = 'group' AND value NOT IN (SELECT id::text FROM groups);
@@ -0,0 +1,87 @@
+package psql
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/immxrtalbeast/plandstu/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type AccessRepository struct {
+	db *gorm.DB
+}
+
+func NewAccessRepository(db *gorm.DB) *AccessRepository {
+	return &AccessRepository{db: db}
+}
+
+// SeedRoles добавляет недостающие роли и права. Уже существующие связи не трогает,
+// поэтому права, выданные вручную, сохраняются.
+func (r *AccessRepository) SeedRoles(ctx context.Context, roles map[string][]string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for role, permissions := range roles {
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&domain.Role{Name: role}).Error; err != nil {
+				return err
+			}
+			for _, permission := range permissions {
+				if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&domain.Permission{Name: permission}).Error; err != nil {
+					return err
+				}
+				link := domain.RolePermission{RoleName: role, PermissionName: permission}
+				if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&link).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func (r *AccessRepository) RoleExists(ctx context.Context, role string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&domain.Role{}).Where("name = ?", role).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *AccessRepository) RolePermissions(ctx context.Context, role string) ([]string, error) {
+	var permissions []string
+	err := r.db.WithContext(ctx).
+		Model(&domain.RolePermission{}).
+		Where("role_name = ?", role).
+		Pluck("permission_name", &permissions).
+		Error
+	return permissions, err
+}
+
+func (r *AccessRepository) GrantValues(ctx context.Context, userID uuid.UUID, scope string) ([]string, error) {
+	var values []string
+	err := r.db.WithContext(ctx).
+		Model(&domain.AccessGrant{}).
+		Where("user_id = ? AND scope = ?", userID, scope).
+		Pluck("value", &values).
+		Error
+	return values, err
+}
+
+func (r *AccessRepository) Grants(ctx context.Context, userID uuid.UUID) ([]*domain.AccessGrant, error) {
+	var grants []*domain.AccessGrant
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("scope, value").Find(&grants).Error
+	return grants, err
+}
+
+func (r *AccessRepository) CreateGrant(ctx context.Context, grant *domain.AccessGrant) error {
+	return r.db.WithContext(ctx).Create(grant).Error
+}
+
+func (r *AccessRepository) DeleteGrant(ctx context.Context, grantID uuid.UUID) error {
+	result := r.db.WithContext(ctx).Where("id = ?", grantID).Delete(&domain.AccessGrant{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package psql
+
+import (
+	"context"
+
+	"github.com/immxrtalbeast/plandstu/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CatalogRepository struct {
+	db *gorm.DB
+}
+
+func NewCatalogRepository(db *gorm.DB) *CatalogRepository {
+	return &CatalogRepository{db: db}
+}
+
+func (r *CatalogRepository) CatalogEntry(ctx context.Context, key string) (*domain.CatalogEntry, error) {
+	var entry domain.CatalogEntry
+	err := r.db.WithContext(ctx).Where("key = ?", key).First(&entry).Error
+	return &entry, err
+}
+
+func (r *CatalogRepository) SaveCatalogEntry(ctx context.Context, entry *domain.CatalogEntry) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "key"}},
+			DoUpdates: clause.AssignmentColumns([]string{"kind", "payload", "fetched_at"}),
+		}).
+		Create(entry).Error
+}
+
+func (r *CatalogRepository) CatalogEntries(ctx context.Context) ([]*domain.CatalogEntry, error) {
+	var entries []*domain.CatalogEntry
+	err := r.db.WithContext(ctx).
+		Select("key", "kind", "fetched_at").
+		Order("fetched_at").
+		Find(&entries).Error
+	return entries, err
+}
@@ -0,0 +1,104 @@
+package psql
+
+import (
+	"context"
+
+	"github.com/immxrtalbeast/plandstu/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const catalogBatchSize = 500
+
+type DisciplineRepository struct {
+	db *gorm.DB
+}
+
+func NewDisciplineRepository(db *gorm.DB) *DisciplineRepository {
+	return &DisciplineRepository{db: db}
+}
+
+func (r *DisciplineRepository) Discipline(ctx context.Context, id int) (*domain.Discipline, error) {
+	var discipline domain.Discipline
+	err := r.db.WithContext(ctx).Preload("Directions").Where("id = ?", id).First(&discipline).Error
+	return &discipline, err
+}
+
+func (r *DisciplineRepository) Disciplines(ctx context.Context, filter domain.DisciplineFilter) ([]*domain.Discipline, error) {
+	query := r.db.WithContext(ctx).Model(&domain.Discipline{})
+	if filter.DirectionID != "" || filter.FacultyID != "" {
+		links := r.db.Model(&domain.DirectionDiscipline{}).
+			Select("direction_disciplines.discipline_id").
+			Joins("JOIN directions ON directions.id = direction_disciplines.direction_id")
+		if filter.DirectionID != "" {
+			links = links.Where("directions.id = ?", filter.DirectionID)
+		}
+		if filter.FacultyID != "" {
+			links = links.Where("directions.faculty_id = ?", filter.FacultyID)
+		}
+		query = query.Where("id IN (?)", links)
+	}
+	if filter.Query != "" {
+		query = query.Where(`name ILIKE ? ESCAPE '\'`, containsPattern(filter.Query))
+	}
+	var disciplines []*domain.Discipline
+	err := query.Order("name").Find(&disciplines).Error
+	return disciplines, err
+}
+
+// SaveCatalog сохраняет справочник одной транзакцией и обновляет копию
+// названия дисциплины в отчётах.
+func (r *DisciplineRepository) SaveCatalog(ctx context.Context, snapshot *domain.CatalogSnapshot) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(snapshot.Faculties) > 0 {
+			err := tx.Clauses(upsert("id", "name", "updated_at")).
+				CreateInBatches(snapshot.Faculties, catalogBatchSize).Error
+			if err != nil {
+				return err
+			}
+		}
+		if len(snapshot.Directions) > 0 {
+			err := tx.Omit(clause.Associations).
+				Clauses(upsert("id", "faculty_id", "name", "link", "updated_at")).
+				CreateInBatches(snapshot.Directions, catalogBatchSize).Error
+			if err != nil {
+				return err
+			}
+		}
+		if len(snapshot.Disciplines) > 0 {
+			err := tx.Omit(clause.Associations).
+				Clauses(upsert("id", "name", "link", "updated_at")).
+				CreateInBatches(snapshot.Disciplines, catalogBatchSize).Error
+			if err != nil {
+				return err
+			}
+		}
+		if len(snapshot.LoadedDirections) > 0 {
+			err := tx.Where("direction_id IN ?", snapshot.LoadedDirections).
+				Delete(&domain.DirectionDiscipline{}).Error
+			if err != nil {
+				return err
+			}
+		}
+		if len(snapshot.Links) > 0 {
+			err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+				CreateInBatches(snapshot.Links, catalogBatchSize).Error
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Exec(`UPDATE reports SET discipline_title = disciplines.name
+			FROM disciplines
+			WHERE reports.discipline_id = disciplines.id
+				AND disciplines.name <> ''
+				AND reports.discipline_title <> disciplines.name`).Error
+	})
+}
+
+// upsert обновляет columns (кроме первого - ключа) при конфликте по ключу.
+func upsert(key string, columns ...string) clause.OnConflict {
+	return clause.OnConflict{
+		Columns:   []clause.Column{{Name: key}},
+		DoUpdates: clause.AssignmentColumns(columns),
+	}
+}
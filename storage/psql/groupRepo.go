@@ -0,0 +1,90 @@
+package psql
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/immxrtalbeast/plandstu/internal/domain"
+	"gorm.io/gorm"
+)
+
+type GroupRepository struct {
+	db *gorm.DB
+}
+
+func NewGroupRepository(db *gorm.DB) *GroupRepository {
+	return &GroupRepository{db: db}
+}
+
+func (r *GroupRepository) Groups(ctx context.Context, filter domain.GroupFilter) ([]*domain.Group, error) {
+	query := r.db.WithContext(ctx).Model(&domain.Group{})
+	if filter.Faculty != "" {
+		query = query.Where("faculty = ?", filter.Faculty)
+	}
+	if filter.Direction != "" {
+		query = query.Where("direction = ?", filter.Direction)
+	}
+	if filter.Year != 0 {
+		query = query.Where("year = ?", filter.Year)
+	}
+	var groups []*domain.Group
+	err := query.Order("name").Find(&groups).Error
+	return groups, err
+}
+
+func (r *GroupRepository) Group(ctx context.Context, id uuid.UUID) (*domain.Group, error) {
+	var group domain.Group
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&group).Error
+	return &group, err
+}
+
+func (r *GroupRepository) GroupByName(ctx context.Context, name string) (*domain.Group, error) {
+	var group domain.Group
+	err := r.db.WithContext(ctx).Where("name = ?", name).First(&group).Error
+	return &group, err
+}
+
+func (r *GroupRepository) CreateGroup(ctx context.Context, group *domain.Group) error {
+	return r.db.WithContext(ctx).Create(group).Error
+}
+
+// UpdateGroup обновляет группу и копию её названия у пользователей и в отчётах.
+func (r *GroupRepository) UpdateGroup(ctx context.Context, group *domain.Group) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&domain.Group{}).
+			Where("id = ?", group.ID).
+			Select("name", "faculty", "direction", "year", "curator_id").
+			Updates(group)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if err := tx.Model(&domain.User{}).Where("group_id = ?", group.ID).Update("group", group.Name).Error; err != nil {
+			return err
+		}
+		return tx.Model(&domain.Report{}).Where("group_id = ?", group.ID).Update("group", group.Name).Error
+	})
+}
+
+// DeleteGroup удаляет группу и гранты на неё. Ссылки обнуляет внешний ключ, копию
+// названия у пользователей очищаем сами, в отчётах оставляем как историю.
+func (r *GroupRepository) DeleteGroup(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&domain.User{}).Where("group_id = ?", id).Update("group", "").Error; err != nil {
+			return err
+		}
+		if err := tx.Where("scope = ? AND value = ?", domain.ScopeGroup, id.String()).Delete(&domain.AccessGrant{}).Error; err != nil {
+			return err
+		}
+		result := tx.Where("id = ?", id).Delete(&domain.Group{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
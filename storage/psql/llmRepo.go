@@ -2,6 +2,7 @@ package psql
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/immxrtalbeast/plandstu/internal/domain"
@@ -17,16 +18,138 @@ func NewLLMRepository(db *gorm.DB) *LLMRepository {
 	return &LLMRepository{db: db}
 }
 
-func (r *LLMRepository) SaveHistory(ctx context.Context, history *domain.History) (uuid.UUID, error) {
-	result := r.db.WithContext(ctx).
-		Clauses(clause.OnConflict{
-			Columns:   []clause.Column{{Name: "user_id"}},
-			DoUpdates: clause.AssignmentColumns([]string{"messages_json_b", "created_at"}),
-		}).
-		Create(history)
+func (r *LLMRepository) SaveMessages(ctx context.Context, threadID uuid.UUID, messages []domain.ChatMessage) (int64, error) {
+	if len(messages) == 0 {
+		return 0, nil
+	}
+	var saved int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Уникальный индекс учитывает и удалённые строки, так что очищенные сообщения не возвращаются
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&messages)
+		if result.Error != nil {
+			return result.Error
+		}
+		saved = result.RowsAffected
+		if saved == 0 {
+			return nil
+		}
+		return tx.Model(&domain.ChatThread{}).Where("id = ?", threadID).
+			Update("last_message_at", gorm.Expr("GREATEST(last_message_at, ?)", lastSentAt(messages))).Error
+	})
+	return saved, err
+}
+
+func (r *LLMRepository) Messages(ctx context.Context, userID uuid.UUID, filter domain.ChatFilter) ([]*domain.ChatMessage, int64, error) {
+	query := r.db.WithContext(ctx).Model(&domain.ChatMessage{}).Where("user_id = ?", userID)
+	if filter.ThreadID != uuid.Nil {
+		query = query.Where("thread_id = ?", filter.ThreadID)
+	}
+	if filter.Query != "" {
+		query = query.Where(`content ILIKE ? ESCAPE '\'`, containsPattern(filter.Query))
+	}
+	if filter.Role != "" {
+		query = query.Where("role = ?", filter.Role)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var messages []*domain.ChatMessage
+	err := query.Order("sent_at DESC, created_at DESC").Limit(filter.Limit).Offset(filter.Offset).Find(&messages).Error
+	return messages, total, err
+}
+
+func (r *LLMRepository) AllMessages(ctx context.Context, userID uuid.UUID, threadID uuid.UUID) ([]*domain.ChatMessage, error) {
+	query := r.db.WithContext(ctx).Where("user_id = ?", userID)
+	if threadID != uuid.Nil {
+		query = query.Where("thread_id = ?", threadID)
+	}
+	var messages []*domain.ChatMessage
+	err := query.Order("sent_at, created_at").Find(&messages).Error
+	return messages, err
+}
+
+func (r *LLMRepository) DeleteMessages(ctx context.Context, userID uuid.UUID, threadID uuid.UUID) error {
+	query := r.db.WithContext(ctx).Where("user_id = ?", userID)
+	if threadID != uuid.Nil {
+		query = query.Where("thread_id = ?", threadID)
+	}
+	return query.Delete(&domain.ChatMessage{}).Error
+}
+
+func (r *LLMRepository) CreateThread(ctx context.Context, thread *domain.ChatThread) error {
+	return r.db.WithContext(ctx).Create(thread).Error
+}
+
+func (r *LLMRepository) Thread(ctx context.Context, userID uuid.UUID, threadID uuid.UUID) (*domain.ChatThread, error) {
+	var thread domain.ChatThread
+	err := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", threadID, userID).First(&thread).Error
+	return &thread, err
+}
 
+func (r *LLMRepository) LatestThread(ctx context.Context, userID uuid.UUID) (*domain.ChatThread, error) {
+	var thread domain.ChatThread
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND archived_at IS NULL", userID).
+		Order("COALESCE(last_message_at, created_at) DESC").
+		First(&thread).Error
+	return &thread, err
+}
+
+func (r *LLMRepository) Threads(ctx context.Context, userID uuid.UUID, filter domain.ChatThreadFilter) ([]*domain.ChatThread, int64, error) {
+	query := r.db.WithContext(ctx).Model(&domain.ChatThread{}).Where("user_id = ?", userID)
+	if filter.Archived {
+		query = query.Where("archived_at IS NOT NULL")
+	} else {
+		query = query.Where("archived_at IS NULL")
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var threads []*domain.ChatThread
+	err := query.Order("COALESCE(last_message_at, created_at) DESC").Limit(filter.Limit).Offset(filter.Offset).Find(&threads).Error
+	return threads, total, err
+}
+
+func (r *LLMRepository) ThreadIDs(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.db.WithContext(ctx).Model(&domain.ChatThread{}).Where("user_id = ?", userID).Pluck("id", &ids).Error
+	return ids, err
+}
+
+func (r *LLMRepository) UpdateThread(ctx context.Context, thread *domain.ChatThread) error {
+	result := r.db.WithContext(ctx).Model(&domain.ChatThread{}).
+		Where("id = ? AND user_id = ?", thread.ID, thread.UserID).
+		Select("title", "archived_at", "updated_at").
+		Updates(thread)
 	if result.Error != nil {
-		return uuid.Nil, result.Error
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// DeleteThread удаляет тред вместе с сообщениями.
+func (r *LLMRepository) DeleteThread(ctx context.Context, userID uuid.UUID, threadID uuid.UUID) error {
+	result := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", threadID, userID).Delete(&domain.ChatThread{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func lastSentAt(messages []domain.ChatMessage) time.Time {
+	var last time.Time
+	for _, m := range messages {
+		if m.SentAt.After(last) {
+			last = m.SentAt
+		}
 	}
-	return history.ID, nil
+	return last
 }
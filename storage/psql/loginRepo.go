@@ -0,0 +1,66 @@
+package psql
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/immxrtalbeast/plandstu/internal/domain"
+	"gorm.io/gorm"
+)
+
+type LoginRepository struct {
+	db *gorm.DB
+}
+
+func NewLoginRepository(db *gorm.DB) *LoginRepository {
+	return &LoginRepository{db: db}
+}
+
+// RecordFailure увеличивает счётчик одним запросом, чтобы параллельные попытки не терялись.
+func (r *LoginRepository) RecordFailure(ctx context.Context, kind string, key string, windowStart time.Time) (int, error) {
+	var failures int
+	err := r.db.WithContext(ctx).Raw(`INSERT INTO login_throttles (kind, key, failures, last_failure_at)
+		VALUES (?, ?, 1, ?)
+		ON CONFLICT (kind, key) DO UPDATE SET
+			failures = CASE WHEN login_throttles.last_failure_at < ? THEN 1 ELSE login_throttles.failures + 1 END,
+			last_failure_at = EXCLUDED.last_failure_at
+		RETURNING failures`, kind, key, time.Now(), windowStart).Scan(&failures).Error
+	return failures, err
+}
+
+func (r *LoginRepository) Throttle(ctx context.Context, kind string, key string) (*domain.LoginThrottle, error) {
+	var throttle domain.LoginThrottle
+	err := r.db.WithContext(ctx).Where("kind = ? AND key = ?", kind, key).First(&throttle).Error
+	return &throttle, err
+}
+
+func (r *LoginRepository) ResetFailures(ctx context.Context, kind string, key string) error {
+	return r.db.WithContext(ctx).Where("kind = ? AND key = ?", kind, key).Delete(&domain.LoginThrottle{}).Error
+}
+
+func (r *LoginRepository) LockUser(ctx context.Context, userID uuid.UUID, until *time.Time) error {
+	result := r.db.WithContext(ctx).Model(&domain.User{}).Where("id = ?", userID).Update("locked_until", until)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *LoginRepository) WriteLoginEvent(ctx context.Context, event *domain.LoginEvent) error {
+	return r.db.WithContext(ctx).Create(event).Error
+}
+
+func (r *LoginRepository) LoginEvents(ctx context.Context, userID uuid.UUID, filter domain.LoginEventFilter) ([]*domain.LoginEvent, int64, error) {
+	query := r.db.WithContext(ctx).Model(&domain.LoginEvent{}).Where("user_id = ?", userID)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var events []*domain.LoginEvent
+	err := query.Order("created_at DESC").Limit(filter.Limit).Offset(filter.Offset).Find(&events).Error
+	return events, total, err
+}
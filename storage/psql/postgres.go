@@ -0,0 +1,47 @@
+package psql
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/immxrtalbeast/plandstu/internal/config"
+	"github.com/immxrtalbeast/plandstu/internal/logging"
+	"github.com/immxrtalbeast/plandstu/internal/metrics"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/plugin/opentelemetry/tracing"
+)
+
+// Open подключается к Postgres и настраивает пул соединений.
+func Open(cfg config.DBConfig) (*gorm.DB, error) {
+	db, err := gorm.Open(postgres.New(postgres.Config{
+		DSN:                  cfg.DSN(),
+		PreferSimpleProtocol: cfg.SimpleProtocol(),
+	}), &gorm.Config{TranslateError: true, Logger: logging.NewGormLogger(cfg.SlowQueryThreshold)})
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+	if err := db.Use(metrics.GormPlugin{}); err != nil {
+		return nil, err
+	}
+	if err := db.Use(tracing.NewPlugin(tracing.WithoutMetrics(), tracing.WithoutQueryVariables())); err != nil {
+		return nil, err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+	return db, nil
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// containsPattern строит шаблон для "ILIKE ? ESCAPE '\'", в котором
+// спецсимволы запроса ищутся буквально.
+func containsPattern(query string) string {
+	return "%" + likeEscaper.Replace(query) + "%"
+}
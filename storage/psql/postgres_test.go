@@ -0,0 +1,21 @@
+package psql
+
+import "testing"
+
+func TestContainsPattern(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{query: "go", want: `%go%`},
+		{query: "100%", want: `%100\%%`},
+		{query: "snake_case", want: `%snake\_case%`},
+		{query: `C:\dir`, want: `%C:\\dir%`},
+		{query: `\%_`, want: `%\\\%\_%`},
+	}
+	for _, tt := range tests {
+		if got := containsPattern(tt.query); got != tt.want {
+			t.Errorf("containsPattern(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
@@ -54,46 +54,50 @@ func (r *ReportRepository) UpdateReport(ctx context.Context, report domain.Repor
 	return result.Error
 }
 
+// ReportDisciplines - дисциплины, по которым есть отчёты. Название берётся из
+// справочника, а не из отчёта: в старых отчётах оно могло устареть.
 func (r *ReportRepository) ReportDisciplines(ctx context.Context) ([]domain.DisciplineResponse, error) {
 	var disciplines []domain.DisciplineResponse
 	err := r.db.WithContext(ctx).
 		Model(&domain.Report{}).
-		Select("DISTINCT discipline_title as name, discipline_id as id").
+		Joins("JOIN disciplines ON disciplines.id = reports.discipline_id").
+		Select("DISTINCT disciplines.name as name, disciplines.id as id").
+		Order("disciplines.name").
 		Scan(&disciplines).
 		Error
 
 	return disciplines, err
 }
 
-func (r *ReportRepository) ReportGroups(ctx context.Context, disciplineName string) ([]string, error) {
-	var groups []string
+func (r *ReportRepository) ReportGroups(ctx context.Context, disciplineID int) ([]*domain.Group, error) {
+	var groups []*domain.Group
+	// Группы берутся из справочника, отчёты без ссылки на группу не учитываются
 	err := r.db.WithContext(ctx).
-		Model(&domain.Report{}).
-		Where("discipline_title = ?", disciplineName).
-		Distinct("group").
-		Pluck("group", &groups).
+		Where("id IN (?)", r.db.Model(&domain.Report{}).Select("group_id").Where("discipline_id = ?", disciplineID)).
+		Order("name").
+		Find(&groups).
 		Error
 
 	return groups, err
 }
 
-func (r *ReportRepository) ReportsByGroupAndDiscipline(ctx context.Context, disciplineName string, group string) ([]*domain.Report, *domain.TimelineStat, error) {
+func (r *ReportRepository) ReportsByGroupAndDiscipline(ctx context.Context, disciplineID int, groupID uuid.UUID) ([]*domain.Report, *domain.TimelineStat, error) {
 	var reports []*domain.Report
 	err := r.db.WithContext(ctx).
-		Where(`discipline_title = ? AND "group" = ?`, disciplineName, group).
+		Where("discipline_id = ? AND group_id = ?", disciplineID, groupID).
 		Find(&reports).
 		Error
 	if err != nil {
 		return nil, nil, fmt.Errorf("%w", err)
 	}
-	stats, err := r.GetTimelineStats(ctx, disciplineName, group)
+	stats, err := r.GetTimelineStats(ctx, disciplineID, groupID)
 	return reports, stats, err
 }
-func (r *ReportRepository) GetTimelineStats(ctx context.Context, discipline, group string) (*domain.TimelineStat, error) {
+func (r *ReportRepository) GetTimelineStats(ctx context.Context, disciplineID int, groupID uuid.UUID) (*domain.TimelineStat, error) {
 	// Получаем все отчеты из базы данных
 	var reports []domain.Report
 	err := r.db.WithContext(ctx).
-		Where("discipline_title = ? AND \"group\" = ?", discipline, group).
+		Where("discipline_id = ? AND group_id = ?", disciplineID, groupID).
 		Find(&reports).Error
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch reports: %w", err)
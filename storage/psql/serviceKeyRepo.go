@@ -0,0 +1,69 @@
+package psql
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/immxrtalbeast/plandstu/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ServiceKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewServiceKeyRepository(db *gorm.DB) *ServiceKeyRepository {
+	return &ServiceKeyRepository{db: db}
+}
+
+func (r *ServiceKeyRepository) CreateServiceKey(ctx context.Context, key *domain.ServiceKey) error {
+	return r.db.WithContext(ctx).Create(key).Error
+}
+
+func (r *ServiceKeyRepository) ServiceKey(ctx context.Context, id uuid.UUID) (*domain.ServiceKey, error) {
+	var key domain.ServiceKey
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&key).Error
+	return &key, err
+}
+
+func (r *ServiceKeyRepository) ServiceKeys(ctx context.Context) ([]*domain.ServiceKey, error) {
+	var keys []*domain.ServiceKey
+	err := r.db.WithContext(ctx).Order("created_at DESC").Find(&keys).Error
+	return keys, err
+}
+
+func (r *ServiceKeyRepository) RevokeServiceKey(ctx context.Context, id uuid.UUID, at time.Time) error {
+	result := r.db.WithContext(ctx).Model(&domain.ServiceKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", at)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *ServiceKeyRepository) TouchServiceKey(ctx context.Context, id uuid.UUID, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&domain.ServiceKey{}).Where("id = ?", id).Update("last_used_at", at).Error
+}
+
+func (r *ServiceKeyRepository) UpdateServiceKeySecret(ctx context.Context, id uuid.UUID, secret string) error {
+	return r.db.WithContext(ctx).Model(&domain.ServiceKey{}).Where("id = ?", id).Update("secret", secret).Error
+}
+
+func (r *ServiceKeyRepository) UseNonce(ctx context.Context, nonce *domain.ServiceNonce) (bool, error) {
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(nonce)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *ServiceKeyRepository) PurgeNonces(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("expires_at < ?", before).Delete(&domain.ServiceNonce{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,87 @@
+package psql
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/immxrtalbeast/plandstu/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SessionRepository struct {
+	db *gorm.DB
+}
+
+func NewSessionRepository(db *gorm.DB) *SessionRepository {
+	return &SessionRepository{db: db}
+}
+
+func (r *SessionRepository) CreateSession(ctx context.Context, session *domain.RefreshSession) error {
+	return r.db.WithContext(ctx).Create(session).Error
+}
+
+func (r *SessionRepository) SessionByHash(ctx context.Context, tokenHash string) (*domain.RefreshSession, error) {
+	var session domain.RefreshSession
+	err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&session).Error
+	return &session, err
+}
+
+// RotateSession атомарно отзывает старую сессию и создаёт следующую.
+// Если старая сессия уже отозвана параллельным запросом, возвращает gorm.ErrRecordNotFound.
+func (r *SessionRepository) RotateSession(ctx context.Context, oldID uuid.UUID, next *domain.RefreshSession) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(next).Error; err != nil {
+			return err
+		}
+		result := tx.Model(&domain.RefreshSession{}).
+			Where("id = ? AND revoked_at IS NULL", oldID).
+			Updates(map[string]any{"revoked_at": time.Now(), "replaced_by": next.ID})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+func (r *SessionRepository) RevokeSession(ctx context.Context, sessionID uuid.UUID) error {
+	return r.db.WithContext(ctx).Model(&domain.RefreshSession{}).
+		Where("id = ? AND revoked_at IS NULL", sessionID).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (r *SessionRepository) RevokeUserSessions(ctx context.Context, userID uuid.UUID) error {
+	now := time.Now()
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&domain.RefreshSession{}).
+			Where("user_id = ? AND revoked_at IS NULL", userID).
+			Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+		// iat в токенах с точностью до секунды, поэтому и границу храним так же
+		return tx.Model(&domain.User{}).Where("id = ?", userID).
+			Update("tokens_valid_after", now.Truncate(time.Second)).Error
+	})
+}
+
+func (r *SessionRepository) RevokeToken(ctx context.Context, token domain.RevokedToken) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&token).Error
+}
+
+func (r *SessionRepository) TokenRevoked(ctx context.Context, jti string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&domain.RevokedToken{}).
+		Where("jti = ? AND expires_at > ?", jti, time.Now()).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// PurgeRevokedTokens удаляет отозванные токены, срок действия которых истёк до before.
+func (r *SessionRepository) PurgeRevokedTokens(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("expires_at < ?", before).Delete(&domain.RevokedToken{})
+	return result.RowsAffected, result.Error
+}
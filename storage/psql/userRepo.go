@@ -35,3 +35,85 @@ func (r *UserRepository) UserByLogin(ctx context.Context, login string) (*domain
 	err := r.db.Where("login = ?", login).First(&user).Error
 	return &user, err
 }
+
+func (r *UserRepository) UserByEmail(ctx context.Context, email string) (*domain.User, error) {
+	var user domain.User
+	err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error
+	return &user, err
+}
+
+func (r *UserRepository) Users(ctx context.Context, filter domain.UserFilter) ([]*domain.User, int64, error) {
+	query := r.db.WithContext(ctx).Model(&domain.User{})
+	if filter.Query != "" {
+		like := containsPattern(filter.Query)
+		query = query.Where(`login ILIKE ? ESCAPE '\' OR "group" ILIKE ? ESCAPE '\'`, like, like)
+	}
+	if filter.Role != "" {
+		query = query.Where("role = ?", filter.Role)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var users []*domain.User
+	err := query.Order("login").Limit(filter.Limit).Offset(filter.Offset).Find(&users).Error
+	return users, total, err
+}
+
+func (r *UserRepository) UpdateRole(ctx context.Context, userID uuid.UUID, role string) error {
+	return r.updateColumn(ctx, userID, "role", role)
+}
+
+func (r *UserRepository) SetDisabled(ctx context.Context, userID uuid.UUID, disabled bool) error {
+	return r.updateColumn(ctx, userID, "disabled", disabled)
+}
+
+func (r *UserRepository) UpdatePassword(ctx context.Context, userID uuid.UUID, passHash []byte) error {
+	return r.updateColumn(ctx, userID, "pass_hash", passHash)
+}
+
+// UpdateProfile обновляет профиль. При moveReports отчёты студента переносятся
+// в его новую группу, чтобы статистика групп не расходилась с профилем.
+func (r *UserRepository) UpdateProfile(ctx context.Context, user *domain.User, moveReports bool) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&domain.User{}).
+			Where("id = ?", user.ID).
+			Select("faculty", "direction", "group", "group_id", "email").
+			Updates(user)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if !moveReports {
+			return nil
+		}
+		return tx.Model(&domain.Report{}).
+			Where("user_id = ?", user.ID).
+			Updates(map[string]any{"group_id": user.GroupID, "group": user.Group}).
+			Error
+	})
+}
+
+func (r *UserRepository) DeleteUser(ctx context.Context, userID uuid.UUID) error {
+	result := r.db.WithContext(ctx).Where("id = ?", userID).Delete(&domain.User{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *UserRepository) updateColumn(ctx context.Context, userID uuid.UUID, column string, value any) error {
+	result := r.db.WithContext(ctx).Model(&domain.User{}).Where("id = ?", userID).Update(column, value)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}